@@ -0,0 +1,318 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+// Tool arguments, filled in by bindArgs. The shared ones are embedded by
+// the argument structs of the data tools.
+type (
+	profileArg struct {
+		Profile string `arg:"profile"`
+	}
+
+	unitsArg struct {
+		Units string `arg:"units" validate:"oneof=metric imperial"`
+	}
+
+	// recordArgs are the arguments of tools without parameters of their own.
+	recordArgs struct {
+		profileArg
+		unitsArg
+	}
+
+	listArgs struct {
+		profileArg
+		unitsArg
+		Start      string `arg:"start" validate:"time"`
+		End        string `arg:"end" validate:"time"`
+		Limit      int    `arg:"limit" validate:"min=1,max=25" default:"10"`
+		NextToken  string `arg:"next_token"`
+		ScoredOnly bool   `arg:"scored_only"`
+		Format     string `arg:"format" validate:"oneof=json compact markdown"`
+		Fields     string `arg:"fields"`
+	}
+
+	cycleIDArgs struct {
+		profileArg
+		unitsArg
+		CycleID int64 `arg:"cycle_id" validate:"required,min=1"`
+	}
+
+	sleepIDArgs struct {
+		profileArg
+		unitsArg
+		SleepID string `arg:"sleep_id" validate:"required,uuid"`
+	}
+
+	workoutIDArgs struct {
+		profileArg
+		unitsArg
+		WorkoutID string `arg:"workout_id" validate:"required,uuid"`
+	}
+
+	activityMappingArgs struct {
+		profileArg
+		ActivityV1ID int64 `arg:"activity_v1_id" validate:"required,min=1"`
+	}
+
+	authorizeArgs struct {
+		profileArg
+		Scopes string `arg:"scopes"`
+	}
+
+	logoutArgs struct {
+		profileArg
+		Confirm bool `arg:"confirm" validate:"required"`
+	}
+
+	switchProfileArgs struct {
+		Profile string `arg:"profile" validate:"required"`
+	}
+
+	doctorArgs struct {
+		profileArg
+		Offline bool `arg:"offline"`
+	}
+)
+
+// timeRange parses the start and end arguments.
+func (a listArgs) timeRange(loc *time.Location) (whoop.TimeRange, error) {
+	return whoop.ParseTimeRange(a.Start, a.End, loc, time.Now())
+}
+
+// fieldError is a problem with one tool argument.
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// bindArgs copies tool arguments onto dst, a pointer to a struct, and
+// validates them. Fields, including those of embedded structs, are matched
+// by their arg tag; a default tag gives the value of an absent argument.
+// The validate tag is a comma-separated list of:
+//
+//	required      the argument must be present and not empty
+//	min=N, max=N  bounds of an integer
+//	oneof=a b c   allowed values, matched case-insensitively
+//	uuid          a UUID
+//	time          a time accepted by whoop.ParseTimeRange
+//
+// Integers must be whole numbers; numeric strings are accepted. Every
+// problem, including unknown arguments, is reported in one argumentError.
+func bindArgs(args map[string]interface{}, dst interface{}) error {
+	v := reflect.ValueOf(dst).Elem()
+	if m, ok := v.Addr().Interface().(*map[string]interface{}); ok {
+		*m = args
+		return nil
+	}
+
+	var problems []fieldError
+	known := make(map[string]bool)
+	bindFields(v, args, known, &problems)
+
+	var unknown []string
+	for name := range args {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		problems = append(problems, fieldError{Field: name, Message: "unknown argument"})
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(problems))
+	for i, p := range problems {
+		msgs[i] = p.Field + ": " + p.Message
+	}
+	return &argumentError{
+		msg:    "Invalid arguments: " + strings.Join(msgs, "; "),
+		fields: problems,
+	}
+}
+
+func bindFields(v reflect.Value, args map[string]interface{}, known map[string]bool, problems *[]fieldError) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			bindFields(v.Field(i), args, known, problems)
+			continue
+		}
+		name := field.Tag.Get("arg")
+		if name == "" {
+			continue
+		}
+		known[name] = true
+
+		raw, present := args[name]
+		if raw == nil {
+			present = false
+		}
+		if !present {
+			raw = field.Tag.Get("default")
+		}
+		if msg := bindField(v.Field(i), raw, present, field.Tag.Get("validate")); msg != "" {
+			*problems = append(*problems, fieldError{Field: name, Message: msg})
+		}
+	}
+}
+
+// bindField sets one field and returns what is wrong with the value, if
+// anything. raw is the field's default when the argument is absent.
+func bindField(v reflect.Value, raw interface{}, present bool, rules string) string {
+	switch v.Kind() {
+	case reflect.String:
+		s, ok := raw.(string)
+		if !ok {
+			return fmt.Sprintf("must be a string, got %s", describeArg(raw))
+		}
+		v.SetString(strings.TrimSpace(s))
+	case reflect.Int, reflect.Int64:
+		if s, ok := raw.(string); ok && s == "" && !present {
+			break
+		}
+		n, msg := intArg(raw)
+		if msg != "" {
+			return msg
+		}
+		v.SetInt(n)
+	case reflect.Bool:
+		switch b := raw.(type) {
+		case bool:
+			v.SetBool(b)
+		case string:
+			if b == "" && !present {
+				break
+			}
+			parsed, err := strconv.ParseBool(b)
+			if err != nil {
+				return fmt.Sprintf("must be true or false, got %s", describeArg(raw))
+			}
+			v.SetBool(parsed)
+		default:
+			return fmt.Sprintf("must be true or false, got %s", describeArg(raw))
+		}
+	}
+
+	for _, rule := range strings.Split(rules, ",") {
+		if rule == "" {
+			continue
+		}
+		if msg := checkRule(v, rule, present); msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
+
+func checkRule(v reflect.Value, rule string, present bool) string {
+	name, param, _ := strings.Cut(rule, "=")
+	if name == "required" {
+		if !present || (v.Kind() == reflect.String && v.String() == "") {
+			return "is required"
+		}
+		return ""
+	}
+	if v.IsZero() && !present {
+		return ""
+	}
+
+	switch name {
+	case "min":
+		limit, _ := strconv.Atoi(param)
+		if v.Int() < int64(limit) {
+			return fmt.Sprintf("must be at least %d, got %d", limit, v.Int())
+		}
+	case "max":
+		limit, _ := strconv.Atoi(param)
+		if v.Int() > int64(limit) {
+			return fmt.Sprintf("must be at most %d, got %d", limit, v.Int())
+		}
+	case "oneof":
+		if v.String() == "" {
+			return ""
+		}
+		options := strings.Fields(param)
+		for _, option := range options {
+			if strings.EqualFold(v.String(), option) {
+				v.SetString(option)
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of %s, got %q", strings.Join(options, ", "), v.String())
+	case "uuid":
+		if !whoop.IsValidUUID(v.String()) {
+			return fmt.Sprintf("must be a UUID such as 89329a72-94e7-486c-a072-342501371575, got %q", v.String())
+		}
+	case "time":
+		if v.String() == "" {
+			return ""
+		}
+		if err := whoop.CheckTimeExpr(v.String()); err != nil {
+			return err.Error()
+		}
+	}
+	return ""
+}
+
+// maxExactInt is the largest magnitude up to which a JSON number decoded as
+// float64 still holds every integer exactly.
+const maxExactInt = 1 << 53
+
+// intArg converts a JSON number or numeric string to an int64. Numbers
+// beyond maxExactInt may already have been rounded and are rejected;
+// larger IDs can be passed as strings.
+func intArg(raw interface{}) (int64, string) {
+	switch n := raw.(type) {
+	case float64:
+		if n != math.Trunc(n) {
+			return 0, fmt.Sprintf("must be a whole number, got %v", n)
+		}
+		if math.Abs(n) > maxExactInt {
+			return 0, fmt.Sprintf("must be within ±%d when passed as a number (pass larger values as a string), got %.0f", int64(maxExactInt), n)
+		}
+		return int64(n), ""
+	case int:
+		return int64(n), ""
+	case int64:
+		return n, ""
+	case string:
+		i, err := strconv.ParseInt(strings.TrimSpace(n), 10, 64)
+		if err != nil {
+			return 0, fmt.Sprintf("must be an integer, got %s", describeArg(raw))
+		}
+		return i, ""
+	default:
+		return 0, fmt.Sprintf("must be an integer, got %s", describeArg(raw))
+	}
+}
+
+func describeArg(raw interface{}) string {
+	switch v := raw.(type) {
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case float64:
+		return fmt.Sprintf("number %v", v)
+	case bool:
+		return fmt.Sprintf("boolean %v", v)
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	default:
+		return fmt.Sprintf("%T", raw)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestBindArgs(t *testing.T) {
+	t.Run("defaults and normalization", func(t *testing.T) {
+		var args listArgs
+		err := bindArgs(map[string]interface{}{
+			"format":      "Markdown",
+			"units":       "IMPERIAL",
+			"scored_only": "true",
+			"start":       " 7d ",
+			"profile":     nil,
+		}, &args)
+		if err != nil {
+			t.Fatalf("bindArgs() error = %v", err)
+		}
+		if args.Limit != 10 || args.Format != "markdown" || args.Units != "imperial" || !args.ScoredOnly || args.Start != "7d" {
+			t.Errorf("unexpected args %+v", args)
+		}
+	})
+
+	t.Run("64-bit IDs", func(t *testing.T) {
+		var args cycleIDArgs
+		if err := bindArgs(map[string]interface{}{"cycle_id": float64(4_294_967_296)}, &args); err != nil || args.CycleID != 4_294_967_296 {
+			t.Errorf("got %+v, %v", args, err)
+		}
+	})
+
+	t.Run("numeric strings", func(t *testing.T) {
+		var args cycleIDArgs
+		if err := bindArgs(map[string]interface{}{"cycle_id": "93845"}, &args); err != nil || args.CycleID != 93845 {
+			t.Errorf("got %+v, %v", args, err)
+		}
+	})
+
+	tests := []struct {
+		name   string
+		args   map[string]interface{}
+		dst    interface{}
+		fields []string
+	}{
+		{name: "missing required", args: nil, dst: &cycleIDArgs{}, fields: []string{"cycle_id: is required"}},
+		{name: "string for integer", args: map[string]interface{}{"cycle_id": "abc"}, dst: &cycleIDArgs{}, fields: []string{`cycle_id: must be an integer, got string "abc"`}},
+		{name: "fraction", args: map[string]interface{}{"cycle_id": 1.9}, dst: &cycleIDArgs{}, fields: []string{"cycle_id: must be a whole number, got 1.9"}},
+		{name: "zero ID", args: map[string]interface{}{"cycle_id": float64(0)}, dst: &cycleIDArgs{}, fields: []string{"cycle_id: must be at least 1, got 0"}},
+		{name: "invalid UUID", args: map[string]interface{}{"sleep_id": "123"}, dst: &sleepIDArgs{}, fields: []string{"sleep_id: must be a UUID"}},
+		{name: "empty UUID", args: map[string]interface{}{"workout_id": ""}, dst: &workoutIDArgs{}, fields: []string{"workout_id: is required"}},
+		{name: "missing confirmation", args: nil, dst: &logoutArgs{}, fields: []string{"confirm: is required"}},
+		{name: "confirmation not a boolean", args: map[string]interface{}{"confirm": "yes"}, dst: &logoutArgs{}, fields: []string{`confirm: must be true or false, got string "yes"`}},
+		{name: "profile not a string", args: map[string]interface{}{"profile": true}, dst: &switchProfileArgs{}, fields: []string{"profile: must be a string, got boolean true"}},
+		{name: "unknown doctor argument", args: map[string]interface{}{"offline": true, "verbose": true}, dst: &doctorArgs{}, fields: []string{"verbose: unknown argument"}},
+		{name: "scopes not a string", args: map[string]interface{}{"scopes": []interface{}{"read:sleep"}}, dst: &authorizeArgs{}, fields: []string{"scopes: must be a string, got an array"}},
+		{
+			name: "every problem at once",
+			args: map[string]interface{}{
+				"limit":   float64(500),
+				"start":   "last tuesday",
+				"format":  "xml",
+				"units":   "furlongs",
+				"profile": 3.0,
+				"limt":    5.0,
+			},
+			dst: &listArgs{},
+			fields: []string{
+				"profile: must be a string, got number 3",
+				`units: must be one of metric, imperial, got "furlongs"`,
+				`start: unrecognized time "last tuesday"`,
+				"limit: must be at most 25, got 500",
+				`format: must be one of json, compact, markdown, got "xml"`,
+				"limt: unknown argument",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := bindArgs(tt.args, tt.dst)
+			argErr, ok := err.(*argumentError)
+			if !ok {
+				t.Fatalf("expected *argumentError, got %v", err)
+			}
+			if len(argErr.fields) != len(tt.fields) {
+				t.Fatalf("got %d problems %v, want %d", len(argErr.fields), argErr.fields, len(tt.fields))
+			}
+			for i, want := range tt.fields {
+				got := argErr.fields[i].Field + ": " + argErr.fields[i].Message
+				if !strings.HasPrefix(got, want) {
+					t.Errorf("problem %d = %q, want prefix %q", i, got, want)
+				}
+				if !strings.Contains(err.Error(), got) {
+					t.Errorf("message %q does not list %q", err.Error(), got)
+				}
+			}
+		})
+	}
+}
+
+func TestProfileToolValidatesArguments(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	registry, err := newProfileRegistry("id", "secret", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	called := false
+	handler := profileTool(registry, nil, func(ctx context.Context, session *profileSession, args cycleIDArgs) (interface{}, error) {
+		called = true
+		return nil, nil
+	})
+
+	var request mcp.CallToolRequest
+	request.Params.Arguments = map[string]interface{}{"cycle_id": "abc", "units": "furlongs"}
+	result, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("handler() error = %v", err)
+	}
+	if called || !result.IsError {
+		t.Fatal("invalid arguments should fail before the data handler runs")
+	}
+
+	details := result.Meta["error"].(map[string]interface{})
+	fields, _ := details["fields"].([]fieldError)
+	if details["code"] != "validation_error" || len(fields) != 2 {
+		t.Errorf("unexpected error details %v", details)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/xokvictor/whoop-mcp/pkg/auth"
+)
+
+// runLogout implements the "logout" subcommand: revoke the profile's tokens
+// on WHOOP's side and delete the local token file.
+func runLogout(args []string) {
+	fs := flag.NewFlagSet("logout", flag.ExitOnError)
+	profile := fs.String("profile", os.Getenv("WHOOP_PROFILE"), "WHOOP account profile to sign out")
+	_ = fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	creds, err := auth.LoadCredentials(ctx, *profile)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	tokenManager, err := auth.NewProfileTokenManager(creds.ClientID, creds.ClientSecret, *profile)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	result, err := tokenManager.Logout(ctx)
+	if err != nil {
+		fmt.Printf("❌ Logout failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !result.TokenDeleted {
+		fmt.Printf("ℹ️  No stored token for profile %q (%s)\n", result.Profile, tokenManager.TokenPath())
+		return
+	}
+
+	fmt.Printf("✅ Logged out of profile %q\n", result.Profile)
+	fmt.Printf("   Access token revoked:  %v\n", result.AccessRevoked)
+	fmt.Printf("   Refresh token revoked: %v\n", result.RefreshRevoked)
+	fmt.Printf("   Token file deleted:    %s\n", tokenManager.TokenPath())
+	for _, warning := range result.Warnings {
+		fmt.Printf("⚠️  %s\n", warning)
+	}
+}
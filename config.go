@@ -0,0 +1,378 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+	"gopkg.in/yaml.v3"
+
+	"github.com/xokvictor/whoop-mcp/pkg/auth"
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+const (
+	// envConfig names the configuration file, overriding the default path.
+	envConfig = "WHOOP_CONFIG"
+	// defaultConfigFile is read when no file is named, relative to the
+	// home directory. It is optional.
+	defaultConfigFile = ".whoop/config.yaml"
+
+	redactedValue = "[redacted]"
+)
+
+// config is the server configuration. Values come from, lowest precedence
+// first: built-in defaults, the configuration file, environment variables
+// and command-line flags.
+type config struct {
+	// File is the configuration file that was read, empty when none.
+	File string `yaml:"-" json:"file,omitempty"`
+
+	Profile  string `yaml:"profile" json:"profile"`
+	Timezone string `yaml:"timezone" json:"timezone"`
+	Units    string `yaml:"units" json:"units"`
+	LogLevel string `yaml:"log_level" json:"log_level"`
+	// LogFormat is text or json.
+	LogFormat string `yaml:"log_format" json:"log_format"`
+
+	Server  serverConfig  `yaml:"server" json:"server"`
+	Sync    syncConfig    `yaml:"sync" json:"sync"`
+	Client  clientConfig  `yaml:"client" json:"client"`
+	Auth    authConfig    `yaml:"auth" json:"auth"`
+	Tools   toolsConfig   `yaml:"tools" json:"tools"`
+	Privacy privacyConfig `yaml:"privacy" json:"privacy"`
+	Metrics metricsConfig `yaml:"metrics" json:"metrics"`
+	Tracing tracingConfig `yaml:"tracing" json:"tracing"`
+
+	// Cache is not supported: every read goes to WHOOP. The key is parsed
+	// only so that validate can say so.
+	Cache interface{} `yaml:"cache" json:"-"`
+}
+
+type serverConfig struct {
+	// Transport is how clients connect. Only stdio is supported.
+	Transport string `yaml:"transport" json:"transport"`
+	// Listen is not supported, as there is no network transport. The key
+	// is parsed only so that validate can say so.
+	Listen string `yaml:"listen" json:"listen,omitempty"`
+}
+
+type syncConfig struct {
+	// PollInterval is how often subscribed resources are checked for new
+	// WHOOP data; zero disables polling.
+	PollInterval duration `yaml:"poll_interval" json:"poll_interval"`
+}
+
+type clientConfig struct {
+	Timeout      duration `yaml:"timeout" json:"timeout"`
+	MaxRetries   int      `yaml:"max_retries" json:"max_retries"`
+	RetryBackoff duration `yaml:"retry_backoff" json:"retry_backoff"`
+	// MaxRetryWait caps each wait between retries; a rate limit asking for
+	// longer is returned to the caller.
+	MaxRetryWait duration `yaml:"max_retry_wait" json:"max_retry_wait"`
+}
+
+type authConfig struct {
+	ClientID          string  `yaml:"client_id" json:"client_id,omitempty"`
+	ClientSecret      string  `yaml:"client_secret" json:"client_secret,omitempty"`
+	ClientSecretFile  string  `yaml:"client_secret_file" json:"client_secret_file,omitempty"`
+	CredentialHelper  string  `yaml:"credential_helper" json:"credential_helper,omitempty"`
+	BackgroundRefresh bool    `yaml:"background_refresh" json:"background_refresh"`
+	RefreshFraction   float64 `yaml:"refresh_fraction" json:"refresh_fraction"`
+}
+
+// toolsConfig selects the tools to register. Entries are tool names or
+// scopes such as read:body_measurement, which match every tool requiring
+// that scope.
+type toolsConfig struct {
+	// Enabled, when not empty, lists the only tools to register.
+	Enabled []string `yaml:"enabled" json:"enabled,omitempty"`
+	// Disabled lists tools not to register.
+	Disabled []string `yaml:"disabled" json:"disabled,omitempty"`
+}
+
+type privacyConfig struct {
+	// Enabled redacts the user's ID, email and name from every response.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// HideBodyMeasurements, with Enabled, also withholds height, weight and
+	// maximum heart rate.
+	HideBodyMeasurements bool `yaml:"hide_body_measurements" json:"hide_body_measurements"`
+}
+
+type metricsConfig struct {
+	// Listen is the address serving Prometheus metrics at /metrics, such as
+	// 127.0.0.1:9464; empty disables metrics.
+	Listen string `yaml:"listen" json:"listen,omitempty"`
+}
+
+type tracingConfig struct {
+	// Endpoint is the OTLP/HTTP collector receiving spans, such as
+	// http://localhost:4318. When empty, tracing is enabled only by the
+	// standard OTEL_EXPORTER_OTLP_ENDPOINT or
+	// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT variables.
+	Endpoint string `yaml:"endpoint" json:"endpoint,omitempty"`
+}
+
+// duration is a time.Duration written as a Go duration string such as "5m".
+type duration time.Duration
+
+func (d *duration) UnmarshalYAML(value *yaml.Node) error {
+	parsed, err := time.ParseDuration(value.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", value.Line, err)
+	}
+	*d = duration(parsed)
+	return nil
+}
+
+func (d duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// defaultConfig returns the built-in defaults.
+func defaultConfig() config {
+	return config{
+		Profile:   auth.DefaultProfile,
+		LogLevel:  "info",
+		LogFormat: logFormatText,
+		Server:    serverConfig{Transport: "stdio"},
+		Sync:      syncConfig{PollInterval: duration(5 * time.Minute)},
+		Client: clientConfig{
+			Timeout:      duration(30 * time.Second),
+			MaxRetries:   2,
+			RetryBackoff: duration(whoop.DefaultRetryBackoff),
+			MaxRetryWait: duration(whoop.DefaultRetryMaxWait),
+		},
+		Auth: authConfig{RefreshFraction: 0.8},
+	}
+}
+
+// configPath returns the configuration file to read and whether it was
+// named explicitly, by flag or WHOOP_CONFIG, and so must exist.
+func configPath(flagValue string) (string, bool) {
+	if flagValue != "" {
+		return flagValue, true
+	}
+	if path := os.Getenv(envConfig); path != "" {
+		return path, true
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", false
+	}
+	return filepath.Join(home, defaultConfigFile), false
+}
+
+// loadConfig returns the defaults overlaid with the YAML file at path. A
+// missing file is only an error when required is set. Unknown keys are
+// rejected so typos don't go unnoticed.
+func loadConfig(path string, required bool) (config, error) {
+	cfg := defaultConfig()
+	if path == "" {
+		return cfg, nil
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("opening config file: %w", err)
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return cfg, fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	cfg.File = path
+	return cfg, nil
+}
+
+// applyEnv overrides settings with the environment variables that are set.
+// Credential variables are resolved by auth.LoadCredentialsWithConfig.
+func (c *config) applyEnv() {
+	setString := func(key string, dst *string) {
+		if v := os.Getenv(key); v != "" {
+			*dst = v
+		}
+	}
+	setString("WHOOP_PROFILE", &c.Profile)
+	setString("WHOOP_TIMEZONE", &c.Timezone)
+	setString("WHOOP_UNITS", &c.Units)
+	setString("WHOOP_LOG_LEVEL", &c.LogLevel)
+	setString("WHOOP_LOG_FORMAT", &c.LogFormat)
+	setString("WHOOP_METRICS_ADDR", &c.Metrics.Listen)
+
+	c.Sync.PollInterval = duration(envDuration("WHOOP_POLL_INTERVAL", time.Duration(c.Sync.PollInterval)))
+	if v := os.Getenv("WHOOP_BACKGROUND_REFRESH"); v != "" {
+		c.Auth.BackgroundRefresh = envBool("WHOOP_BACKGROUND_REFRESH")
+	}
+	if v := os.Getenv("WHOOP_PRIVACY_MODE"); v != "" {
+		c.Privacy.Enabled = envBool("WHOOP_PRIVACY_MODE")
+	}
+}
+
+// validate checks the settings that are not parsed elsewhere.
+func (c config) validate() error {
+	var problems []string
+	if c.Server.Transport != "stdio" {
+		problems = append(problems, fmt.Sprintf("server.transport: unsupported transport %q, only stdio is available", c.Server.Transport))
+	}
+	if c.Server.Listen != "" {
+		problems = append(problems, "server.listen: not supported, the server only talks over stdio (metrics.listen serves metrics)")
+	}
+	if c.Cache != nil {
+		problems = append(problems, "cache: not supported, WHOOP data is not cached (sync.poll_interval sets how often subscriptions are checked)")
+	}
+	if _, err := parseLogLevel(c.LogLevel); err != nil {
+		problems = append(problems, "log_level: "+err.Error())
+	}
+	if c.LogFormat != logFormatText && c.LogFormat != logFormatJSON {
+		problems = append(problems, fmt.Sprintf("log_format: unknown format %q, use text or json", c.LogFormat))
+	}
+	if c.Sync.PollInterval < 0 {
+		problems = append(problems, "sync.poll_interval: must not be negative")
+	}
+	if c.Client.Timeout < 0 {
+		problems = append(problems, "client.timeout: must not be negative")
+	}
+	if c.Client.MaxRetries < 0 {
+		problems = append(problems, "client.max_retries: must not be negative")
+	}
+	if c.Auth.RefreshFraction <= 0 || c.Auth.RefreshFraction >= 1 {
+		problems = append(problems, "auth.refresh_fraction: must be between 0 and 1, got "+strconv.FormatFloat(c.Auth.RefreshFraction, 'g', -1, 64))
+	}
+	if c.Metrics.Listen != "" {
+		if _, _, err := net.SplitHostPort(c.Metrics.Listen); err != nil {
+			problems = append(problems, fmt.Sprintf("metrics.listen: %v", err))
+		}
+	}
+	if c.Tracing.Endpoint != "" {
+		if u, err := url.Parse(c.Tracing.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("tracing.endpoint: %q is not an http or https URL", c.Tracing.Endpoint))
+		}
+	}
+	for _, list := range []struct {
+		key   string
+		names []string
+	}{{"tools.enabled", c.Tools.Enabled}, {"tools.disabled", c.Tools.Disabled}} {
+		for _, name := range list.names {
+			if _, ok := toolMetadata[name]; !ok && !slices.Contains(whoop.ReadScopes, name) {
+				problems = append(problems, fmt.Sprintf("%s: unknown tool or scope %q", list.key, name))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func parseLogLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("unknown level %q, use debug, info, warn or error", s)
+	}
+	return level, nil
+}
+
+// credentials returns the credential fallbacks from the file.
+func (c config) credentials() auth.CredentialConfig {
+	return auth.CredentialConfig{
+		ClientID:         c.Auth.ClientID,
+		ClientSecret:     c.Auth.ClientSecret,
+		ClientSecretFile: c.Auth.ClientSecretFile,
+		CredentialHelper: c.Auth.CredentialHelper,
+	}
+}
+
+// configureClient applies the timeout and retry policy to a WHOOP client.
+func (c config) configureClient(client *whoop.Client) {
+	client.SetTimeout(time.Duration(c.Client.Timeout))
+	client.SetRetryPolicy(whoop.RetryPolicy{
+		MaxRetries: c.Client.MaxRetries,
+		Backoff:    time.Duration(c.Client.RetryBackoff),
+		MaxWait:    time.Duration(c.Client.MaxRetryWait),
+	})
+}
+
+// redacted returns a copy safe to show to clients, with secrets replaced.
+// The secret file's path and the credential helper's command line are
+// replaced too: both lead to secrets, and a helper's arguments may contain
+// one.
+func (c config) redacted() config {
+	for _, value := range []*string{&c.Auth.ClientSecret, &c.Auth.ClientSecretFile, &c.Auth.CredentialHelper} {
+		if *value != "" {
+			*value = redactedValue
+		}
+	}
+	c.Tools.Enabled = slices.Clone(c.Tools.Enabled)
+	c.Tools.Disabled = slices.Clone(c.Tools.Disabled)
+	return c
+}
+
+// privacy returns the privacy mode options for the data tools.
+func (c config) privacy() privacyOptions {
+	return privacyOptions{
+		redactPII: c.Privacy.Enabled,
+		hideBody:  c.Privacy.Enabled && c.Privacy.HideBodyMeasurements,
+	}
+}
+
+// allowed reports whether the tool is registered under this configuration.
+func (t toolsConfig) allowed(name string) bool {
+	if len(t.Enabled) > 0 && !matchesTool(t.Enabled, name) {
+		return false
+	}
+	return !matchesTool(t.Disabled, name)
+}
+
+// matchesTool reports whether any entry names the tool or a scope it
+// requires.
+func matchesTool(entries []string, name string) bool {
+	for _, entry := range entries {
+		if entry == name || slices.Contains(toolScopes[name], entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// toolEnabled reports whether the tool is registered: it must pass the tool
+// filter, and privacy mode may withhold body measurements.
+func (c config) toolEnabled(name string) bool {
+	if c.privacy().hideBody && slices.Contains(toolScopes[name], whoop.ScopeBodyMeasurement) {
+		return false
+	}
+	return c.Tools.allowed(name)
+}
+
+// removeDisabledTools deletes the tools the configuration turns off and
+// returns their names.
+func removeDisabledTools(s *server.MCPServer, cfg config) []string {
+	var disabled []string
+	for name := range toolMetadata {
+		if !cfg.toolEnabled(name) {
+			disabled = append(disabled, name)
+		}
+	}
+	sort.Strings(disabled)
+	if len(disabled) > 0 {
+		s.DeleteTools(disabled...)
+	}
+	return disabled
+}
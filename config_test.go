@@ -0,0 +1,342 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Run("missing default file", func(t *testing.T) {
+		cfg, err := loadConfig(filepath.Join(t.TempDir(), "config.yaml"), false)
+		if err != nil {
+			t.Fatalf("loadConfig() error = %v", err)
+		}
+		if cfg.File != "" || cfg.Sync.PollInterval != duration(5*time.Minute) {
+			t.Errorf("cfg = %+v, want defaults", cfg)
+		}
+	})
+
+	t.Run("missing named file", func(t *testing.T) {
+		if _, err := loadConfig(filepath.Join(t.TempDir(), "config.yaml"), true); err == nil {
+			t.Error("loadConfig() should fail when a named file does not exist")
+		}
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		cfg, err := loadConfig(writeConfig(t, ""), true)
+		if err != nil {
+			t.Fatalf("loadConfig() error = %v", err)
+		}
+		if cfg.Client.MaxRetries != 2 {
+			t.Errorf("MaxRetries = %d, want default 2", cfg.Client.MaxRetries)
+		}
+	})
+
+	t.Run("values", func(t *testing.T) {
+		path := writeConfig(t, `
+profile: work
+units: imperial
+sync:
+  poll_interval: 90s
+client:
+  timeout: 10s
+  max_retries: 0
+auth:
+  client_id: file-id
+tools:
+  disabled: [whoop_logout]
+`)
+		cfg, err := loadConfig(path, true)
+		if err != nil {
+			t.Fatalf("loadConfig() error = %v", err)
+		}
+		if cfg.File != path || cfg.Profile != "work" || cfg.Units != "imperial" || cfg.Auth.ClientID != "file-id" {
+			t.Errorf("cfg = %+v", cfg)
+		}
+		if cfg.Sync.PollInterval != duration(90*time.Second) || cfg.Client.Timeout != duration(10*time.Second) {
+			t.Errorf("durations = %v, %v", cfg.Sync.PollInterval, cfg.Client.Timeout)
+		}
+		if cfg.Client.MaxRetries != 0 {
+			t.Errorf("MaxRetries = %d, want 0", cfg.Client.MaxRetries)
+		}
+		if cfg.Auth.RefreshFraction != 0.8 {
+			t.Errorf("RefreshFraction = %v, want the default to survive", cfg.Auth.RefreshFraction)
+		}
+	})
+
+	t.Run("unsupported keys", func(t *testing.T) {
+		cfg, err := loadConfig(writeConfig(t, "server:\n  listen: :8080\ncache:\n  ttl: 5m\n"), true)
+		if err != nil {
+			t.Fatalf("loadConfig() error = %v", err)
+		}
+		err = cfg.validate()
+		if err == nil || !strings.Contains(err.Error(), "server.listen") || !strings.Contains(err.Error(), "cache") {
+			t.Errorf("validate() error = %v, want server.listen and cache rejected", err)
+		}
+	})
+
+	for name, content := range map[string]string{
+		"unknown key":      "unit: metric\n",
+		"invalid duration": "sync:\n  poll_interval: soon\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := loadConfig(writeConfig(t, content), true); err == nil {
+				t.Error("loadConfig() should fail")
+			}
+		})
+	}
+}
+
+func TestConfigApplyEnv(t *testing.T) {
+	t.Setenv("WHOOP_PROFILE", "env-profile")
+	t.Setenv("WHOOP_UNITS", "")
+	t.Setenv("WHOOP_TIMEZONE", "")
+	t.Setenv("WHOOP_LOG_LEVEL", "debug")
+	t.Setenv("WHOOP_POLL_INTERVAL", "1m")
+	t.Setenv("WHOOP_BACKGROUND_REFRESH", "")
+
+	cfg := defaultConfig()
+	cfg.Profile = "file-profile"
+	cfg.Units = "imperial"
+	cfg.Auth.BackgroundRefresh = true
+	cfg.applyEnv()
+
+	if cfg.Profile != "env-profile" {
+		t.Errorf("Profile = %q, want env-profile", cfg.Profile)
+	}
+	if cfg.Units != "imperial" {
+		t.Errorf("Units = %q, an unset variable should keep the file value", cfg.Units)
+	}
+	if cfg.LogLevel != "debug" || cfg.Sync.PollInterval != duration(time.Minute) {
+		t.Errorf("LogLevel = %q, PollInterval = %v", cfg.LogLevel, cfg.Sync.PollInterval)
+	}
+	if !cfg.Auth.BackgroundRefresh {
+		t.Error("BackgroundRefresh should keep the file value")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*config)
+		want   string
+	}{
+		{name: "defaults", modify: func(*config) {}},
+		{name: "transport", modify: func(c *config) { c.Server.Transport = "http" }, want: "server.transport"},
+		{name: "listen address", modify: func(c *config) { c.Server.Listen = ":8080" }, want: "server.listen: not supported"},
+		{name: "cache", modify: func(c *config) { c.Cache = map[string]interface{}{"ttl": "5m"} }, want: "cache: not supported"},
+		{name: "log level", modify: func(c *config) { c.LogLevel = "loud" }, want: "log_level"},
+		{name: "retries", modify: func(c *config) { c.Client.MaxRetries = -1 }, want: "client.max_retries"},
+		{name: "refresh fraction", modify: func(c *config) { c.Auth.RefreshFraction = 1.5 }, want: "auth.refresh_fraction"},
+		{name: "unknown tool", modify: func(c *config) { c.Tools.Disabled = []string{"get_cycle"} }, want: `unknown tool or scope "get_cycle"`},
+		{name: "metrics address", modify: func(c *config) { c.Metrics.Listen = "9464" }, want: "metrics.listen"},
+		{name: "tracing endpoint", modify: func(c *config) { c.Tracing.Endpoint = "localhost:4318" }, want: "tracing.endpoint"},
+		{name: "scope", modify: func(c *config) { c.Tools.Enabled = []string{"read:sleep", "get_cycles"} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			tt.modify(&cfg)
+			err := cfg.validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("validate() error = %v, want mention of %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigRedacted(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.Auth.ClientID = "client-id"
+	cfg.Auth.ClientSecret = "s3cret"
+	cfg.Auth.ClientSecretFile = "/run/secrets/whoop"
+	cfg.Auth.CredentialHelper = "pass-helper --token t0ken"
+
+	data, err := json.Marshal(cfg.redacted())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"s3cret", "/run/secrets/whoop", "t0ken"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("redacted config leaks %q: %s", secret, data)
+		}
+	}
+	if !strings.Contains(string(data), `"client_id":"client-id"`) || !strings.Contains(string(data), `"poll_interval":"5m0s"`) {
+		t.Errorf("redacted config = %s", data)
+	}
+	if cfg.Auth.ClientSecret != "s3cret" {
+		t.Error("redacted() must not modify the original")
+	}
+}
+
+func TestRemoveDisabledTools(t *testing.T) {
+	newServer := func() *server.MCPServer {
+		s := server.NewMCPServer("test", "1.0.0")
+		for name := range toolMetadata {
+			s.AddTool(mcp.NewTool(name), nil)
+		}
+		return s
+	}
+	listTools := func(s *server.MCPServer) []string {
+		response := s.HandleMessage(t.Context(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+		result := response.(mcp.JSONRPCResponse).Result.(mcp.ListToolsResult)
+		var names []string
+		for _, tool := range result.Tools {
+			names = append(names, tool.Name)
+		}
+		return names
+	}
+
+	withTools := func(tools toolsConfig) config {
+		cfg := defaultConfig()
+		cfg.Tools = tools
+		return cfg
+	}
+
+	s := newServer()
+	disabled := removeDisabledTools(s, withTools(toolsConfig{Disabled: []string{"whoop_logout", "whoop_authorize"}}))
+	if strings.Join(disabled, ",") != "whoop_authorize,whoop_logout" {
+		t.Errorf("disabled = %v", disabled)
+	}
+	if got := len(listTools(s)); got != len(toolMetadata)-2 {
+		t.Errorf("tools = %d, want %d", got, len(toolMetadata)-2)
+	}
+
+	s = newServer()
+	removeDisabledTools(s, withTools(toolsConfig{Enabled: []string{"get_cycles", "get_sleeps"}, Disabled: []string{"get_sleeps"}}))
+	if got := listTools(s); strings.Join(got, ",") != "get_cycles" {
+		t.Errorf("tools = %v, want only get_cycles", got)
+	}
+
+	t.Run("by scope", func(t *testing.T) {
+		cfg := withTools(toolsConfig{Disabled: []string{"read:sleep"}})
+		want := map[string]bool{"get_sleeps": false, "get_sleep_by_id": false, "get_sleep_for_cycle": false, "get_cycles": true, "whoop_authorize": true}
+		for name, enabled := range want {
+			if cfg.toolEnabled(name) != enabled {
+				t.Errorf("toolEnabled(%q) = %v, want %v", name, !enabled, enabled)
+			}
+		}
+
+		cfg = withTools(toolsConfig{Enabled: []string{"read:recovery"}})
+		if !cfg.toolEnabled("get_recovery_for_cycle") || cfg.toolEnabled("get_cycles") || cfg.toolEnabled("whoop_authorize") {
+			t.Error("enabled scope should register only the tools requiring it")
+		}
+	})
+
+	t.Run("privacy mode", func(t *testing.T) {
+		cfg := defaultConfig()
+		cfg.Privacy.HideBodyMeasurements = true
+		if !cfg.toolEnabled("get_body_measurements") {
+			t.Error("hide_body_measurements applies only with privacy mode enabled")
+		}
+		cfg.Privacy.Enabled = true
+		if cfg.toolEnabled("get_body_measurements") {
+			t.Error("privacy mode should withhold get_body_measurements")
+		}
+		if !cfg.toolEnabled("get_user_profile") {
+			t.Error("privacy mode redacts get_user_profile rather than removing it")
+		}
+	})
+}
+
+func TestDisabledToolsFilterResourcesAndPrompts(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	cfg := defaultConfig()
+	cfg.Tools.Disabled = []string{"read:body_measurement", "read:sleep", "read:recovery"}
+	opts := toolOptions{location: time.UTC, units: whoop.UnitsMetric, enabled: cfg.toolEnabled}
+
+	s := server.NewMCPServer("test", "1.0.0", server.WithResourceCapabilities(false, false), server.WithPromptCapabilities(false))
+	registerRecordResources(s, newResourceTestRegistry(t, srv), opts)
+	registerPrompts(s, newResourceTestRegistry(t, srv), opts)
+
+	call := func(method string, params interface{}) mcp.JSONRPCMessage {
+		request, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.HandleMessage(t.Context(), request)
+	}
+
+	listed := make(map[string]bool)
+	for _, r := range call("resources/list", nil).(mcp.JSONRPCResponse).Result.(mcp.ListResourcesResult).Resources {
+		listed[r.URI] = true
+	}
+	for _, r := range call("resources/templates/list", nil).(mcp.JSONRPCResponse).Result.(mcp.ListResourceTemplatesResult).ResourceTemplates {
+		listed[r.URITemplate] = true
+	}
+	for _, p := range call("prompts/list", nil).(mcp.JSONRPCResponse).Result.(mcp.ListPromptsResult).Prompts {
+		listed[p.Name] = true
+	}
+
+	want := map[string]bool{
+		"whoop://profile":         true,
+		"whoop://latest/cycle":    true,
+		"whoop://cycle/{id}":      true,
+		"whoop://day/{date}":      true,
+		"morning_briefing":        true,
+		"whoop://latest/sleep":    false,
+		"whoop://sleep/{uuid}":    false,
+		"whoop://latest/recovery": false,
+		"sleep_coaching":          false,
+		"illness_check":           false,
+	}
+	for name, registered := range want {
+		if listed[name] != registered {
+			t.Errorf("%s registered = %v, want %v", name, listed[name], registered)
+		}
+	}
+
+	response, ok := call("resources/read", map[string]interface{}{"uri": "whoop://profile"}).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatal("expected whoop://profile to be readable")
+	}
+	text := response.Result.(mcp.ReadResourceResult).Contents[0].(mcp.TextResourceContents).Text
+	if strings.Contains(text, "body_measurements") || slices.Contains(paths, "/v2/user/measurement/body") {
+		t.Errorf("whoop://profile serves body measurements of a disabled scope: %s", text)
+	}
+
+	paths = nil
+	response, ok = call("resources/read", map[string]interface{}{"uri": "whoop://today"}).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatal("expected whoop://today to be readable")
+	}
+	text = response.Result.(mcp.ReadResourceResult).Contents[0].(mcp.TextResourceContents).Text
+	if strings.Contains(text, `"sleeps"`) || strings.Contains(text, `"recoveries"`) || slices.Contains(paths, "/v2/activity/sleep") {
+		t.Errorf("whoop://today serves records of a disabled scope: %s", text)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/xokvictor/whoop-mcp/pkg/auth"
+	"github.com/xokvictor/whoop-mcp/pkg/doctor"
+)
+
+// doctorOptions collects what doctor.Run needs to inspect a profile. cfg is
+// the effective configuration with secrets redacted, and cfgErr the result
+// of validating it.
+func doctorOptions(session *profileSession, creds *auth.Credentials, cfg config, cfgErr error, offline bool) doctor.Options {
+	return doctor.Options{
+		Profile:      session.name,
+		Credentials:  creds,
+		External:     session.external,
+		TokenManager: session.tokenManager,
+		Client:       session.client,
+		ToolScopes:   toolScopes,
+		Offline:      offline,
+		Config:       cfg,
+		ConfigErr:    cfgErr,
+	}
+}
+
+// runDoctor implements "whoop-mcp doctor": print the JSON report for a
+// profile and return the process exit code.
+func runDoctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	configFile := fs.String("config", "", "configuration file (env: WHOOP_CONFIG, default: ~/.whoop/config.yaml)")
+	profile := fs.String("profile", "", "WHOOP account profile to diagnose (env: WHOOP_PROFILE)")
+	offline := fs.Bool("offline", false, "skip the API endpoint probes")
+	_ = fs.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := loadConfig(configPath(*configFile))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		return 1
+	}
+	cfg.applyEnv()
+	if *profile != "" {
+		cfg.Profile = *profile
+	}
+	// An invalid configuration is reported as a failed check rather than
+	// stopping the diagnosis
+	cfgErr := cfg.validate()
+
+	creds, err := auth.LoadCredentialsWithConfig(ctx, cfg.Profile, cfg.credentials())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load credentials: %v\n", err)
+		return 1
+	}
+
+	profiles, err := newProfileRegistry(creds.ClientID, creds.ClientSecret, creds.AccessToken, cfg.Profile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid profile: %v\n", err)
+		return 1
+	}
+	profiles.ConfigureClients(cfg.configureClient)
+	session, err := profiles.Get("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize profile: %v\n", err)
+		return 1
+	}
+
+	report := doctor.Run(ctx, doctorOptions(session, creds, cfg.redacted(), cfgErr, *offline))
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", err)
+		return 1
+	}
+
+	if report.Status == doctor.StatusFail {
+		return 1
+	}
+	return 0
+}
+
+func registerDoctorTool(s *server.MCPServer, profiles *profileRegistry, creds *auth.Credentials, cfg config) {
+	s.AddTool(
+		mcp.NewTool("whoop_doctor",
+			mcp.WithDescription("Diagnose the WHOOP setup for a profile: credential sources, token file permissions, token expiry, granted scopes versus tools, API endpoint reachability and latency, rate-limit headroom, and the OAuth callback port. Returns a JSON report with an ok/warn/fail status per check and the effective server configuration, secrets redacted."),
+			mcp.WithString("profile",
+				mcp.Description(profileArgDescription),
+			),
+			mcp.WithBoolean("offline",
+				mcp.Description("Skip the API endpoint probes (default: false)."),
+			),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var args doctorArgs
+			if err := bindArgs(request.Params.Arguments, &args); err != nil {
+				return errorResult(err), nil
+			}
+
+			session, err := profiles.Resolve(request.Params.Arguments)
+			if err != nil {
+				return errorResult(err), nil
+			}
+
+			// The server does not start with an invalid configuration.
+			return resultFromJSON(doctor.Run(ctx, doctorOptions(session, creds, cfg, nil, args.Offline)))
+		},
+	)
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+const formatDescription = "Output format: json (full records, the default), compact (a column list plus one row of values per record; smallest) or markdown (a table)."
+
+// outputFormat selects how list tools render their records.
+type outputFormat string
+
+const (
+	formatJSON     outputFormat = "json"
+	formatCompact  outputFormat = "compact"
+	formatMarkdown outputFormat = "markdown"
+)
+
+// column is a named value in compact and markdown output. paths are dotted
+// JSON paths into the rendered record; the first one present is used, so a
+// column can cover both the metric and imperial derived value.
+type column struct {
+	name  string
+	paths []string
+}
+
+func col(name string, paths ...string) column {
+	return column{name: name, paths: paths}
+}
+
+// Default columns of each list tool in compact and markdown output.
+var (
+	cycleColumns = []column{
+		col("id", "id"),
+		col("start", "start"),
+		col("end", "end"),
+		col("status", "status"),
+		col("strain", "score.strain"),
+		col("calories_kcal", "derived.calories_kcal"),
+		col("average_heart_rate", "score.average_heart_rate"),
+		col("max_heart_rate", "score.max_heart_rate"),
+		col("duration_hours", "derived.duration_hours"),
+	}
+	sleepColumns = []column{
+		col("id", "id"),
+		col("start", "start"),
+		col("end", "end"),
+		col("nap", "nap"),
+		col("status", "status"),
+		col("total_sleep_hours", "derived.total_sleep_hours"),
+		col("performance_percent", "score.sleep_performance_percentage"),
+		col("efficiency_percent", "score.sleep_efficiency_percentage"),
+		col("slow_wave_sleep_percent", "derived.slow_wave_sleep_percent"),
+		col("rem_sleep_percent", "derived.rem_sleep_percent"),
+		col("respiratory_rate", "score.respiratory_rate"),
+	}
+	recoveryColumns = []column{
+		col("cycle_id", "cycle_id"),
+		col("created_at", "created_at"),
+		col("status", "status"),
+		col("recovery_score", "score.recovery_score"),
+		col("hrv_ms", "score.hrv_rmssd_milli"),
+		col("resting_heart_rate", "score.resting_heart_rate"),
+		col("spo2_percent", "score.spo2_percentage"),
+		col("skin_temp", "derived.skin_temp_celsius", "derived.skin_temp_fahrenheit"),
+	}
+	workoutColumns = []column{
+		col("id", "id"),
+		col("start", "start"),
+		col("sport_name", "sport_name"),
+		col("status", "status"),
+		col("strain", "score.strain"),
+		col("duration_minutes", "derived.duration_minutes"),
+		col("calories_kcal", "derived.calories_kcal"),
+		col("average_heart_rate", "score.average_heart_rate"),
+		col("max_heart_rate", "score.max_heart_rate"),
+		col("distance", "derived.distance_km", "derived.distance_miles"),
+		col("pace", "derived.pace_per_km", "derived.pace_per_mile"),
+	}
+)
+
+// fieldsDescription documents the fields argument for a tool's columns.
+func fieldsDescription(columns []column) string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.name
+	}
+	return fmt.Sprintf("Comma-separated fields to return, in any format. Use column names (%s) or dotted JSON paths such as score.zone_durations. Defaults to all fields for json and to the columns above otherwise.",
+		strings.Join(names, ", "))
+}
+
+// listOutput is a page of records rendered in the requested format. It
+// implements toolRenderer and structuredResult.
+type listOutput struct {
+	format  outputFormat
+	units   whoop.Units
+	columns []column
+	// projected is set when the fields argument narrowed the columns.
+	projected bool
+	page      interface{}
+}
+
+// newListOutput parses the format and fields arguments. Call setPage with
+// the records before returning it from a handler.
+func newListOutput(args listArgs, present presenter, columns []column) (*listOutput, error) {
+	out := &listOutput{format: formatJSON, units: present.units, columns: columns}
+
+	switch f := outputFormat(strings.ToLower(args.Format)); f {
+	case "", formatJSON:
+	case formatCompact, formatMarkdown:
+		out.format = f
+	default:
+		return nil, newArgumentError("format must be json, compact or markdown, got %q", f)
+	}
+
+	if args.Fields != "" {
+		selected, err := selectColumns(columns, args.Fields)
+		if err != nil {
+			return nil, err
+		}
+		out.columns = selected
+		out.projected = true
+	}
+	return out, nil
+}
+
+// setPage attaches the records to render and returns out.
+func (o *listOutput) setPage(page interface{}) *listOutput {
+	o.page = page
+	return o
+}
+
+// selectColumns resolves a comma-separated field list against the known
+// columns; anything else is taken as a dotted JSON path.
+func selectColumns(columns []column, fields string) ([]column, error) {
+	known := make(map[string]column, len(columns))
+	for _, c := range columns {
+		known[c.name] = c
+	}
+
+	var selected []column
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(fields, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" || seen[field] {
+			continue
+		}
+		seen[field] = true
+
+		if c, ok := known[field]; ok {
+			selected = append(selected, c)
+			continue
+		}
+		if strings.Trim(field, "abcdefghijklmnopqrstuvwxyz0123456789_.") != "" ||
+			strings.HasPrefix(field, ".") || strings.HasSuffix(field, ".") || strings.Contains(field, "..") {
+			return nil, newArgumentError("invalid field %q: use a column name or a dotted JSON path", field)
+		}
+		selected = append(selected, col(field, field))
+	}
+	if len(selected) == 0 {
+		return nil, newArgumentError("fields must name at least one field")
+	}
+	return selected, nil
+}
+
+// genericPage is a recordPage decoded back from JSON, so that any record
+// type can be projected by path.
+type genericPage struct {
+	Records   []map[string]interface{} `json:"records"`
+	NextToken *string                  `json:"next_token,omitempty"`
+	Skipped   int                      `json:"skipped_unscored,omitempty"`
+}
+
+// structured returns the full page regardless of format and fields, so
+// that structured content always matches the tool's output schema.
+func (o *listOutput) structured() interface{} {
+	return o.page
+}
+
+func (o *listOutput) render() (*mcp.CallToolResult, error) {
+	if o.format == formatJSON && !o.projected {
+		return resultFromJSON(o.page)
+	}
+
+	page, err := decodePage(o.page)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Serialization error: %v", err)), nil
+	}
+
+	switch o.format {
+	case formatCompact:
+		return o.renderCompact(page)
+	case formatMarkdown:
+		return mcp.NewToolResultText(o.renderMarkdown(page)), nil
+	default:
+		projected := make([]map[string]interface{}, len(page.Records))
+		for i, record := range page.Records {
+			projected[i] = make(map[string]interface{}, len(o.columns))
+			for _, c := range o.columns {
+				projected[i][c.name] = c.value(record)
+			}
+		}
+		page.Records = projected
+		return resultFromJSON(page)
+	}
+}
+
+// renderCompact emits unindented JSON with the column names once and a row
+// of values per record.
+func (o *listOutput) renderCompact(page *genericPage) (*mcp.CallToolResult, error) {
+	out := struct {
+		Units     whoop.Units     `json:"units"`
+		Columns   []string        `json:"columns"`
+		Rows      [][]interface{} `json:"rows"`
+		NextToken *string         `json:"next_token,omitempty"`
+		Skipped   int             `json:"skipped_unscored,omitempty"`
+	}{
+		Units:     o.units,
+		Columns:   make([]string, len(o.columns)),
+		Rows:      make([][]interface{}, len(page.Records)),
+		NextToken: page.NextToken,
+		Skipped:   page.Skipped,
+	}
+	for i, c := range o.columns {
+		out.Columns[i] = c.name
+	}
+	for i, record := range page.Records {
+		row := make([]interface{}, len(o.columns))
+		for j, c := range o.columns {
+			row[j] = c.value(record)
+		}
+		out.Rows[i] = row
+	}
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Serialization error: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+func (o *listOutput) renderMarkdown(page *genericPage) string {
+	var b strings.Builder
+
+	names := make([]string, len(o.columns))
+	separators := make([]string, len(o.columns))
+	for i, c := range o.columns {
+		names[i] = c.name
+		separators[i] = "---"
+	}
+	b.WriteString("| " + strings.Join(names, " | ") + " |\n")
+	b.WriteString("|" + strings.Join(separators, "|") + "|\n")
+
+	for _, record := range page.Records {
+		cells := make([]string, len(o.columns))
+		for i, c := range o.columns {
+			cells[i] = markdownCell(c.value(record))
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+
+	fmt.Fprintf(&b, "\n%d records, units: %s", len(page.Records), o.units)
+	if page.Skipped > 0 {
+		fmt.Fprintf(&b, ", %d unscored skipped", page.Skipped)
+	}
+	b.WriteString(".")
+	if page.NextToken != nil && *page.NextToken != "" {
+		fmt.Fprintf(&b, " More records: next_token=%s", *page.NextToken)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// value returns the first of the column's paths present in record, or nil.
+func (c column) value(record map[string]interface{}) interface{} {
+	for _, path := range c.paths {
+		if v, ok := lookupPath(record, path); ok && v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+func lookupPath(record map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = record
+	for _, key := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// decodePage round-trips a recordPage through JSON, keeping numbers as
+// written so IDs are not reformatted as floats.
+func decodePage(page interface{}) (*genericPage, error) {
+	data, err := json.Marshal(page)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var generic genericPage
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return &generic, nil
+}
+
+func markdownCell(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "-"
+	case string:
+		return strings.ReplaceAll(val, "|", `\|`)
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return "-"
+		}
+		return strings.ReplaceAll(string(data), "|", `\|`)
+	default:
+		return fmt.Sprint(val)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+func testCyclePage() recordPage[cycleView] {
+	start := time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	cycles := []whoop.Cycle{
+		{ID: 1325792966, Start: start, End: &end, ScoreState: whoop.ScoreStateScored, Score: &whoop.CycleScore{Strain: 12.5, Kilojoule: 8368}},
+		{ID: 1325792967, Start: end, ScoreState: whoop.ScoreStatePendingScore},
+	}
+	next := "abc"
+	return labelPage(cycles, &next, false, presenter{units: whoop.UnitsMetric}.cycle)
+}
+
+func renderText(t *testing.T, out *listOutput) string {
+	t.Helper()
+	result, err := out.render()
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("render returned error result: %+v", result.Content)
+	}
+	text, ok := result.Content[0].(mcp.TextContent)
+	if !ok {
+		t.Fatalf("expected text content, got %T", result.Content[0])
+	}
+	return text.Text
+}
+
+func TestListOutputFormats(t *testing.T) {
+	present := presenter{units: whoop.UnitsMetric}
+
+	t.Run("compact", func(t *testing.T) {
+		out, err := newListOutput(listArgs{Format: "compact"}, present, cycleColumns)
+		if err != nil {
+			t.Fatalf("newListOutput: %v", err)
+		}
+		text := renderText(t, out.setPage(testCyclePage()))
+		if strings.Contains(text, "\n") {
+			t.Errorf("compact output should be a single line: %s", text)
+		}
+
+		var decoded struct {
+			Units     string          `json:"units"`
+			Columns   []string        `json:"columns"`
+			Rows      [][]interface{} `json:"rows"`
+			NextToken string          `json:"next_token"`
+		}
+		if err := json.Unmarshal([]byte(text), &decoded); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if len(decoded.Columns) != len(cycleColumns) || len(decoded.Rows) != 2 || decoded.NextToken != "abc" {
+			t.Fatalf("unexpected compact output: %s", text)
+		}
+		if !strings.Contains(text, "1325792966") {
+			t.Errorf("expected ID to keep its integer form: %s", text)
+		}
+		if decoded.Rows[0][5] != 2000.0 {
+			t.Errorf("calories_kcal = %v, want 2000", decoded.Rows[0][5])
+		}
+		if decoded.Rows[1][4] != nil {
+			t.Errorf("pending cycle strain = %v, want null", decoded.Rows[1][4])
+		}
+	})
+
+	t.Run("markdown with fields", func(t *testing.T) {
+		args := listArgs{Format: "markdown", Fields: "start, strain,status"}
+		out, err := newListOutput(args, present, cycleColumns)
+		if err != nil {
+			t.Fatalf("newListOutput: %v", err)
+		}
+		text := renderText(t, out.setPage(testCyclePage()))
+
+		lines := strings.Split(text, "\n")
+		if lines[0] != "| start | strain | status |" || lines[1] != "|---|---|---|" {
+			t.Errorf("unexpected header:\n%s", text)
+		}
+		if lines[2] != "| 2024-01-01T06:00:00Z | 12.5 | scored |" {
+			t.Errorf("unexpected first row: %q", lines[2])
+		}
+		if lines[3] != "| 2024-01-02T06:00:00Z | - | in_progress |" {
+			t.Errorf("unexpected second row: %q", lines[3])
+		}
+		if !strings.Contains(text, "next_token=abc") {
+			t.Errorf("expected next_token in footer:\n%s", text)
+		}
+	})
+
+	t.Run("json with dotted path", func(t *testing.T) {
+		args := listArgs{Fields: "id,score.kilojoule"}
+		out, err := newListOutput(args, present, cycleColumns)
+		if err != nil {
+			t.Fatalf("newListOutput: %v", err)
+		}
+		text := renderText(t, out.setPage(testCyclePage()))
+
+		var decoded genericPage
+		if err := json.Unmarshal([]byte(text), &decoded); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		first := decoded.Records[0]
+		if len(first) != 2 || first["score.kilojoule"] != 8368.0 {
+			t.Errorf("unexpected projection: %v", first)
+		}
+	})
+
+	t.Run("json default is unchanged", func(t *testing.T) {
+		out, err := newListOutput(listArgs{}, present, cycleColumns)
+		if err != nil {
+			t.Fatalf("newListOutput: %v", err)
+		}
+		text := renderText(t, out.setPage(testCyclePage()))
+		if !strings.Contains(text, `"score_state": "SCORED"`) || !strings.Contains(text, `"derived"`) {
+			t.Errorf("expected full indented records:\n%s", text)
+		}
+	})
+}
+
+func TestListOutputArgumentErrors(t *testing.T) {
+	present := presenter{units: whoop.UnitsMetric}
+	tests := []listArgs{
+		{Format: "xml"},
+		{Fields: " , "},
+		{Fields: "score..strain"},
+		{Fields: "Score.Strain"},
+		{Fields: ".strain"},
+	}
+
+	for _, args := range tests {
+		_, err := newListOutput(args, present, cycleColumns)
+		var argErr *argumentError
+		if !errors.As(err, &argErr) {
+			t.Errorf("%v: expected argument error, got %v", args, err)
+		}
+	}
+}
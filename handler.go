@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/xokvictor/whoop-mcp/pkg/auth"
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+// toolScopes lists the scopes each data tool requires.
+var toolScopes = map[string][]string{
+	"get_user_profile":       {whoop.ScopeProfile},
+	"get_body_measurements":  {whoop.ScopeBodyMeasurement},
+	"get_cycles":             {whoop.ScopeCycles},
+	"get_cycle_by_id":        {whoop.ScopeCycles},
+	"get_sleeps":             {whoop.ScopeSleep},
+	"get_sleep_by_id":        {whoop.ScopeSleep},
+	"get_sleep_for_cycle":    {whoop.ScopeSleep, whoop.ScopeCycles},
+	"get_recoveries":         {whoop.ScopeRecovery},
+	"get_recovery_for_cycle": {whoop.ScopeRecovery, whoop.ScopeCycles},
+	"get_workouts":           {whoop.ScopeWorkout},
+	"get_workout_by_id":      {whoop.ScopeWorkout},
+	"get_activity_mapping":   nil,
+}
+
+// dataHandler fetches the data for a tool call on behalf of a resolved
+// profile. args are the tool arguments bound by bindArgs.
+type dataHandler[A any] func(ctx context.Context, session *profileSession, args A) (interface{}, error)
+
+// toolRenderer is implemented by handler results that render themselves
+// instead of being returned as indented JSON.
+type toolRenderer interface {
+	render() (*mcp.CallToolResult, error)
+}
+
+// argumentError is an invalid tool argument. Its message is shown to the
+// caller verbatim.
+type argumentError struct {
+	msg string
+	// fields lists each invalid argument when the error comes from bindArgs.
+	fields []fieldError
+}
+
+func (e *argumentError) Error() string {
+	return e.msg
+}
+
+// Unwrap classifies argument errors as validation failures.
+func (e *argumentError) Unwrap() error {
+	return whoop.ErrValidation
+}
+
+func newArgumentError(format string, args ...interface{}) error {
+	return &argumentError{msg: fmt.Sprintf(format, args...)}
+}
+
+// profileTool adapts a dataHandler into an MCP tool handler: it binds and
+// validates the arguments, resolves the profile, checks that the required
+// scopes were granted, and renders the result or error.
+func profileTool[A any](profiles *profileRegistry, scopes []string, handler dataHandler[A]) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var args A
+		if err := bindArgs(request.Params.Arguments, &args); err != nil {
+			return errorResult(err), nil
+		}
+
+		session, err := profiles.Resolve(request.Params.Arguments)
+		if err != nil {
+			return errorResult(err), nil
+		}
+
+		granted := session.GrantedScopes()
+		if missing := missingScopes(granted, scopes); len(missing) > 0 {
+			return codedErrorResult(whoop.CodeForbiddenScope, missingScopeMessage(session.name, granted, missing), nil), nil
+		}
+
+		data, err := handler(ctx, session, args)
+		if err != nil {
+			// Scopes are unknown for environment tokens and older token
+			// files, so a 403 is the first sign of a missing grant.
+			if errors.Is(err, whoop.ErrForbiddenScope) && len(scopes) > 0 {
+				msg := fmt.Sprintf("Access denied: WHOOP rejected the request (status 403). This tool requires scope %s, which may not have been granted. %s",
+					strings.Join(scopes, ", "), reauthorizeHint(granted, scopes))
+				return codedErrorResult(whoop.CodeForbiddenScope, msg, err), nil
+			}
+
+			return errorResult(err), nil
+		}
+
+		var result *mcp.CallToolResult
+		if r, ok := data.(toolRenderer); ok {
+			result, err = r.render()
+		} else {
+			result, err = resultFromJSON(data)
+		}
+		if err != nil || result.IsError {
+			return result, err
+		}
+		return withStructuredContent(result, data), nil
+	}
+}
+
+// structuredResult is implemented by handler results whose structured
+// content differs from the result itself.
+type structuredResult interface {
+	structured() interface{}
+}
+
+// withStructuredContent attaches data as the result's structured content,
+// which the tool's output schema describes.
+func withStructuredContent(result *mcp.CallToolResult, data interface{}) *mcp.CallToolResult {
+	if s, ok := data.(structuredResult); ok {
+		data = s.structured()
+	}
+	if result.Meta == nil {
+		result.Meta = make(map[string]interface{})
+	}
+	result.Meta[structuredContentKey] = data
+	return result
+}
+
+// GrantedScopes returns the scopes recorded in the profile's token file, or
+// nil when they are unknown (external token or older token file).
+func (p *profileSession) GrantedScopes() []string {
+	if p.external != nil || p.tokenManager == nil {
+		return nil
+	}
+	token, err := p.tokenManager.Load()
+	if err != nil || token == nil || !token.ScopesKnown() {
+		return nil
+	}
+	return token.Scopes
+}
+
+// missingScopes returns the required scopes absent from granted. Unknown
+// (nil) grants are assumed to cover everything.
+func missingScopes(granted, required []string) []string {
+	if granted == nil {
+		return nil
+	}
+	token := auth.Token{Scopes: granted}
+	return token.MissingScopes(required...)
+}
+
+func missingScopeMessage(profile string, granted, missing []string) string {
+	return fmt.Sprintf("Missing scope %s for profile %q. %s",
+		strings.Join(missing, ", "), profile, reauthorizeHint(granted, missing))
+}
+
+// reauthorizeHint tells the user how to obtain the missing scopes without
+// losing the ones already granted.
+func reauthorizeHint(granted, missing []string) string {
+	requested := append(append([]string{}, granted...), missing...)
+	scopes, err := auth.NormalizeScopes(strings.Join(requested, " "))
+	if err != nil {
+		scopes = strings.Join(requested, " ")
+	}
+	return fmt.Sprintf("Re-authorize with whoop_authorize using scopes %q.", scopes)
+}
+
+// scopeNote returns a description suffix for tools whose scopes were not
+// granted to the startup profile, or "" when they are available.
+func scopeNote(granted, required []string) string {
+	missing := missingScopes(granted, required)
+	if len(missing) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" NOTE: unavailable for the active profile until scope %s is granted (use whoop_authorize).", strings.Join(missing, ", "))
+}
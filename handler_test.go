@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/xokvictor/whoop-mcp/pkg/auth"
+)
+
+func TestMissingScopes(t *testing.T) {
+	tests := []struct {
+		name     string
+		granted  []string
+		required []string
+		expected []string
+	}{
+		{"unknown grants", nil, []string{"read:sleep"}, nil},
+		{"all granted", []string{"read:sleep", "read:cycles"}, []string{"read:sleep", "read:cycles"}, nil},
+		{"one missing", []string{"read:sleep"}, []string{"read:sleep", "read:cycles"}, []string{"read:cycles"}},
+		{"no requirements", []string{"read:sleep"}, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := missingScopes(tt.granted, tt.required)
+			if strings.Join(got, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("missingScopes() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReauthorizeHint(t *testing.T) {
+	hint := reauthorizeHint([]string{"read:sleep", "offline"}, []string{"read:body_measurement"})
+
+	expected := `"read:sleep offline read:body_measurement"`
+	if !strings.Contains(hint, expected) {
+		t.Errorf("reauthorizeHint() = %q, want it to contain %s", hint, expected)
+	}
+	if !strings.Contains(hint, "whoop_authorize") {
+		t.Errorf("reauthorizeHint() = %q, should mention whoop_authorize", hint)
+	}
+}
+
+func TestScopeNote(t *testing.T) {
+	if note := scopeNote(nil, []string{"read:sleep"}); note != "" {
+		t.Errorf("scopeNote() with unknown grants = %q, want empty", note)
+	}
+	if note := scopeNote([]string{"read:sleep"}, []string{"read:sleep"}); note != "" {
+		t.Errorf("scopeNote() with granted scope = %q, want empty", note)
+	}
+	if note := scopeNote([]string{"read:sleep"}, []string{"read:body_measurement"}); !strings.Contains(note, "read:body_measurement") {
+		t.Errorf("scopeNote() = %q, should name the missing scope", note)
+	}
+}
+
+func TestProfileToolMissingScope(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	registry, err := newProfileRegistry("id", "secret", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := registry.Get("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.tokenManager.Save(&auth.Token{
+		AccessToken: "access",
+		Scopes:      []string{"read:sleep", "offline"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	called := false
+	handler := profileTool(registry, []string{"read:body_measurement"}, func(ctx context.Context, session *profileSession, args map[string]interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	})
+
+	result, err := handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("handler() error = %v", err)
+	}
+	if called {
+		t.Error("data handler should not run when a scope is missing")
+	}
+	if !result.IsError {
+		t.Fatal("result should be an error")
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "Missing scope read:body_measurement") {
+		t.Errorf("error = %q, should name the missing scope", text)
+	}
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+// Log formats accepted by log_format.
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// sensitiveLogKeys are attributes whose values are never logged.
+var sensitiveLogKeys = map[string]bool{
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"client_secret": true,
+	"authorization": true,
+	"password":      true,
+	"email":         true,
+	"first_name":    true,
+	"last_name":     true,
+}
+
+// Secrets and PII that can appear inside log messages and errors.
+var (
+	bearerPattern = regexp.MustCompile(`(?i)\bbearer\s+[^\s"',]+`)
+	jwtPattern    = regexp.MustCompile(`\beyJ[\w-]+\.[\w-]+\.[\w-]*`)
+	paramPattern  = regexp.MustCompile(`(?i)\b(access_token|refresh_token|client_secret|code)=[^&\s"]+`)
+	emailPattern  = regexp.MustCompile(`[\w.%+-]+@[\w-]+(\.[\w-]+)*\.[A-Za-z]{2,}`)
+)
+
+// redactLogString removes tokens, secrets and email addresses from s.
+func redactLogString(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+redactedValue)
+	s = jwtPattern.ReplaceAllString(s, redactedValue)
+	s = paramPattern.ReplaceAllString(s, "$1="+redactedValue)
+	return emailPattern.ReplaceAllString(s, redactedValue)
+}
+
+// redactLogAttr is a slog ReplaceAttr function that redacts sensitive
+// attributes and scrubs strings and errors.
+func redactLogAttr(_ []string, a slog.Attr) slog.Attr {
+	if sensitiveLogKeys[a.Key] {
+		return slog.String(a.Key, redactedValue)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, redactLogString(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, redactLogString(err.Error()))
+		}
+	}
+	return a
+}
+
+// newLogHandler returns a handler writing redacted records to w in the
+// given format.
+func newLogHandler(w io.Writer, format string, level slog.Leveler) (slog.Handler, error) {
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redactLogAttr}
+	switch format {
+	case logFormatText, "":
+		return slog.NewTextHandler(w, opts), nil
+	case logFormatJSON:
+		return slog.NewJSONHandler(w, opts), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q, use text or json", format)
+	}
+}
+
+// newRequestID returns a random correlation ID for one MCP request.
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// contextHandler adds the request's correlation ID, if any, to records
+// logged with a context.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := whoop.RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// multiHandler sends each record to every handler that accepts its level.
+type multiHandler []slog.Handler
+
+func (m multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range m {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var firstErr error
+	for _, h := range m {
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, r.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (m multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(multiHandler, len(m))
+	for i, h := range m {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (m multiHandler) WithGroup(name string) slog.Handler {
+	handlers := make(multiHandler, len(m))
+	for i, h := range m {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
+
+// mcpLogLevels maps the MCP logging levels (RFC 5424 severities) to slog
+// levels, least severe first.
+var mcpLogLevels = []struct {
+	name  mcp.LoggingLevel
+	level slog.Level
+}{
+	{mcp.LoggingLevelDebug, slog.LevelDebug},
+	{mcp.LoggingLevelInfo, slog.LevelInfo},
+	{mcp.LoggingLevelNotice, slog.LevelInfo + 2},
+	{mcp.LoggingLevelWarning, slog.LevelWarn},
+	{mcp.LoggingLevelError, slog.LevelError},
+	{mcp.LoggingLevelCritical, slog.LevelError + 4},
+	{mcp.LoggingLevelAlert, slog.LevelError + 8},
+	{mcp.LoggingLevelEmergency, slog.LevelError + 12},
+}
+
+// parseMCPLogLevel returns the slog level for an MCP logging level.
+func parseMCPLogLevel(name string) (slog.Level, bool) {
+	for _, l := range mcpLogLevels {
+		if string(l.name) == name {
+			return l.level, true
+		}
+	}
+	return 0, false
+}
+
+// mcpLogLevel returns the MCP logging level of a slog level.
+func mcpLogLevel(level slog.Level) mcp.LoggingLevel {
+	name := mcpLogLevels[0].name
+	for _, l := range mcpLogLevels {
+		if level >= l.level {
+			name = l.name
+		}
+	}
+	return name
+}
+
+// clientLog forwards log records to the MCP client as notifications/message
+// once a sender is attached. The client picks the minimum level with
+// logging/setLevel; until then it receives warnings and errors.
+type clientLog struct {
+	mu    sync.Mutex
+	level slog.Level
+	send  func(params map[string]interface{}) error
+}
+
+func newClientLog() *clientLog {
+	return &clientLog{level: slog.LevelWarn}
+}
+
+// attach sets the function that delivers notifications/message params.
+func (l *clientLog) attach(send func(params map[string]interface{}) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.send = send
+}
+
+// SetLevel sets the minimum level sent to the client.
+func (l *clientLog) SetLevel(level slog.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = level
+}
+
+// handler returns the slog handler that feeds the client log.
+func (l *clientLog) handler() slog.Handler {
+	return &clientLogHandler{log: l}
+}
+
+// clientLogHandler is the slog side of clientLog. Each record becomes one
+// notification whose data holds the message and the redacted attributes.
+type clientLogHandler struct {
+	log    *clientLog
+	attrs  []slog.Attr
+	prefix string
+}
+
+func (h *clientLogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	h.log.mu.Lock()
+	defer h.log.mu.Unlock()
+	return h.log.send != nil && level >= h.log.level
+}
+
+func (h *clientLogHandler) Handle(_ context.Context, r slog.Record) error {
+	data := map[string]interface{}{"message": redactLogString(r.Message)}
+	add := func(prefix string, a slog.Attr) {
+		a = redactLogAttr(nil, a)
+		data[prefix+a.Key] = a.Value.Resolve().Any()
+	}
+	for _, a := range h.attrs {
+		add("", a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		add(h.prefix, a)
+		return true
+	})
+	for key, value := range data {
+		// Durations, times and other values marshal poorly as JSON
+		switch value.(type) {
+		case string, bool, int64, uint64, float64:
+		default:
+			data[key] = fmt.Sprint(value)
+		}
+	}
+
+	h.log.mu.Lock()
+	send := h.log.send
+	h.log.mu.Unlock()
+	if send == nil {
+		return nil
+	}
+	// Failures are not logged: the record would come straight back here.
+	_ = send(map[string]interface{}{
+		"level":  string(mcpLogLevel(r.Level)),
+		"logger": serverName,
+		"data":   data,
+	})
+	return nil
+}
+
+func (h *clientLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	copied := *h
+	copied.attrs = append([]slog.Attr{}, h.attrs...)
+	for _, a := range attrs {
+		copied.attrs = append(copied.attrs, slog.Attr{Key: h.prefix + a.Key, Value: a.Value})
+	}
+	return &copied
+}
+
+func (h *clientLogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	copied := *h
+	copied.prefix = h.prefix + name + "."
+	return &copied
+}
+
+// setupLogging makes slog's default logger, and with it the log package,
+// write to stderr in the configured format and to the MCP client log.
+func setupLogging(cfg config, client *clientLog) error {
+	level, err := parseLogLevel(cfg.LogLevel)
+	if err != nil {
+		return err
+	}
+	stderr, err := newLogHandler(os.Stderr, cfg.LogFormat, level)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(contextHandler{multiHandler{stderr, client.handler()}}))
+	return nil
+}
+
+// fatal logs msg at error level and exits, like log.Fatal.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
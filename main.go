@@ -3,10 +3,17 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
+	_ "time/tzdata" // WHOOP_TIMEZONE must resolve on systems without a zoneinfo database
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -20,37 +27,152 @@ const (
 	serverVersion = "0.1.0"
 )
 
+// Descriptions shared by the start/end arguments of every list tool.
+const (
+	rangeStartDescription = "Start of the range (inclusive): an RFC 3339 timestamp (2024-01-01T06:00:00Z), a date (2024-01-01, in the server timezone), a duration ago (36h, 7d, 2w) or a period (today, yesterday, this_week, last_week, this_month, last_month). A period with no end covers just that period; a date with no end runs until now."
+	rangeEndDescription   = "End of the range (exclusive): same formats as start. A date or period ends after its last day, so end=2024-01-31 includes January 31. Defaults to now."
+)
+
 func main() {
-	// Configure logging to stderr (required for STDIO servers)
-	log.SetOutput(os.Stderr)
-	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
-
-	// Get OAuth credentials from environment
-	clientID := os.Getenv("WHOOP_CLIENT_ID")
-	clientSecret := os.Getenv("WHOOP_CLIENT_SECRET")
-
-	// Initialize TokenManager if credentials are available
-	var tokenManager *auth.TokenManager
-	if clientID != "" && clientSecret != "" {
-		var err error
-		tokenManager, err = auth.NewTokenManager(clientID, clientSecret)
-		if err != nil {
-			log.Printf("Warning: Failed to initialize token manager: %v", err)
+	if len(os.Args) > 1 && os.Args[1] == "doctor" {
+		os.Exit(runDoctor(os.Args[2:]))
+	}
+
+	defaults := defaultConfig()
+	configFlag := flag.String("config", "", "Configuration file (env: WHOOP_CONFIG, default: ~/.whoop/config.yaml)")
+	profileFlag := flag.String("profile", defaults.Profile, "WHOOP account profile to use (env: WHOOP_PROFILE)")
+	backgroundRefresh := flag.Bool("background-refresh", defaults.Auth.BackgroundRefresh, "Refresh stored tokens in the background before they expire (env: WHOOP_BACKGROUND_REFRESH)")
+	refreshFraction := flag.Float64("refresh-fraction", defaults.Auth.RefreshFraction, "Fraction of the token lifetime after which the background refresher renews it")
+	unitsFlag := flag.String("units", defaults.Units, "Default units for derived values in tool output: metric or imperial (env: WHOOP_UNITS)")
+	pollInterval := flag.Duration("poll-interval", time.Duration(defaults.Sync.PollInterval), "How often to check for new WHOOP data while the client has resource subscriptions; 0 disables (env: WHOOP_POLL_INTERVAL)")
+	timezone := flag.String("timezone", defaults.Timezone, "IANA timezone for dates and relative ranges in tool arguments, e.g. Europe/Berlin (env: WHOOP_TIMEZONE, default: system timezone)")
+	privacyMode := flag.Bool("privacy-mode", defaults.Privacy.Enabled, "Redact the user's ID, email and name from every response (env: WHOOP_PRIVACY_MODE)")
+	logLevel := flag.String("log-level", defaults.LogLevel, "Minimum level of log messages on stderr: debug, info, warn or error (env: WHOOP_LOG_LEVEL)")
+	logFormat := flag.String("log-format", defaults.LogFormat, "Format of log messages on stderr: text or json (env: WHOOP_LOG_FORMAT)")
+	otlpEndpoint := flag.String("otlp-endpoint", defaults.Tracing.Endpoint, "OTLP/HTTP endpoint to export traces to, e.g. http://localhost:4318; empty disables unless OTEL_EXPORTER_OTLP_ENDPOINT is set")
+	metricsAddr := flag.String("metrics-addr", defaults.Metrics.Listen, "Address to serve Prometheus metrics on at /metrics, e.g. 127.0.0.1:9464; empty disables (env: WHOOP_METRICS_ADDR)")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Log to stderr (required for STDIO servers) until the configured
+	// handlers are set up
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{ReplaceAttr: redactLogAttr})))
+
+	// Build the effective configuration: file, then environment, then flags
+	cfg, err := loadConfig(configPath(*configFlag))
+	if err != nil {
+		fatal("failed to load configuration", "error", err)
+	}
+	cfg.applyEnv()
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "profile":
+			cfg.Profile = *profileFlag
+		case "background-refresh":
+			cfg.Auth.BackgroundRefresh = *backgroundRefresh
+		case "refresh-fraction":
+			cfg.Auth.RefreshFraction = *refreshFraction
+		case "units":
+			cfg.Units = *unitsFlag
+		case "poll-interval":
+			cfg.Sync.PollInterval = duration(*pollInterval)
+		case "timezone":
+			cfg.Timezone = *timezone
+		case "log-level":
+			cfg.LogLevel = *logLevel
+		case "log-format":
+			cfg.LogFormat = *logFormat
+		case "privacy-mode":
+			cfg.Privacy.Enabled = *privacyMode
+		case "metrics-addr":
+			cfg.Metrics.Listen = *metricsAddr
+		case "otlp-endpoint":
+			cfg.Tracing.Endpoint = *otlpEndpoint
 		}
+	})
+	if err := cfg.validate(); err != nil {
+		fatal("invalid configuration", "error", err)
 	}
 
-	// Initialize WHOOP client with token provider
-	envToken := os.Getenv("WHOOP_ACCESS_TOKEN")
-	var client *whoop.Client
-	if tokenManager != nil {
-		client = whoop.NewClientWithTokenProvider(envToken, tokenManager)
-	} else {
-		client = whoop.NewClientWithToken(envToken)
+	// Log to stderr and, once connected, to the MCP client
+	clientLogs := newClientLog()
+	if err := setupLogging(cfg, clientLogs); err != nil {
+		fatal("invalid logging configuration", "error", err)
+	}
+
+	activeProfile := cfg.Profile
+	if activeProfile == "" {
+		activeProfile = auth.DefaultProfile
+	}
+	if err := auth.ValidateProfileName(activeProfile); err != nil {
+		fatal("invalid profile", "error", err)
+	}
+
+	loc, err := loadLocation(cfg.Timezone)
+	if err != nil {
+		fatal("invalid timezone", "error", err)
+	}
+
+	units, err := whoop.ParseUnits(cfg.Units)
+	if err != nil {
+		fatal("invalid units", "error", err)
+	}
+
+	// Resolve OAuth credentials and any external access token
+	creds, err := auth.LoadCredentialsWithConfig(ctx, activeProfile, cfg.credentials())
+	if err != nil {
+		fatal("failed to load credentials", "error", err)
+	}
+
+	// Initialize per-profile token managers and WHOOP clients
+	profiles, err := newProfileRegistry(creds.ClientID, creds.ClientSecret, creds.AccessToken, activeProfile)
+	if err != nil {
+		fatal("invalid profile", "error", err)
+	}
+	// Export traces when a collector is configured
+	shutdownTracing, err := setupTracing(ctx, cfg.Tracing)
+	if err != nil {
+		fatal("failed to set up tracing", "error", err)
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			slog.Warn("flushing traces failed", "error", err)
+		}
+	}()
+
+	// Record metrics when they are served
+	var appMetrics *metrics
+	if cfg.Metrics.Listen != "" {
+		appMetrics = newMetrics()
+		if err := serveMetrics(ctx, cfg.Metrics.Listen, appMetrics); err != nil {
+			fatal("failed to serve metrics", "error", err)
+		}
+		profiles.ObserveTokens(appMetrics)
+	}
+	profiles.ConfigureClients(func(client *whoop.Client) {
+		cfg.configureClient(client)
+		if appMetrics != nil {
+			client.SetObserver(appMetrics)
+		}
+	})
+	if cfg.Auth.BackgroundRefresh {
+		profiles.EnableBackgroundRefresh(ctx, auth.RefresherConfig{
+			RefreshFraction: cfg.Auth.RefreshFraction,
+			Logger:          slog.Default(),
+		})
 	}
 
 	// Validate token on startup
-	if !client.HasToken() {
-		log.Println("Warning: No authentication configured. Set WHOOP_ACCESS_TOKEN or WHOOP_CLIENT_ID/WHOOP_CLIENT_SECRET.")
+	session, err := profiles.Get("")
+	if err != nil {
+		fatal("failed to initialize profile", "error", err)
+	}
+	if !session.client.HasToken() {
+		slog.Warn("no authentication configured: set WHOOP_ACCESS_TOKEN, WHOOP_ACCESS_TOKEN_FILE, WHOOP_CREDENTIAL_HELPER or WHOOP_CLIENT_ID/WHOOP_CLIENT_SECRET")
 	}
 
 	// Create MCP server
@@ -59,19 +181,37 @@ func main() {
 		serverVersion,
 		server.WithResourceCapabilities(true, false),
 		server.WithPromptCapabilities(false),
+		server.WithLogging(),
 	)
 
 	// Register tools
-	registerTools(s, client)
-	registerAuthTools(s, tokenManager, clientID, clientSecret)
+	opts := toolOptions{location: loc, units: units, privacy: cfg.privacy(), enabled: cfg.toolEnabled}
+	registerTools(s, profiles, opts)
+	registerAuthTools(s, profiles, creds)
+	registerProfileTools(s, profiles)
+	registerDoctorTool(s, profiles, creds, cfg.redacted())
+	if disabled := removeDisabledTools(s, cfg); len(disabled) > 0 {
+		slog.Info("tools disabled by configuration", "tools", strings.Join(disabled, ", "))
+	}
 
-	// Register OAuth configuration resource
+	// Register OAuth configuration and WHOOP record resources
 	registerResources(s)
+	registerRecordResources(s, profiles, opts)
+
+	// Register coaching prompts
+	registerPrompts(s, profiles, opts)
+
+	// Notify subscribers about new WHOOP data
+	subs := newSubscriptions()
+	transport := newStdioTransport(s, subs, clientLogs, appMetrics, os.Stdout)
+	if cfg.Sync.PollInterval > 0 {
+		go newPoller(profiles, subs, transport.Notify, time.Duration(cfg.Sync.PollInterval), opts.enabledKinds(latestKinds...)).Run(ctx)
+	}
 
 	// Start server
-	log.Printf("Starting %s v%s", serverName, serverVersion)
-	if err := server.ServeStdio(s); err != nil {
-		log.Fatalf("Server error: %v", err)
+	slog.Info("starting server", "name", serverName, "version", serverVersion, "profile", profiles.Active())
+	if err := transport.Serve(ctx, os.Stdin); err != nil {
+		fatal("server error", "error", err)
 	}
 }
 
@@ -87,14 +227,7 @@ func registerResources(s *server.MCPServer) {
 		config := map[string]interface{}{
 			"authorization_url": whoop.AuthURL,
 			"token_url":         whoop.TokenURL,
-			"scopes": map[string]string{
-				"read:recovery":         "Read Recovery data (HRV, resting HR, recovery score)",
-				"read:cycles":           "Read physiological cycles (strain, day boundaries)",
-				"read:workout":          "Read workout data (activities, heart rate zones)",
-				"read:sleep":            "Read sleep data (stages, efficiency, duration)",
-				"read:profile":          "Read user profile (name, email)",
-				"read:body_measurement": "Read body measurements (height, weight, max HR)",
-			},
+			"scopes":            whoop.ScopeDescriptions,
 		}
 		data, err := json.Marshal(config)
 		if err != nil {
@@ -112,265 +245,456 @@ func registerResources(s *server.MCPServer) {
 	})
 }
 
-func registerTools(s *server.MCPServer, client *whoop.Client) {
+func registerTools(s *server.MCPServer, profiles *profileRegistry, opts toolOptions) {
+	// Tools whose scopes the startup profile lacks are annotated, not hidden,
+	// since another profile may have them.
+	var granted []string
+	if session, err := profiles.Get(""); err == nil {
+		granted = session.GrantedScopes()
+	}
+
 	// User profile tools
+	profileScopes := toolScopes["get_user_profile"]
 	s.AddTool(
 		mcp.NewTool("get_user_profile",
-			mcp.WithDescription("Get the authenticated user's basic profile information. Returns user ID, email, first name, and last name. Requires scope: read:profile"),
+			mcp.WithDescription("Get the authenticated user's basic profile information. Returns user ID, email, first name, and last name. Requires scope: read:profile"+privacyNote(opts.privacy)+scopeNote(granted, profileScopes)),
+			mcp.WithString("profile",
+				mcp.Description(profileArgDescription),
+			),
 		),
-		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			profile, err := client.GetUserProfile(ctx)
+		profileTool(profiles, profileScopes, func(ctx context.Context, session *profileSession, _ profileArg) (interface{}, error) {
+			present, err := opts.presenter("")
 			if err != nil {
-				return mcp.NewToolResultError(formatError(err)), nil
+				return nil, err
 			}
-			return resultFromJSON(profile)
-		},
+			profile, err := session.client.GetUserProfile(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return present.profile(*profile), nil
+		}),
 	)
 
+	bodyScopes := toolScopes["get_body_measurements"]
 	s.AddTool(
 		mcp.NewTool("get_body_measurements",
-			mcp.WithDescription("Get the user's body measurements including height (meters), weight (kilograms), and maximum heart rate. Requires scope: read:body_measurement"),
+			mcp.WithDescription("Get the user's body measurements including height (meters), weight (kilograms), and maximum heart rate. Requires scope: read:body_measurement"+scopeNote(granted, bodyScopes)),
+			mcp.WithString("units",
+				mcp.Description(unitsDescription),
+				mcp.Enum(string(whoop.UnitsMetric), string(whoop.UnitsImperial)),
+			),
+			mcp.WithString("profile",
+				mcp.Description(profileArgDescription),
+			),
 		),
-		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			measurements, err := client.GetBodyMeasurements(ctx)
+		profileTool(profiles, bodyScopes, func(ctx context.Context, session *profileSession, args recordArgs) (interface{}, error) {
+			present, err := opts.presenter(args.Units)
 			if err != nil {
-				return mcp.NewToolResultError(formatError(err)), nil
+				return nil, err
 			}
-			return resultFromJSON(measurements)
-		},
+			measurement, err := session.client.GetBodyMeasurements(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return present.bodyMeasurement(*measurement), nil
+		}),
 	)
 
 	// Cycle tools
+	cycleScopes := toolScopes["get_cycles"]
 	s.AddTool(
 		mcp.NewTool("get_cycles",
-			mcp.WithDescription("Get the user's physiological cycles. Each cycle represents a day's worth of strain data with start/end times. Returns paginated results with cycle ID, timestamps, strain score, and heart rate data. Requires scope: read:cycles"),
+			mcp.WithDescription("Get the user's physiological cycles. Each cycle represents a day's worth of strain data with start/end times. Returns paginated results with cycle ID, timestamps, strain score, and heart rate data. "+recordStatusDescription+" Requires scope: read:cycles"+scopeNote(granted, cycleScopes)),
 			mcp.WithString("start",
-				mcp.Description("Start date/time in ISO 8601 format (e.g., 2024-01-01T00:00:00Z). Filters cycles starting on or after this time."),
+				mcp.Description(rangeStartDescription),
 			),
 			mcp.WithString("end",
-				mcp.Description("End date/time in ISO 8601 format (e.g., 2024-12-31T23:59:59Z). Filters cycles ending on or before this time."),
+				mcp.Description(rangeEndDescription),
 			),
 			mcp.WithNumber("limit",
 				mcp.Description("Maximum number of records to return (1-25, default: 10). Use with next_token for pagination."),
+				mcp.Min(1),
+				mcp.Max(whoop.MaxLimit),
 			),
 			mcp.WithString("next_token",
 				mcp.Description("Pagination token from previous response. Use to fetch the next page of results."),
 			),
+			mcp.WithBoolean("scored_only",
+				mcp.Description(scoredOnlyDescription),
+			),
+			mcp.WithString("format",
+				mcp.Description(formatDescription),
+				mcp.Enum(string(formatJSON), string(formatCompact), string(formatMarkdown)),
+			),
+			mcp.WithString("fields",
+				mcp.Description(fieldsDescription(cycleColumns)),
+			),
+			mcp.WithString("units",
+				mcp.Description(unitsDescription),
+				mcp.Enum(string(whoop.UnitsMetric), string(whoop.UnitsImperial)),
+			),
+			mcp.WithString("profile",
+				mcp.Description(profileArgDescription),
+			),
 		),
-		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		profileTool(profiles, cycleScopes, func(ctx context.Context, session *profileSession, args listArgs) (interface{}, error) {
+			present, err := opts.presenter(args.Units)
+			if err != nil {
+				return nil, err
+			}
+			out, err := newListOutput(args, present, cycleColumns)
+			if err != nil {
+				return nil, err
+			}
+			timeRange, err := args.timeRange(opts.location)
+			if err != nil {
+				return nil, err
+			}
 			params := whoop.CycleParams{
-				Start:     getStringArg(request.Params.Arguments, "start"),
-				End:       getStringArg(request.Params.Arguments, "end"),
-				Limit:     getIntArg(request.Params.Arguments, "limit", 10),
-				NextToken: getStringArg(request.Params.Arguments, "next_token"),
+				TimeRange: timeRange,
+				Limit:     args.Limit,
+				NextToken: args.NextToken,
 			}
-			cycles, err := client.GetCycles(ctx, params)
+			resp, err := session.client.GetCycles(ctx, params)
 			if err != nil {
-				return mcp.NewToolResultError(formatError(err)), nil
+				return nil, err
 			}
-			return resultFromJSON(cycles)
-		},
+			return out.setPage(labelPage(resp.Records, resp.NextToken, args.ScoredOnly, present.cycle)), nil
+		}),
 	)
 
 	s.AddTool(
 		mcp.NewTool("get_cycle_by_id",
-			mcp.WithDescription("Get a specific physiological cycle by its numeric ID. Returns detailed cycle data including strain, heart rate stats, and timestamps. Requires scope: read:cycles"),
+			mcp.WithDescription("Get a specific physiological cycle by its numeric ID. Returns detailed cycle data including strain, heart rate stats, and timestamps. Requires scope: read:cycles"+scopeNote(granted, cycleScopes)),
 			mcp.WithNumber("cycle_id",
 				mcp.Required(),
 				mcp.Description("The numeric cycle ID (e.g., 1325792966). Can be obtained from get_cycles response."),
+				mcp.Min(1),
+			),
+			mcp.WithString("units",
+				mcp.Description(unitsDescription),
+				mcp.Enum(string(whoop.UnitsMetric), string(whoop.UnitsImperial)),
+			),
+			mcp.WithString("profile",
+				mcp.Description(profileArgDescription),
 			),
 		),
-		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			cycleID := getIntArg(request.Params.Arguments, "cycle_id", 0)
-			if cycleID == 0 {
-				return mcp.NewToolResultError("cycle_id is required and must be a positive integer"), nil
+		profileTool(profiles, cycleScopes, func(ctx context.Context, session *profileSession, args cycleIDArgs) (interface{}, error) {
+			present, err := opts.presenter(args.Units)
+			if err != nil {
+				return nil, err
 			}
-			cycle, err := client.GetCycleByID(ctx, cycleID)
+			record, err := session.client.GetCycleByID(ctx, args.CycleID)
 			if err != nil {
-				return mcp.NewToolResultError(formatError(err)), nil
+				return nil, err
 			}
-			return resultFromJSON(cycle)
-		},
+			return present.cycle(*record), nil
+		}),
 	)
 
 	// Sleep tools
+	sleepScopes := toolScopes["get_sleeps"]
 	s.AddTool(
 		mcp.NewTool("get_sleeps",
-			mcp.WithDescription("Get the user's sleep records. Each record includes sleep stages (light, deep, REM), efficiency percentage, disturbances, and respiratory rate. Requires scope: read:sleep"),
+			mcp.WithDescription("Get the user's sleep records. Each record includes sleep stages (light, deep, REM), efficiency percentage, disturbances, and respiratory rate. "+recordStatusDescription+" Requires scope: read:sleep"+scopeNote(granted, sleepScopes)),
 			mcp.WithString("start",
-				mcp.Description("Start date/time in ISO 8601 format. Filters sleep records starting on or after this time."),
+				mcp.Description(rangeStartDescription),
 			),
 			mcp.WithString("end",
-				mcp.Description("End date/time in ISO 8601 format. Filters sleep records ending on or before this time."),
+				mcp.Description(rangeEndDescription),
 			),
 			mcp.WithNumber("limit",
 				mcp.Description("Maximum number of records to return (1-25, default: 10)."),
+				mcp.Min(1),
+				mcp.Max(whoop.MaxLimit),
 			),
 			mcp.WithString("next_token",
 				mcp.Description("Pagination token from previous response."),
 			),
+			mcp.WithBoolean("scored_only",
+				mcp.Description(scoredOnlyDescription),
+			),
+			mcp.WithString("format",
+				mcp.Description(formatDescription),
+				mcp.Enum(string(formatJSON), string(formatCompact), string(formatMarkdown)),
+			),
+			mcp.WithString("fields",
+				mcp.Description(fieldsDescription(sleepColumns)),
+			),
+			mcp.WithString("units",
+				mcp.Description(unitsDescription),
+				mcp.Enum(string(whoop.UnitsMetric), string(whoop.UnitsImperial)),
+			),
+			mcp.WithString("profile",
+				mcp.Description(profileArgDescription),
+			),
 		),
-		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		profileTool(profiles, sleepScopes, func(ctx context.Context, session *profileSession, args listArgs) (interface{}, error) {
+			present, err := opts.presenter(args.Units)
+			if err != nil {
+				return nil, err
+			}
+			out, err := newListOutput(args, present, sleepColumns)
+			if err != nil {
+				return nil, err
+			}
+			timeRange, err := args.timeRange(opts.location)
+			if err != nil {
+				return nil, err
+			}
 			params := whoop.SleepParams{
-				Start:     getStringArg(request.Params.Arguments, "start"),
-				End:       getStringArg(request.Params.Arguments, "end"),
-				Limit:     getIntArg(request.Params.Arguments, "limit", 10),
-				NextToken: getStringArg(request.Params.Arguments, "next_token"),
+				TimeRange: timeRange,
+				Limit:     args.Limit,
+				NextToken: args.NextToken,
 			}
-			sleeps, err := client.GetSleeps(ctx, params)
+			resp, err := session.client.GetSleeps(ctx, params)
 			if err != nil {
-				return mcp.NewToolResultError(formatError(err)), nil
+				return nil, err
 			}
-			return resultFromJSON(sleeps)
-		},
+			return out.setPage(labelPage(resp.Records, resp.NextToken, args.ScoredOnly, present.sleep)), nil
+		}),
 	)
 
 	s.AddTool(
 		mcp.NewTool("get_sleep_by_id",
-			mcp.WithDescription("Get a specific sleep record by its UUID. Returns detailed sleep data including all stages, efficiency, and performance metrics. Requires scope: read:sleep"),
+			mcp.WithDescription("Get a specific sleep record by its UUID. Returns detailed sleep data including all stages, efficiency, and performance metrics. Requires scope: read:sleep"+scopeNote(granted, sleepScopes)),
 			mcp.WithString("sleep_id",
 				mcp.Required(),
 				mcp.Description("The sleep record UUID (e.g., 89329a72-94e7-486c-a072-342501371575). Can be obtained from get_sleeps response."),
 			),
+			mcp.WithString("units",
+				mcp.Description(unitsDescription),
+				mcp.Enum(string(whoop.UnitsMetric), string(whoop.UnitsImperial)),
+			),
+			mcp.WithString("profile",
+				mcp.Description(profileArgDescription),
+			),
 		),
-		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			sleepID := getStringArg(request.Params.Arguments, "sleep_id")
-			if sleepID == "" {
-				return mcp.NewToolResultError("sleep_id is required and must be a valid UUID"), nil
+		profileTool(profiles, sleepScopes, func(ctx context.Context, session *profileSession, args sleepIDArgs) (interface{}, error) {
+			present, err := opts.presenter(args.Units)
+			if err != nil {
+				return nil, err
 			}
-			sleep, err := client.GetSleepByID(ctx, sleepID)
+			record, err := session.client.GetSleepByID(ctx, args.SleepID)
 			if err != nil {
-				return mcp.NewToolResultError(formatError(err)), nil
+				return nil, err
 			}
-			return resultFromJSON(sleep)
-		},
+			return present.sleep(*record), nil
+		}),
 	)
 
+	sleepCycleScopes := toolScopes["get_sleep_for_cycle"]
 	s.AddTool(
 		mcp.NewTool("get_sleep_for_cycle",
-			mcp.WithDescription("Get the sleep record associated with a specific physiological cycle. Useful for correlating sleep with daily strain. Requires scopes: read:sleep, read:cycles"),
+			mcp.WithDescription("Get the sleep record associated with a specific physiological cycle. Useful for correlating sleep with daily strain. Requires scopes: read:sleep, read:cycles"+scopeNote(granted, sleepCycleScopes)),
 			mcp.WithNumber("cycle_id",
 				mcp.Required(),
 				mcp.Description("The numeric cycle ID to get sleep data for."),
+				mcp.Min(1),
+			),
+			mcp.WithString("units",
+				mcp.Description(unitsDescription),
+				mcp.Enum(string(whoop.UnitsMetric), string(whoop.UnitsImperial)),
+			),
+			mcp.WithString("profile",
+				mcp.Description(profileArgDescription),
 			),
 		),
-		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			cycleID := getIntArg(request.Params.Arguments, "cycle_id", 0)
-			if cycleID == 0 {
-				return mcp.NewToolResultError("cycle_id is required and must be a positive integer"), nil
+		profileTool(profiles, sleepCycleScopes, func(ctx context.Context, session *profileSession, args cycleIDArgs) (interface{}, error) {
+			present, err := opts.presenter(args.Units)
+			if err != nil {
+				return nil, err
 			}
-			sleep, err := client.GetSleepForCycle(ctx, cycleID)
+			record, err := session.client.GetSleepForCycle(ctx, args.CycleID)
 			if err != nil {
-				return mcp.NewToolResultError(formatError(err)), nil
+				return nil, err
 			}
-			return resultFromJSON(sleep)
-		},
+			return present.sleep(*record), nil
+		}),
 	)
 
 	// Recovery tools
+	recoveryScopes := toolScopes["get_recoveries"]
 	s.AddTool(
 		mcp.NewTool("get_recoveries",
-			mcp.WithDescription("Get the user's recovery records. Each record includes recovery score (0-100%), HRV (heart rate variability in ms), resting heart rate, SpO2 percentage, and skin temperature. Requires scope: read:recovery"),
+			mcp.WithDescription("Get the user's recovery records. Each record includes recovery score (0-100%), HRV (heart rate variability in ms), resting heart rate, SpO2 percentage, and skin temperature. "+recordStatusDescription+" Requires scope: read:recovery"+scopeNote(granted, recoveryScopes)),
 			mcp.WithString("start",
-				mcp.Description("Start date/time in ISO 8601 format. Filters recovery records starting on or after this time."),
+				mcp.Description(rangeStartDescription),
 			),
 			mcp.WithString("end",
-				mcp.Description("End date/time in ISO 8601 format. Filters recovery records ending on or before this time."),
+				mcp.Description(rangeEndDescription),
 			),
 			mcp.WithNumber("limit",
 				mcp.Description("Maximum number of records to return (1-25, default: 10)."),
+				mcp.Min(1),
+				mcp.Max(whoop.MaxLimit),
 			),
 			mcp.WithString("next_token",
 				mcp.Description("Pagination token from previous response."),
 			),
+			mcp.WithBoolean("scored_only",
+				mcp.Description(scoredOnlyDescription),
+			),
+			mcp.WithString("format",
+				mcp.Description(formatDescription),
+				mcp.Enum(string(formatJSON), string(formatCompact), string(formatMarkdown)),
+			),
+			mcp.WithString("fields",
+				mcp.Description(fieldsDescription(recoveryColumns)),
+			),
+			mcp.WithString("units",
+				mcp.Description(unitsDescription),
+				mcp.Enum(string(whoop.UnitsMetric), string(whoop.UnitsImperial)),
+			),
+			mcp.WithString("profile",
+				mcp.Description(profileArgDescription),
+			),
 		),
-		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		profileTool(profiles, recoveryScopes, func(ctx context.Context, session *profileSession, args listArgs) (interface{}, error) {
+			present, err := opts.presenter(args.Units)
+			if err != nil {
+				return nil, err
+			}
+			out, err := newListOutput(args, present, recoveryColumns)
+			if err != nil {
+				return nil, err
+			}
+			timeRange, err := args.timeRange(opts.location)
+			if err != nil {
+				return nil, err
+			}
 			params := whoop.RecoveryParams{
-				Start:     getStringArg(request.Params.Arguments, "start"),
-				End:       getStringArg(request.Params.Arguments, "end"),
-				Limit:     getIntArg(request.Params.Arguments, "limit", 10),
-				NextToken: getStringArg(request.Params.Arguments, "next_token"),
+				TimeRange: timeRange,
+				Limit:     args.Limit,
+				NextToken: args.NextToken,
 			}
-			recoveries, err := client.GetRecoveries(ctx, params)
+			resp, err := session.client.GetRecoveries(ctx, params)
 			if err != nil {
-				return mcp.NewToolResultError(formatError(err)), nil
+				return nil, err
 			}
-			return resultFromJSON(recoveries)
-		},
+			return out.setPage(labelPage(resp.Records, resp.NextToken, args.ScoredOnly, present.recovery)), nil
+		}),
 	)
 
+	recoveryCycleScopes := toolScopes["get_recovery_for_cycle"]
 	s.AddTool(
 		mcp.NewTool("get_recovery_for_cycle",
-			mcp.WithDescription("Get the recovery record associated with a specific physiological cycle. Requires scopes: read:recovery, read:cycles"),
+			mcp.WithDescription("Get the recovery record associated with a specific physiological cycle. Requires scopes: read:recovery, read:cycles"+scopeNote(granted, recoveryCycleScopes)),
 			mcp.WithNumber("cycle_id",
 				mcp.Required(),
 				mcp.Description("The numeric cycle ID to get recovery data for."),
+				mcp.Min(1),
+			),
+			mcp.WithString("units",
+				mcp.Description(unitsDescription),
+				mcp.Enum(string(whoop.UnitsMetric), string(whoop.UnitsImperial)),
+			),
+			mcp.WithString("profile",
+				mcp.Description(profileArgDescription),
 			),
 		),
-		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			cycleID := getIntArg(request.Params.Arguments, "cycle_id", 0)
-			if cycleID == 0 {
-				return mcp.NewToolResultError("cycle_id is required and must be a positive integer"), nil
+		profileTool(profiles, recoveryCycleScopes, func(ctx context.Context, session *profileSession, args cycleIDArgs) (interface{}, error) {
+			present, err := opts.presenter(args.Units)
+			if err != nil {
+				return nil, err
 			}
-			recovery, err := client.GetRecoveryForCycle(ctx, cycleID)
+			record, err := session.client.GetRecoveryForCycle(ctx, args.CycleID)
 			if err != nil {
-				return mcp.NewToolResultError(formatError(err)), nil
+				return nil, err
 			}
-			return resultFromJSON(recovery)
-		},
+			return present.recovery(*record), nil
+		}),
 	)
 
 	// Workout tools
+	workoutScopes := toolScopes["get_workouts"]
 	s.AddTool(
 		mcp.NewTool("get_workouts",
-			mcp.WithDescription("Get the user's workout records. Each record includes sport type, strain, heart rate data (average/max), calories burned, duration, and heart rate zone distribution. Requires scope: read:workout"),
+			mcp.WithDescription("Get the user's workout records. Each record includes sport type, strain, heart rate data (average/max), calories burned, duration, and heart rate zone distribution. "+recordStatusDescription+" Requires scope: read:workout"+scopeNote(granted, workoutScopes)),
 			mcp.WithString("start",
-				mcp.Description("Start date/time in ISO 8601 format. Filters workouts starting on or after this time."),
+				mcp.Description(rangeStartDescription),
 			),
 			mcp.WithString("end",
-				mcp.Description("End date/time in ISO 8601 format. Filters workouts ending on or before this time."),
+				mcp.Description(rangeEndDescription),
 			),
 			mcp.WithNumber("limit",
 				mcp.Description("Maximum number of records to return (1-25, default: 10)."),
+				mcp.Min(1),
+				mcp.Max(whoop.MaxLimit),
 			),
 			mcp.WithString("next_token",
 				mcp.Description("Pagination token from previous response."),
 			),
+			mcp.WithBoolean("scored_only",
+				mcp.Description(scoredOnlyDescription),
+			),
+			mcp.WithString("format",
+				mcp.Description(formatDescription),
+				mcp.Enum(string(formatJSON), string(formatCompact), string(formatMarkdown)),
+			),
+			mcp.WithString("fields",
+				mcp.Description(fieldsDescription(workoutColumns)),
+			),
+			mcp.WithString("units",
+				mcp.Description(unitsDescription),
+				mcp.Enum(string(whoop.UnitsMetric), string(whoop.UnitsImperial)),
+			),
+			mcp.WithString("profile",
+				mcp.Description(profileArgDescription),
+			),
 		),
-		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		profileTool(profiles, workoutScopes, func(ctx context.Context, session *profileSession, args listArgs) (interface{}, error) {
+			present, err := opts.presenter(args.Units)
+			if err != nil {
+				return nil, err
+			}
+			out, err := newListOutput(args, present, workoutColumns)
+			if err != nil {
+				return nil, err
+			}
+			timeRange, err := args.timeRange(opts.location)
+			if err != nil {
+				return nil, err
+			}
 			params := whoop.WorkoutParams{
-				Start:     getStringArg(request.Params.Arguments, "start"),
-				End:       getStringArg(request.Params.Arguments, "end"),
-				Limit:     getIntArg(request.Params.Arguments, "limit", 10),
-				NextToken: getStringArg(request.Params.Arguments, "next_token"),
+				TimeRange: timeRange,
+				Limit:     args.Limit,
+				NextToken: args.NextToken,
 			}
-			workouts, err := client.GetWorkouts(ctx, params)
+			resp, err := session.client.GetWorkouts(ctx, params)
 			if err != nil {
-				return mcp.NewToolResultError(formatError(err)), nil
+				return nil, err
 			}
-			return resultFromJSON(workouts)
-		},
+			return out.setPage(labelPage(resp.Records, resp.NextToken, args.ScoredOnly, present.workout)), nil
+		}),
 	)
 
 	s.AddTool(
 		mcp.NewTool("get_workout_by_id",
-			mcp.WithDescription("Get a specific workout by its UUID. Returns detailed workout data including all heart rate zones and metrics. Requires scope: read:workout"),
+			mcp.WithDescription("Get a specific workout by its UUID. Returns detailed workout data including all heart rate zones and metrics. Requires scope: read:workout"+scopeNote(granted, workoutScopes)),
 			mcp.WithString("workout_id",
 				mcp.Required(),
 				mcp.Description("The workout UUID (e.g., 89329a72-94e7-486c-a072-342501371575). Can be obtained from get_workouts response."),
 			),
+			mcp.WithString("units",
+				mcp.Description(unitsDescription),
+				mcp.Enum(string(whoop.UnitsMetric), string(whoop.UnitsImperial)),
+			),
+			mcp.WithString("profile",
+				mcp.Description(profileArgDescription),
+			),
 		),
-		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			workoutID := getStringArg(request.Params.Arguments, "workout_id")
-			if workoutID == "" {
-				return mcp.NewToolResultError("workout_id is required and must be a valid UUID"), nil
+		profileTool(profiles, workoutScopes, func(ctx context.Context, session *profileSession, args workoutIDArgs) (interface{}, error) {
+			present, err := opts.presenter(args.Units)
+			if err != nil {
+				return nil, err
 			}
-			workout, err := client.GetWorkoutByID(ctx, workoutID)
+			record, err := session.client.GetWorkoutByID(ctx, args.WorkoutID)
 			if err != nil {
-				return mcp.NewToolResultError(formatError(err)), nil
+				return nil, err
 			}
-			return resultFromJSON(workout)
-		},
+			return present.workout(*record), nil
+		}),
 	)
 
 	// Utility tools
@@ -380,67 +704,122 @@ func registerTools(s *server.MCPServer, client *whoop.Client) {
 			mcp.WithNumber("activity_v1_id",
 				mcp.Required(),
 				mcp.Description("The legacy V1 Activity ID (numeric). Returns the corresponding V2 UUID."),
+				mcp.Min(1),
+			),
+			mcp.WithString("profile",
+				mcp.Description(profileArgDescription),
 			),
 		),
-		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			activityID := getIntArg(request.Params.Arguments, "activity_v1_id", 0)
-			if activityID == 0 {
-				return mcp.NewToolResultError("activity_v1_id is required and must be a positive integer"), nil
-			}
-			mapping, err := client.GetActivityMapping(ctx, activityID)
-			if err != nil {
-				return mcp.NewToolResultError(formatError(err)), nil
-			}
-			return resultFromJSON(mapping)
-		},
+		profileTool(profiles, toolScopes["get_activity_mapping"], func(ctx context.Context, session *profileSession, args activityMappingArgs) (interface{}, error) {
+			return session.client.GetActivityMapping(ctx, args.ActivityV1ID)
+		}),
 	)
 }
 
-// Helper functions
+// loadLocation resolves an IANA timezone name, defaulting to the system
+// timezone when name is empty.
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
+
+func formatError(err error) string {
+	var argErr *argumentError
+	if errors.As(err, &argErr) {
+		return argErr.Error()
+	}
 
-func getStringArg(args map[string]interface{}, key string) string {
-	if args == nil {
-		return ""
+	if errors.Is(err, whoop.ErrReauthenticationRequired) {
+		return fmt.Sprintf("Authentication failed: WHOOP rejected the access token and it could not be refreshed (%v). Please run whoop_authorize to sign in again.", err)
 	}
-	if val, ok := args[key]; ok {
-		if str, ok := val.(string); ok {
-			return str
+	if errors.Is(err, whoop.ErrRefreshRejected) {
+		return fmt.Sprintf("Authentication failed: the access token expired and WHOOP rejected the refresh token (%v). Please run whoop_authorize to sign in again.", err)
+	}
+
+	var msg string
+	var apiErr *whoop.APIError
+	var rateLimited *whoop.ErrRateLimited
+	switch {
+	case errors.Is(err, whoop.ErrUnauthorized):
+		msg = "Authentication failed: Invalid or expired access token. Please refresh your WHOOP_ACCESS_TOKEN or run whoop_authorize."
+	case errors.Is(err, whoop.ErrForbiddenScope):
+		msg = "Access denied: The access token lacks a scope this request needs. Run whoop_authorize to grant it."
+	case errors.Is(err, whoop.ErrNotFound):
+		msg = "Resource not found: The requested ID does not exist or you don't have access to it."
+	case errors.As(err, &rateLimited):
+		msg = "Rate limited: Too many requests. Please wait a moment and try again."
+		if rateLimited.RetryAfter > 0 {
+			msg = fmt.Sprintf("Rate limited: Too many requests. Please retry after %s.", rateLimited.RetryAfter)
 		}
+	case errors.Is(err, whoop.ErrValidation):
+		msg = fmt.Sprintf("Invalid request: %v", err)
+		if errors.As(err, &apiErr) {
+			msg = fmt.Sprintf("Invalid request: WHOOP rejected it (status %d): %s", apiErr.StatusCode, apiErr.Message)
+		}
+	case errors.Is(err, whoop.ErrUpstream):
+		msg = fmt.Sprintf("WHOOP API unavailable: %v. Please try again later.", err)
+		if errors.As(err, &apiErr) {
+			msg = fmt.Sprintf("WHOOP API unavailable (status %d): %s. Please try again later.", apiErr.StatusCode, apiErr.Message)
+		}
+	case errors.As(err, &apiErr):
+		msg = fmt.Sprintf("WHOOP API error (status %d): %s", apiErr.StatusCode, apiErr.Message)
+	default:
+		return fmt.Sprintf("Error: %v", err)
+	}
+
+	if errors.As(err, &apiErr) && apiErr.RequestID != "" {
+		msg += fmt.Sprintf(" (request ID: %s)", apiErr.RequestID)
 	}
-	return ""
+	return msg
 }
 
-func getIntArg(args map[string]interface{}, key string, defaultVal int) int {
-	if args == nil {
-		return defaultVal
-	}
-	if val, ok := args[key]; ok {
-		switch v := val.(type) {
-		case float64:
-			return int(v)
-		case int:
-			return v
-		case int64:
-			return int(v)
-		}
+// errorResult renders err as a tool error. The text is the human-readable
+// message; _meta.error carries a machine-readable code and request details.
+func errorResult(err error) *mcp.CallToolResult {
+	return codedErrorResult(errorCode(err), formatError(err), err)
+}
+
+// errorCode is whoop.ErrorCode, also classifying argument errors.
+func errorCode(err error) string {
+	var argErr *argumentError
+	if errors.As(err, &argErr) {
+		return whoop.CodeValidation
 	}
-	return defaultVal
+	return whoop.ErrorCode(err)
 }
 
-func formatError(err error) string {
-	if apiErr, ok := err.(*whoop.APIError); ok {
-		switch {
-		case apiErr.IsUnauthorized():
-			return "Authentication failed: Invalid or expired access token. Please refresh your WHOOP_ACCESS_TOKEN."
-		case apiErr.IsNotFound():
-			return "Resource not found: The requested ID does not exist or you don't have access to it."
-		case apiErr.IsRateLimited():
-			return "Rate limited: Too many requests. Please wait a moment and try again."
-		default:
-			return fmt.Sprintf("WHOOP API error (status %d): %s", apiErr.StatusCode, apiErr.Message)
+// codedErrorResult builds a tool error with the given code and message,
+// adding request details from err when it wraps an *APIError.
+func codedErrorResult(code, message string, err error) *mcp.CallToolResult {
+	result := mcp.NewToolResultError(fmt.Sprintf("%s (error code: %s)", message, code))
+
+	details := map[string]interface{}{"code": code}
+	var argErr *argumentError
+	if errors.As(err, &argErr) && len(argErr.fields) > 0 {
+		details["fields"] = argErr.fields
+	}
+	var apiErr *whoop.APIError
+	if errors.As(err, &apiErr) {
+		details["status"] = apiErr.StatusCode
+		if apiErr.Endpoint != "" {
+			details["endpoint"] = strings.TrimSpace(apiErr.Method + " " + apiErr.Endpoint)
+		}
+		if apiErr.RequestID != "" {
+			details["request_id"] = apiErr.RequestID
 		}
+		if apiErr.Code != "" {
+			details["whoop_code"] = apiErr.Code
+		}
+	}
+	var rateLimited *whoop.ErrRateLimited
+	if errors.As(err, &rateLimited) && rateLimited.RetryAfter > 0 {
+		details["retry_after_seconds"] = int(rateLimited.RetryAfter.Seconds())
 	}
-	return fmt.Sprintf("Error: %v", err)
+
+	result.Meta = map[string]interface{}{"error": details}
+	return result
 }
 
 func resultFromJSON(data interface{}) (*mcp.CallToolResult, error) {
@@ -451,27 +830,48 @@ func resultFromJSON(data interface{}) (*mcp.CallToolResult, error) {
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-func registerAuthTools(s *server.MCPServer, tokenManager *auth.TokenManager, clientID, clientSecret string) {
+func registerAuthTools(s *server.MCPServer, profiles *profileRegistry, creds *auth.Credentials) {
+	clientID, clientSecret := creds.ClientID, creds.ClientSecret
+
 	// Auth status tool
 	s.AddTool(
 		mcp.NewTool("whoop_auth_status",
-			mcp.WithDescription("Check the current WHOOP authentication status. Returns whether you're authenticated, token expiry time, and token file location."),
+			mcp.WithDescription("Check the current WHOOP authentication status. Returns whether you're authenticated, token expiry time, granted scopes, and token file location."),
+			mcp.WithString("profile",
+				mcp.Description(profileArgDescription),
+			),
 		),
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var args profileArg
+			if err := bindArgs(request.Params.Arguments, &args); err != nil {
+				return errorResult(err), nil
+			}
+
+			session, err := profiles.Resolve(request.Params.Arguments)
+			if err != nil {
+				return errorResult(err), nil
+			}
+
 			status := map[string]interface{}{
-				"authenticated": false,
-				"method":        "none",
+				"profile":            session.name,
+				"authenticated":      false,
+				"method":             "none",
+				"credential_sources": creds.Sources,
 			}
 
-			// Check environment variable first
-			if os.Getenv("WHOOP_ACCESS_TOKEN") != "" {
+			// External tokens take precedence over the token file
+			if external := session.external; external != nil {
 				status["authenticated"] = true
-				status["method"] = "environment_variable"
-				status["note"] = "Using WHOOP_ACCESS_TOKEN environment variable"
+				status["method"] = externalTokenMethod(external.Source())
+				status["note"] = fmt.Sprintf("Using access token from %s", external.Source())
+				if _, expiry := external.Current(); !expiry.IsZero() {
+					status["expires_at"] = expiry.Format(time.RFC3339)
+				}
 				return resultFromJSON(status)
 			}
 
 			// Check token file
+			tokenManager := session.tokenManager
 			if tokenManager == nil {
 				status["error"] = "Token manager not initialized. Set WHOOP_CLIENT_ID and WHOOP_CLIENT_SECRET."
 				return resultFromJSON(status)
@@ -494,6 +894,22 @@ func registerAuthTools(s *server.MCPServer, tokenManager *auth.TokenManager, cli
 			status["authenticated"] = true
 			status["method"] = "token_file"
 			status["token_path"] = tokenManager.TokenPath()
+			if token.ScopesKnown() {
+				status["scopes"] = token.Scopes
+			}
+
+			refresh := tokenManager.RefreshStatus()
+			status["background_refresh"] = refresh.BackgroundActive
+			if !refresh.LastRefresh.IsZero() {
+				status["last_refresh"] = refresh.LastRefresh.Format(time.RFC3339)
+			}
+			if refresh.LastError != "" {
+				status["last_refresh_error"] = refresh.LastError
+				status["last_refresh_error_at"] = refresh.LastErrorAt.Format(time.RFC3339)
+			}
+			if refresh.BackgroundActive && !refresh.NextRefresh.IsZero() {
+				status["next_refresh"] = refresh.NextRefresh.Format(time.RFC3339)
+			}
 
 			if !token.Expiry.IsZero() {
 				status["expires_at"] = token.Expiry.Format(time.RFC3339)
@@ -514,16 +930,32 @@ func registerAuthTools(s *server.MCPServer, tokenManager *auth.TokenManager, cli
 	s.AddTool(
 		mcp.NewTool("whoop_authorize",
 			mcp.WithDescription("Start the WHOOP OAuth authorization flow. Opens a browser for authentication and saves the token for future use. Requires WHOOP_CLIENT_ID and WHOOP_CLIENT_SECRET environment variables."),
+			mcp.WithString("profile",
+				mcp.Description("WHOOP account profile to save the token under (defaults to the active profile)."),
+			),
+			mcp.WithString("scopes",
+				mcp.Description("Space-separated scopes to request (e.g. \"read:sleep read:recovery\"). Defaults to all read scopes; offline is always added so the token can be refreshed."),
+			),
 		),
 		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var args authorizeArgs
+			if err := bindArgs(request.Params.Arguments, &args); err != nil {
+				return errorResult(err), nil
+			}
+
 			if clientID == "" || clientSecret == "" {
 				return resultFromJSON(map[string]interface{}{
 					"success": false,
-					"error":   "WHOOP_CLIENT_ID and WHOOP_CLIENT_SECRET environment variables are required",
+					"error":   "WHOOP_CLIENT_ID and WHOOP_CLIENT_SECRET (or WHOOP_CLIENT_SECRET_FILE, or a credential helper) are required",
 				})
 			}
 
-			if tokenManager == nil {
+			session, err := profiles.Resolve(request.Params.Arguments)
+			if err != nil {
+				return errorResult(err), nil
+			}
+
+			if session.tokenManager == nil {
 				return resultFromJSON(map[string]interface{}{
 					"success": false,
 					"error":   "Token manager not initialized",
@@ -533,9 +965,10 @@ func registerAuthTools(s *server.MCPServer, tokenManager *auth.TokenManager, cli
 			config := auth.OAuthConfig{
 				ClientID:     clientID,
 				ClientSecret: clientSecret,
+				Scopes:       args.Scopes,
 			}
 
-			result, err := auth.StartAuthFlow(ctx, config, tokenManager)
+			result, err := auth.StartAuthFlow(ctx, config, session.tokenManager)
 			if err != nil {
 				return resultFromJSON(map[string]interface{}{
 					"success": false,
@@ -546,6 +979,153 @@ func registerAuthTools(s *server.MCPServer, tokenManager *auth.TokenManager, cli
 			return resultFromJSON(result)
 		},
 	)
+
+	// Logout tool
+	s.AddTool(
+		mcp.NewTool("whoop_logout",
+			mcp.WithDescription("Sign out of a WHOOP profile: revoke its access and refresh tokens on WHOOP's side, delete the stored token file, and clear cached state for that account. The profile must be authorized again before further use."),
+			mcp.WithString("profile",
+				mcp.Description("WHOOP account profile to sign out (defaults to the active profile)."),
+			),
+			mcp.WithBoolean("confirm",
+				mcp.Required(),
+				mcp.Description("Must be true to confirm revoking access. This cannot be undone."),
+			),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var args logoutArgs
+			if err := bindArgs(request.Params.Arguments, &args); err != nil {
+				return errorResult(err), nil
+			}
+			if !args.Confirm {
+				return errorResult(newArgumentError("confirm must be true to log out")), nil
+			}
+
+			session, err := profiles.Resolve(request.Params.Arguments)
+			if err != nil {
+				return errorResult(err), nil
+			}
+
+			result, err := profiles.Logout(ctx, session)
+			if err != nil {
+				return errorResult(err), nil
+			}
+
+			return resultFromJSON(result)
+		},
+	)
+}
+
+func registerProfileTools(s *server.MCPServer, profiles *profileRegistry) {
+	s.AddTool(
+		mcp.NewTool("whoop_list_profiles",
+			mcp.WithDescription("List the WHOOP account profiles configured on this machine, which one is active, and whether each has a stored token."),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			names, err := profiles.List()
+			if err != nil {
+				return errorResult(err), nil
+			}
+
+			active := profiles.Active()
+			list := make([]map[string]interface{}, 0, len(names))
+			for _, name := range names {
+				entry := map[string]interface{}{
+					"name":   name,
+					"active": name == active,
+				}
+				if session, err := profiles.Get(name); err == nil {
+					entry["authenticated"] = profileHasCredentials(session)
+					if session.tokenManager != nil {
+						entry["token_path"] = session.tokenManager.TokenPath()
+					}
+				}
+				list = append(list, entry)
+			}
+
+			return resultFromJSON(map[string]interface{}{
+				"active":   active,
+				"profiles": list,
+			})
+		},
+	)
+
+	s.AddTool(
+		mcp.NewTool("whoop_switch_profile",
+			mcp.WithDescription("Switch the active WHOOP account profile. Subsequent tool calls without an explicit profile argument use this account. Use whoop_authorize afterwards if the profile has no token yet."),
+			mcp.WithString("profile",
+				mcp.Required(),
+				mcp.Description("Name of the profile to activate (letters, digits, '.', '_' or '-')."),
+			),
+		),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var args switchProfileArgs
+			if err := bindArgs(request.Params.Arguments, &args); err != nil {
+				return errorResult(err), nil
+			}
+
+			session, err := profiles.Switch(args.Profile)
+			if err != nil {
+				return codedErrorResult(whoop.CodeValidation, err.Error(), err), nil
+			}
+
+			result := map[string]interface{}{
+				"success":       true,
+				"active":        session.name,
+				"authenticated": profileHasCredentials(session),
+			}
+			if !profileHasCredentials(session) {
+				result["message"] = "No token found for this profile. Use whoop_authorize to authenticate."
+			}
+			return resultFromJSON(result)
+		},
+	)
+}
+
+// externalTokenMethod maps an external token source to the method reported
+// by whoop_auth_status.
+func externalTokenMethod(source string) string {
+	switch source {
+	case auth.EnvAccessToken:
+		return "environment_variable"
+	case auth.EnvAccessTokenFile:
+		return "secret_file"
+	default:
+		return source
+	}
+}
+
+// profileHasCredentials reports whether a profile has an external token or a stored token file.
+func profileHasCredentials(session *profileSession) bool {
+	if session.external != nil {
+		return true
+	}
+	if session.tokenManager == nil {
+		return false
+	}
+	token, err := session.tokenManager.Load()
+	return err == nil && token != nil
+}
+
+// envDuration parses a duration from the environment variable, returning
+// def when it is unset or invalid.
+func envDuration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		slog.Warn("ignoring invalid environment variable", "name", key, "value", v, "error", err)
+		return def
+	}
+	return d
+}
+
+// envBool reports whether the environment variable is set to a true value.
+func envBool(key string) bool {
+	v, err := strconv.ParseBool(os.Getenv(key))
+	return err == nil && v
 }
 
 func formatDuration(d time.Duration) string {
@@ -1,130 +1,108 @@
 package main
 
 import (
+	"fmt"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
 )
 
-func TestGetStringArg(t *testing.T) {
+func TestIntArg(t *testing.T) {
 	tests := []struct {
-		name     string
-		args     map[string]interface{}
-		key      string
-		expected string
+		name    string
+		raw     interface{}
+		want    int64
+		wantErr bool
 	}{
-		{
-			name:     "existing string key",
-			args:     map[string]interface{}{"key": "value"},
-			key:      "key",
-			expected: "value",
-		},
-		{
-			name:     "missing key",
-			args:     map[string]interface{}{"other": "value"},
-			key:      "key",
-			expected: "",
-		},
-		{
-			name:     "nil args",
-			args:     nil,
-			key:      "key",
-			expected: "",
-		},
-		{
-			name:     "non-string value",
-			args:     map[string]interface{}{"key": 123},
-			key:      "key",
-			expected: "",
-		},
-		{
-			name:     "empty string value",
-			args:     map[string]interface{}{"key": ""},
-			key:      "key",
-			expected: "",
-		},
+		{name: "float64", raw: float64(42), want: 42},
+		{name: "int", raw: 42, want: 42},
+		{name: "int64", raw: int64(42), want: 42},
+		{name: "zero", raw: float64(0), want: 0},
+		{name: "negative", raw: float64(-5), want: -5},
+		{name: "numeric string", raw: " 12 ", want: 12},
+		{name: "beyond int32", raw: float64(3_000_000_000), want: 3_000_000_000},
+		{name: "largest exact number", raw: float64(1 << 53), want: 1 << 53},
+		{name: "beyond exact numbers", raw: float64(1 << 54), wantErr: true},
+		{name: "large string", raw: "9007199254740993", want: 9007199254740993},
+		{name: "fraction", raw: 1.9, wantErr: true},
+		{name: "non-numeric string", raw: "not a number", wantErr: true},
+		{name: "boolean", raw: true, wantErr: true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := getStringArg(tt.args, tt.key)
-			if result != tt.expected {
-				t.Errorf("getStringArg() = %q, want %q", result, tt.expected)
+			got, msg := intArg(tt.raw)
+			if (msg != "") != tt.wantErr {
+				t.Fatalf("intArg(%v) error = %q, wantErr %v", tt.raw, msg, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("intArg(%v) = %d, want %d", tt.raw, got, tt.want)
 			}
 		})
 	}
 }
 
-func TestGetIntArg(t *testing.T) {
+func TestErrorResult(t *testing.T) {
 	tests := []struct {
-		name       string
-		args       map[string]interface{}
-		key        string
-		defaultVal int
-		expected   int
+		name string
+		err  error
+		code string
+		text string
 	}{
 		{
-			name:       "existing float64 key",
-			args:       map[string]interface{}{"key": float64(42)},
-			key:        "key",
-			defaultVal: 0,
-			expected:   42,
-		},
-		{
-			name:       "existing int key",
-			args:       map[string]interface{}{"key": 42},
-			key:        "key",
-			defaultVal: 0,
-			expected:   42,
-		},
-		{
-			name:       "existing int64 key",
-			args:       map[string]interface{}{"key": int64(42)},
-			key:        "key",
-			defaultVal: 0,
-			expected:   42,
-		},
-		{
-			name:       "missing key returns default",
-			args:       map[string]interface{}{"other": 42},
-			key:        "key",
-			defaultVal: 10,
-			expected:   10,
-		},
-		{
-			name:       "nil args returns default",
-			args:       nil,
-			key:        "key",
-			defaultVal: 5,
-			expected:   5,
+			name: "wrapped rate limit",
+			err:  fmt.Errorf("fetching: %w", &whoop.APIError{StatusCode: 429, Message: "slow down", RetryAfter: 30 * time.Second, RequestID: "req-1", Method: "GET", Endpoint: "/v2/cycle"}),
+			code: whoop.CodeRateLimited,
+			text: "retry after 30s",
 		},
 		{
-			name:       "non-numeric value returns default",
-			args:       map[string]interface{}{"key": "not a number"},
-			key:        "key",
-			defaultVal: 7,
-			expected:   7,
+			name: "not found",
+			err:  &whoop.APIError{StatusCode: 404, Message: "missing"},
+			code: whoop.CodeNotFound,
+			text: "Resource not found",
 		},
 		{
-			name:       "zero value",
-			args:       map[string]interface{}{"key": float64(0)},
-			key:        "key",
-			defaultVal: 10,
-			expected:   0,
+			name: "argument error",
+			err:  newArgumentError("cycle_id is required"),
+			code: whoop.CodeValidation,
+			text: "cycle_id is required",
 		},
 		{
-			name:       "negative value",
-			args:       map[string]interface{}{"key": float64(-5)},
-			key:        "key",
-			defaultVal: 10,
-			expected:   -5,
+			name: "reauthentication",
+			err:  fmt.Errorf("%w: refresh failed", whoop.ErrReauthenticationRequired),
+			code: whoop.CodeReauthenticationRequired,
+			text: "whoop_authorize",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := getIntArg(tt.args, tt.key, tt.defaultVal)
-			if result != tt.expected {
-				t.Errorf("getIntArg() = %d, want %d", result, tt.expected)
+			result := errorResult(tt.err)
+			if !result.IsError {
+				t.Fatal("result should be an error")
+			}
+
+			text := result.Content[0].(mcp.TextContent).Text
+			if !strings.Contains(text, tt.text) {
+				t.Errorf("text = %q, should contain %q", text, tt.text)
+			}
+			if !strings.Contains(text, "error code: "+tt.code) {
+				t.Errorf("text = %q, should include the error code %s", text, tt.code)
+			}
+
+			details, _ := result.Meta["error"].(map[string]interface{})
+			if details["code"] != tt.code {
+				t.Errorf("_meta.error.code = %v, want %v", details["code"], tt.code)
 			}
 		})
 	}
+
+	details := errorResult(tests[0].err).Meta["error"].(map[string]interface{})
+	if details["request_id"] != "req-1" || details["endpoint"] != "GET /v2/cycle" || details["retry_after_seconds"] != 30 {
+		t.Errorf("_meta.error = %v, want request details", details)
+	}
 }
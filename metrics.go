@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+const metricsNamespace = "whoop_mcp"
+
+// metrics records Prometheus metrics for tool calls, WHOOP API requests and
+// access tokens. It implements whoop.Observer and auth.TokenObserver. A nil
+// *metrics records nothing, so callers need not check whether metrics are
+// enabled.
+type metrics struct {
+	registry *prometheus.Registry
+
+	toolCalls    *prometheus.CounterVec
+	toolDuration *prometheus.HistogramVec
+	apiRequests  *prometheus.CounterVec
+	apiDuration  *prometheus.HistogramVec
+	apiRetries   *prometheus.CounterVec
+	// rateLimitRemaining has no labels; as a vector it is only exported
+	// once WHOOP has reported a value, rather than starting at a false zero.
+	rateLimitRemaining *prometheus.GaugeVec
+	tokenLookups       *prometheus.CounterVec
+	tokenRefreshes     *prometheus.CounterVec
+}
+
+// newMetrics creates the metrics on a registry of their own, together with
+// the standard Go runtime and process collectors.
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		toolCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "tool_calls_total",
+			Help:      "Tool calls by tool and outcome: success or the error code of the result.",
+		}, []string{"tool", "outcome"}),
+		toolDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "tool_call_duration_seconds",
+			Help:      "Time taken to answer tool calls, including every WHOOP API request they made.",
+			Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"tool"}),
+		apiRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "api_requests_total",
+			Help:      "WHOOP API request attempts by endpoint and status; status is \"error\" when no response was received.",
+		}, []string{"method", "endpoint", "status"}),
+		apiDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "api_request_duration_seconds",
+			Help:      "Latency of WHOOP API request attempts.",
+			Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		}, []string{"method", "endpoint"}),
+		apiRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "api_retries_total",
+			Help:      "WHOOP API requests retried after a rate limit or upstream failure.",
+		}, []string{"method", "endpoint"}),
+		rateLimitRemaining: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "api_rate_limit_remaining",
+			Help:      "Requests left in the current WHOOP rate limit window, from the last response that reported it.",
+		}, nil),
+		tokenLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "token_cache_lookups_total",
+			Help:      "Access token lookups by profile: hit when the stored token was still valid, miss when it had to be refreshed.",
+		}, []string{"profile", "result"}),
+		tokenRefreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "token_refreshes_total",
+			Help:      "Access token refreshes by profile, trigger (expired, rejected or background) and outcome.",
+		}, []string{"profile", "trigger", "outcome"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.toolCalls,
+		m.toolDuration,
+		m.apiRequests,
+		m.apiDuration,
+		m.apiRetries,
+		m.rateLimitRemaining,
+		m.tokenLookups,
+		m.tokenRefreshes,
+	)
+	return m
+}
+
+// handler serves the metrics in the Prometheus exposition format.
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveTool records a tool call and its result.
+func (m *metrics) ObserveTool(name string, elapsed time.Duration, result *mcp.CallToolResult) {
+	if m == nil {
+		return
+	}
+	m.toolCalls.WithLabelValues(name, toolOutcome(result)).Inc()
+	m.toolDuration.WithLabelValues(name).Observe(elapsed.Seconds())
+}
+
+// toolOutcome is "success", or the error code that errorResult put in the
+// result's _meta.error.
+func toolOutcome(result *mcp.CallToolResult) string {
+	if !result.IsError {
+		return "success"
+	}
+	if details, ok := result.Meta["error"].(map[string]interface{}); ok {
+		if code, ok := details["code"].(string); ok && code != "" {
+			return code
+		}
+	}
+	return "error"
+}
+
+// ObserveRequest implements whoop.Observer.
+func (m *metrics) ObserveRequest(_ context.Context, info whoop.RequestInfo) {
+	if m == nil {
+		return
+	}
+	status := "error"
+	if info.Status != 0 {
+		status = strconv.Itoa(info.Status)
+	}
+	m.apiRequests.WithLabelValues(info.Method, info.Endpoint, status).Inc()
+	m.apiDuration.WithLabelValues(info.Method, info.Endpoint).Observe(info.Duration.Seconds())
+	if info.Retry > 0 {
+		m.apiRetries.WithLabelValues(info.Method, info.Endpoint).Inc()
+	}
+	if info.RateLimit != nil {
+		m.rateLimitRemaining.WithLabelValues().Set(float64(info.RateLimit.Remaining))
+	}
+}
+
+// TokenLookup implements auth.TokenObserver.
+func (m *metrics) TokenLookup(profile string, cached bool) {
+	if m == nil {
+		return
+	}
+	result := "miss"
+	if cached {
+		result = "hit"
+	}
+	m.tokenLookups.WithLabelValues(profile, result).Inc()
+}
+
+// TokenRefreshed implements auth.TokenObserver.
+func (m *metrics) TokenRefreshed(profile, trigger string, err error) {
+	if m == nil {
+		return
+	}
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	m.tokenRefreshes.WithLabelValues(profile, trigger, outcome).Inc()
+}
+
+// serveMetrics serves /metrics on addr until ctx is cancelled. The MCP
+// server itself talks over stdio, so metrics get a listener of their own.
+func serveMetrics(ctx context.Context, addr string, m *metrics) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.handler())
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("serving metrics", "address", listener.Addr().String(), "path", "/metrics")
+	go func() {
+		if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("metrics server failed", "error", err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/xokvictor/whoop-mcp/pkg/auth"
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+// scrapeMetrics returns the metrics in the Prometheus text format.
+func scrapeMetrics(t *testing.T, m *metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /metrics = %d", rec.Code)
+	}
+	return rec.Body.String()
+}
+
+func TestMetrics(t *testing.T) {
+	m := newMetrics()
+	ctx := context.Background()
+	if strings.Contains(scrapeMetrics(t, m), "whoop_mcp_api_rate_limit_remaining") {
+		t.Error("the rate limit should not be exported before WHOOP reports it")
+	}
+
+	m.ObserveRequest(ctx, whoop.RequestInfo{Method: "GET", Endpoint: "/v2/cycle", Status: 429, Duration: 80 * time.Millisecond, Err: &whoop.APIError{StatusCode: 429}})
+	m.ObserveRequest(ctx, whoop.RequestInfo{Method: "GET", Endpoint: "/v2/cycle", Status: 200, Duration: 120 * time.Millisecond, Retry: 1, RateLimit: &whoop.RateLimit{Limit: 100, Remaining: 97}})
+	m.ObserveRequest(ctx, whoop.RequestInfo{Method: "GET", Endpoint: "/v2/recovery", Err: errors.New("connection refused")})
+	m.ObserveTool("get_cycles", 300*time.Millisecond, mcp.NewToolResultText("[]"))
+	m.ObserveTool("get_cycles", time.Millisecond, errorResult(newArgumentError("limit: must be at most 25")))
+	m.TokenLookup("default", true)
+	m.TokenLookup("default", true)
+	m.TokenLookup("default", false)
+	m.TokenRefreshed("default", auth.RefreshExpired, nil)
+	m.TokenRefreshed("work", auth.RefreshBackground, errors.New("refresh failed"))
+
+	body := scrapeMetrics(t, m)
+	for _, want := range []string{
+		`whoop_mcp_api_requests_total{endpoint="/v2/cycle",method="GET",status="429"} 1`,
+		`whoop_mcp_api_requests_total{endpoint="/v2/cycle",method="GET",status="200"} 1`,
+		`whoop_mcp_api_requests_total{endpoint="/v2/recovery",method="GET",status="error"} 1`,
+		`whoop_mcp_api_request_duration_seconds_count{endpoint="/v2/cycle",method="GET"} 2`,
+		`whoop_mcp_api_retries_total{endpoint="/v2/cycle",method="GET"} 1`,
+		`whoop_mcp_api_rate_limit_remaining 97`,
+		`whoop_mcp_tool_calls_total{outcome="success",tool="get_cycles"} 1`,
+		`whoop_mcp_tool_calls_total{outcome="validation_error",tool="get_cycles"} 1`,
+		`whoop_mcp_tool_call_duration_seconds_count{tool="get_cycles"} 2`,
+		`whoop_mcp_token_cache_lookups_total{profile="default",result="hit"} 2`,
+		`whoop_mcp_token_cache_lookups_total{profile="default",result="miss"} 1`,
+		`whoop_mcp_token_refreshes_total{outcome="success",profile="default",trigger="expired"} 1`,
+		`whoop_mcp_token_refreshes_total{outcome="error",profile="work",trigger="background"} 1`,
+		`go_goroutines `,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %s", want)
+		}
+	}
+
+	// A nil *metrics records nothing
+	var disabled *metrics
+	disabled.ObserveTool("get_cycles", time.Second, mcp.NewToolResultText(""))
+	disabled.ObserveRequest(ctx, whoop.RequestInfo{})
+	disabled.TokenLookup("default", true)
+	disabled.TokenRefreshed("default", auth.RefreshExpired, nil)
+}
+
+func TestTransportToolMetrics(t *testing.T) {
+	s := server.NewMCPServer("test", "0.0.0")
+	s.AddTool(mcp.NewTool("get_cycles"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return errorResult(&whoop.ErrRateLimited{RetryAfter: time.Minute}), nil
+	})
+	m := newMetrics()
+	var out strings.Builder
+	transport := newStdioTransport(s, newSubscriptions(), nil, m, &out)
+
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_cycles","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"no_such_tool","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"ping"}`,
+	}, "\n") + "\n"
+	if err := transport.Serve(context.Background(), strings.NewReader(in)); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	body := scrapeMetrics(t, m)
+	if !strings.Contains(body, `whoop_mcp_tool_calls_total{outcome="rate_limited",tool="get_cycles"} 1`) {
+		t.Errorf("rate-limited call not recorded:\n%s", body)
+	}
+	if strings.Contains(body, "no_such_tool") {
+		t.Error("calls to unknown tools should not be recorded")
+	}
+}
@@ -0,0 +1,371 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+// Environment variables consulted by LoadCredentials.
+const (
+	EnvClientID         = "WHOOP_CLIENT_ID"
+	EnvClientSecret     = "WHOOP_CLIENT_SECRET"
+	EnvClientSecretFile = "WHOOP_CLIENT_SECRET_FILE"
+	EnvAccessToken      = "WHOOP_ACCESS_TOKEN"
+	EnvAccessTokenFile  = "WHOOP_ACCESS_TOKEN_FILE"
+	EnvCredentialHelper = "WHOOP_CREDENTIAL_HELPER"
+
+	// SourceCredentialHelper names values supplied by the credential helper.
+	SourceCredentialHelper = "credential_helper"
+	// SourceTokenFile names the per-profile token file managed by TokenManager.
+	SourceTokenFile = "token_file"
+	// SourceConfigFile prefixes values taken from the configuration file,
+	// e.g. "config:client_id".
+	SourceConfigFile = "config:"
+
+	helperTimeout = 30 * time.Second
+)
+
+// CredentialSource describes one place a credential may come from, in
+// precedence order, and whether it was configured and used.
+type CredentialSource struct {
+	Credential string `json:"credential"`
+	Source     string `json:"source"`
+	Configured bool   `json:"configured"`
+	Used       bool   `json:"used"`
+}
+
+// Credentials are the client credentials and external access token
+// resolved from the environment, secret files and the credential helper.
+type Credentials struct {
+	ClientID     string
+	ClientSecret string
+	// AccessToken is nil when no external token is configured, in which
+	// case the profile's token file is used.
+	AccessToken *ExternalToken
+	// Sources lists every source that was consulted, in precedence order.
+	Sources []CredentialSource
+}
+
+// HelperOutput is the JSON a credential helper prints on stdout. Every
+// field is optional.
+type HelperOutput struct {
+	ClientID     string    `json:"client_id,omitempty"`
+	ClientSecret string    `json:"client_secret,omitempty"`
+	AccessToken  string    `json:"access_token,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at,omitempty"`
+	// ExpiresIn is seconds from now, used when ExpiresAt is not set.
+	ExpiresIn int `json:"expires_in,omitempty"`
+}
+
+// CredentialConfig holds credential settings from the configuration file.
+// Each one applies only when its environment variable is unset.
+type CredentialConfig struct {
+	ClientID         string
+	ClientSecret     string
+	ClientSecretFile string
+	CredentialHelper string
+}
+
+// LoadCredentials resolves credentials for the given profile. Precedence,
+// highest first:
+//
+//	client ID:     WHOOP_CLIENT_ID, credential helper
+//	client secret: WHOOP_CLIENT_SECRET, WHOOP_CLIENT_SECRET_FILE, credential helper
+//	access token:  WHOOP_ACCESS_TOKEN, WHOOP_ACCESS_TOKEN_FILE, credential helper, token file
+//
+// The credential helper (WHOOP_CREDENTIAL_HELPER) is only run when a
+// value it could supply is still missing.
+func LoadCredentials(ctx context.Context, profile string) (*Credentials, error) {
+	return LoadCredentialsWithConfig(ctx, profile, CredentialConfig{})
+}
+
+// LoadCredentialsWithConfig is LoadCredentials with fallbacks from the
+// configuration file for the client ID, client secret, secret file and
+// credential helper. Their sources are reported as "config:<key>".
+func LoadCredentialsWithConfig(ctx context.Context, profile string, config CredentialConfig) (*Credentials, error) {
+	if profile == "" {
+		profile = DefaultProfile
+	}
+
+	creds := &Credentials{}
+	var helper *credentialHelper
+	if command, _ := setting(EnvCredentialHelper, config.CredentialHelper, "credential_helper"); command != "" {
+		helper = &credentialHelper{command: command, profile: profile}
+	}
+
+	// Client ID
+	clientID, source := setting(EnvClientID, config.ClientID, "client_id")
+	creds.ClientID = clientID
+	creds.add("client_id", source, creds.ClientID != "", creds.ClientID != "")
+
+	// Client secret
+	clientSecret, source := setting(EnvClientSecret, config.ClientSecret, "client_secret")
+	creds.ClientSecret = clientSecret
+	creds.add("client_secret", source, creds.ClientSecret != "", creds.ClientSecret != "")
+
+	secretFile, source := setting(EnvClientSecretFile, config.ClientSecretFile, "client_secret_file")
+	useSecretFile := secretFile != "" && creds.ClientSecret == ""
+	if useSecretFile {
+		secret, err := readSecretFile(secretFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+		creds.ClientSecret = secret
+	}
+	creds.add("client_secret", source, secretFile != "", useSecretFile)
+
+	// Access token
+	if token := os.Getenv(EnvAccessToken); token != "" {
+		creds.AccessToken = StaticToken(EnvAccessToken, token)
+	}
+	creds.add("access_token", EnvAccessToken, creds.AccessToken != nil, creds.AccessToken != nil)
+
+	tokenFile := os.Getenv(EnvAccessTokenFile)
+	useTokenFile := tokenFile != "" && creds.AccessToken == nil
+	if useTokenFile {
+		creds.AccessToken = FileToken(tokenFile)
+		if _, err := creds.AccessToken.EnsureValidToken(ctx); err != nil {
+			return nil, fmt.Errorf("%s: %w", EnvAccessTokenFile, err)
+		}
+	}
+	creds.add("access_token", EnvAccessTokenFile, tokenFile != "", useTokenFile)
+
+	// Credential helper fills whatever is still missing
+	var usedID, usedSecret, usedToken bool
+	if helper != nil && (creds.ClientID == "" || creds.ClientSecret == "" || creds.AccessToken == nil) {
+		out, err := helper.run(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if creds.ClientID == "" && out.ClientID != "" {
+			creds.ClientID = out.ClientID
+			usedID = true
+		}
+		if creds.ClientSecret == "" && out.ClientSecret != "" {
+			creds.ClientSecret = out.ClientSecret
+			usedSecret = true
+		}
+		if creds.AccessToken == nil && out.AccessToken != "" {
+			creds.AccessToken = helperToken(helper, out)
+			usedToken = true
+		}
+	}
+	creds.add("client_id", SourceCredentialHelper, helper != nil, usedID)
+	creds.add("client_secret", SourceCredentialHelper, helper != nil, usedSecret)
+	creds.add("access_token", SourceCredentialHelper, helper != nil, usedToken)
+
+	hasClient := creds.ClientID != "" && creds.ClientSecret != ""
+	creds.add("access_token", SourceTokenFile, hasClient, hasClient && creds.AccessToken == nil)
+
+	return creds, nil
+}
+
+// setting returns the environment variable's value, falling back to the
+// configured value, and names where it came from.
+func setting(env, configured, key string) (value, source string) {
+	if v := os.Getenv(env); v != "" {
+		return v, env
+	}
+	if configured != "" {
+		return configured, SourceConfigFile + key
+	}
+	return "", env
+}
+
+func (c *Credentials) add(credential, source string, configured, used bool) {
+	c.Sources = append(c.Sources, CredentialSource{
+		Credential: credential,
+		Source:     source,
+		Configured: configured,
+		Used:       used,
+	})
+}
+
+// readSecretFile reads a mounted secret, trimming the trailing newline
+// most secret stores add.
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading secret file: %w", err)
+	}
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return "", fmt.Errorf("secret file %s is empty", path)
+	}
+	return secret, nil
+}
+
+// credentialHelper runs an external command that prints HelperOutput JSON,
+// in the spirit of git's credential.helper.
+type credentialHelper struct {
+	// command is a shell command line, so paths with spaces and quoted
+	// arguments work as they do in git.
+	command string
+	profile string
+}
+
+// run executes "<command> get" through the shell, as git runs its
+// credential helpers, with WHOOP_PROFILE set to the profile. The helper's
+// stderr is passed through to ours.
+func (h *credentialHelper) run(ctx context.Context) (*HelperOutput, error) {
+	if strings.TrimSpace(h.command) == "" {
+		return nil, fmt.Errorf("%s is empty", EnvCredentialHelper)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, helperTimeout)
+	defer cancel()
+
+	var stdout bytes.Buffer
+	cmd := helperCommand(ctx, h.command)
+	cmd.Env = append(os.Environ(), "WHOOP_PROFILE="+h.profile)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("running credential helper: %w", err)
+	}
+
+	var out HelperOutput
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return nil, fmt.Errorf("parsing credential helper output: %w", err)
+	}
+	if out.ExpiresAt.IsZero() && out.ExpiresIn > 0 {
+		out.ExpiresAt = time.Now().Add(time.Duration(out.ExpiresIn) * time.Second)
+	}
+	return &out, nil
+}
+
+// helperCommand builds the command that runs a helper command line with
+// "get" appended. On Unix the line goes to sh with "get" as a positional
+// argument, so it is never re-parsed; Windows has no sh, so cmd parses it.
+func helperCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command+" get")
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command+` "$@"`, command, "get")
+}
+
+// ExternalToken is an access token managed outside the token file. It
+// implements whoop.TokenProvider: file and helper tokens are fetched again
+// when they expire or WHOOP rejects them.
+type ExternalToken struct {
+	source string
+	fetch  func(ctx context.Context) (string, time.Time, error)
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// StaticToken returns a fixed access token, such as WHOOP_ACCESS_TOKEN.
+func StaticToken(source, token string) *ExternalToken {
+	return &ExternalToken{source: source, token: token}
+}
+
+// FileToken returns an access token read from a file, re-read whenever
+// WHOOP rejects the current one so rotated secrets are picked up.
+func FileToken(path string) *ExternalToken {
+	return &ExternalToken{
+		source: EnvAccessTokenFile,
+		fetch: func(ctx context.Context) (string, time.Time, error) {
+			token, err := readSecretFile(path)
+			return token, time.Time{}, err
+		},
+	}
+}
+
+// helperToken returns an access token supplied by the credential helper,
+// seeded with its first output.
+func helperToken(helper *credentialHelper, first *HelperOutput) *ExternalToken {
+	return &ExternalToken{
+		source: SourceCredentialHelper,
+		token:  first.AccessToken,
+		expiry: first.ExpiresAt,
+		fetch: func(ctx context.Context) (string, time.Time, error) {
+			out, err := helper.run(ctx)
+			if err != nil {
+				return "", time.Time{}, err
+			}
+			if out.AccessToken == "" {
+				return "", time.Time{}, fmt.Errorf("credential helper returned no access_token")
+			}
+			return out.AccessToken, out.ExpiresAt, nil
+		},
+	}
+}
+
+// Source returns where the token comes from, e.g. WHOOP_ACCESS_TOKEN_FILE.
+func (e *ExternalToken) Source() string {
+	return e.source
+}
+
+// Refreshable reports whether the token can be fetched again.
+func (e *ExternalToken) Refreshable() bool {
+	return e.fetch != nil
+}
+
+// Current returns the cached token and its expiry without fetching.
+func (e *ExternalToken) Current() (string, time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.token, e.expiry
+}
+
+// EnsureValidToken returns the cached token, fetching a new one when none
+// is cached yet or the known expiry is near.
+func (e *ExternalToken) EnsureValidToken(ctx context.Context) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	expired := !e.expiry.IsZero() && time.Now().Add(expiryBuffer).After(e.expiry)
+	if e.token != "" && (!expired || e.fetch == nil) {
+		return e.token, nil
+	}
+	return e.refetch(ctx)
+}
+
+// ForceRefresh fetches a new token after WHOOP rejected the given one.
+func (e *ExternalToken) ForceRefresh(ctx context.Context, rejected string) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.fetch == nil {
+		return "", fmt.Errorf("%w: access token from %s was rejected and cannot be refreshed", whoop.ErrRefreshRejected, e.source)
+	}
+	if e.token != "" && e.token != rejected {
+		return e.token, nil
+	}
+
+	token, err := e.refetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	if token == rejected {
+		return "", fmt.Errorf("%w: %s still supplies the rejected access token", whoop.ErrRefreshRejected, e.source)
+	}
+	return token, nil
+}
+
+// refetch replaces the cached token. Callers must hold e.mu.
+func (e *ExternalToken) refetch(ctx context.Context) (string, error) {
+	if e.fetch == nil {
+		return "", fmt.Errorf("no access token from %s", e.source)
+	}
+	token, expiry, err := e.fetch(ctx)
+	if err != nil {
+		return "", fmt.Errorf("fetching access token from %s: %w", e.source, err)
+	}
+	e.token = token
+	e.expiry = expiry
+	return token, nil
+}
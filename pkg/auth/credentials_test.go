@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// clearCredentialEnv unsets every credential variable for the test.
+func clearCredentialEnv(t *testing.T) {
+	t.Helper()
+	for _, key := range []string{EnvClientID, EnvClientSecret, EnvClientSecretFile, EnvAccessToken, EnvAccessTokenFile, EnvCredentialHelper} {
+		t.Setenv(key, "")
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeHelper creates an executable credential helper script that prints output.
+func writeHelper(t *testing.T, output string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("credential helper test script requires a POSIX shell")
+	}
+	path := filepath.Join(t.TempDir(), "helper.sh")
+	script := "#!/bin/sh\nif [ \"$1\" != get ]; then exit 2; fi\ncat <<'JSON'\n" + output + "\nJSON\n"
+	if err := os.WriteFile(path, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func findSource(creds *Credentials, credential, source string) CredentialSource {
+	for _, s := range creds.Sources {
+		if s.Credential == credential && s.Source == source {
+			return s
+		}
+	}
+	return CredentialSource{}
+}
+
+func TestLoadCredentialsPrecedence(t *testing.T) {
+	clearCredentialEnv(t)
+	t.Setenv(EnvClientID, "env-id")
+	t.Setenv(EnvClientSecret, "env-secret")
+	t.Setenv(EnvClientSecretFile, writeFile(t, "secret", "file-secret\n"))
+	t.Setenv(EnvAccessToken, "env-token")
+	t.Setenv(EnvAccessTokenFile, writeFile(t, "token", "file-token\n"))
+
+	creds, err := LoadCredentials(context.Background(), DefaultProfile)
+	if err != nil {
+		t.Fatalf("LoadCredentials() error = %v", err)
+	}
+	if creds.ClientSecret != "env-secret" {
+		t.Errorf("ClientSecret = %v, want env-secret", creds.ClientSecret)
+	}
+	if creds.AccessToken.Source() != EnvAccessToken {
+		t.Errorf("AccessToken source = %v, want %v", creds.AccessToken.Source(), EnvAccessToken)
+	}
+
+	fileSource := findSource(creds, "access_token", EnvAccessTokenFile)
+	if !fileSource.Configured || fileSource.Used {
+		t.Errorf("shadowed token file source = %+v, want configured and unused", fileSource)
+	}
+}
+
+func TestLoadCredentialsFromFiles(t *testing.T) {
+	clearCredentialEnv(t)
+	t.Setenv(EnvClientID, "env-id")
+	t.Setenv(EnvClientSecretFile, writeFile(t, "secret", "file-secret\n"))
+	t.Setenv(EnvAccessTokenFile, writeFile(t, "token", "file-token\n"))
+
+	creds, err := LoadCredentials(context.Background(), DefaultProfile)
+	if err != nil {
+		t.Fatalf("LoadCredentials() error = %v", err)
+	}
+	if creds.ClientSecret != "file-secret" {
+		t.Errorf("ClientSecret = %q, want file-secret", creds.ClientSecret)
+	}
+	token, _ := creds.AccessToken.Current()
+	if token != "file-token" {
+		t.Errorf("AccessToken = %q, want file-token", token)
+	}
+	if !findSource(creds, "client_secret", EnvClientSecretFile).Used {
+		t.Error("client secret file should be reported as used")
+	}
+	if findSource(creds, "access_token", SourceTokenFile).Used {
+		t.Error("token file should not be used when an external token is configured")
+	}
+}
+
+func TestLoadCredentialsWithConfig(t *testing.T) {
+	clearCredentialEnv(t)
+	t.Setenv(EnvClientID, "env-id")
+
+	creds, err := LoadCredentialsWithConfig(context.Background(), DefaultProfile, CredentialConfig{
+		ClientID:         "config-id",
+		ClientSecretFile: writeFile(t, "secret", "config-secret\n"),
+	})
+	if err != nil {
+		t.Fatalf("LoadCredentialsWithConfig() error = %v", err)
+	}
+	if creds.ClientID != "env-id" {
+		t.Errorf("ClientID = %q, want env-id (environment overrides the config file)", creds.ClientID)
+	}
+	if creds.ClientSecret != "config-secret" {
+		t.Errorf("ClientSecret = %q, want config-secret", creds.ClientSecret)
+	}
+	if !findSource(creds, "client_secret", SourceConfigFile+"client_secret_file").Used {
+		t.Errorf("config secret file should be reported as used, sources = %+v", creds.Sources)
+	}
+}
+
+func TestLoadCredentialsMissingFile(t *testing.T) {
+	clearCredentialEnv(t)
+	t.Setenv(EnvAccessTokenFile, filepath.Join(t.TempDir(), "missing"))
+
+	if _, err := LoadCredentials(context.Background(), DefaultProfile); err == nil {
+		t.Error("LoadCredentials() should fail when WHOOP_ACCESS_TOKEN_FILE cannot be read")
+	}
+}
+
+func TestLoadCredentialsHelper(t *testing.T) {
+	clearCredentialEnv(t)
+	t.Setenv(EnvClientID, "env-id")
+	t.Setenv(EnvCredentialHelper, writeHelper(t, `{"client_id":"helper-id","client_secret":"helper-secret","access_token":"helper-token","expires_in":3600}`))
+
+	creds, err := LoadCredentials(context.Background(), "work")
+	if err != nil {
+		t.Fatalf("LoadCredentials() error = %v", err)
+	}
+	if creds.ClientID != "env-id" {
+		t.Errorf("ClientID = %v, want env-id", creds.ClientID)
+	}
+	if creds.ClientSecret != "helper-secret" {
+		t.Errorf("ClientSecret = %v, want helper-secret", creds.ClientSecret)
+	}
+	token, expiry := creds.AccessToken.Current()
+	if token != "helper-token" {
+		t.Errorf("AccessToken = %v, want helper-token", token)
+	}
+	if expiry.IsZero() || time.Until(expiry) > time.Hour {
+		t.Errorf("expiry = %v, want about an hour from now", expiry)
+	}
+	if creds.AccessToken.Source() != SourceCredentialHelper || !creds.AccessToken.Refreshable() {
+		t.Error("helper token should be refreshable and report the helper as its source")
+	}
+	if findSource(creds, "client_id", SourceCredentialHelper).Used {
+		t.Error("helper client_id should be shadowed by WHOOP_CLIENT_ID")
+	}
+}
+
+func TestLoadCredentialsHelperBadOutput(t *testing.T) {
+	clearCredentialEnv(t)
+	t.Setenv(EnvCredentialHelper, writeHelper(t, "not json"))
+
+	if _, err := LoadCredentials(context.Background(), DefaultProfile); err == nil {
+		t.Error("LoadCredentials() should fail on invalid helper output")
+	}
+}
+
+func TestCredentialHelperCommandLine(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("credential helper test script requires a POSIX shell")
+	}
+	dir := filepath.Join(t.TempDir(), "My Vault")
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "helper")
+	script := "#!/bin/sh\nif [ \"$#|$1|$2|$3\" != \"3|--account|x y|get\" ]; then exit 2; fi\necho '{\"access_token\":\"quoted-token\"}'\n"
+	if err := os.WriteFile(path, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	helper := &credentialHelper{command: `"` + path + `" --account 'x y'`, profile: DefaultProfile}
+	out, err := helper.run(context.Background())
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if out.AccessToken != "quoted-token" {
+		t.Errorf("AccessToken = %v, want quoted-token", out.AccessToken)
+	}
+}
+
+func TestFileTokenForceRefresh(t *testing.T) {
+	path := writeFile(t, "token", "first\n")
+	source := FileToken(path)
+	ctx := context.Background()
+
+	token, err := source.EnsureValidToken(ctx)
+	if err != nil || token != "first" {
+		t.Fatalf("EnsureValidToken() = %q, %v, want first", token, err)
+	}
+
+	// Not rotated yet: the rejected token cannot be replaced
+	if _, err := source.ForceRefresh(ctx, "first"); err == nil {
+		t.Error("ForceRefresh() should fail while the file still holds the rejected token")
+	}
+
+	if err := os.WriteFile(path, []byte("second\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	token, err = source.ForceRefresh(ctx, "first")
+	if err != nil || token != "second" {
+		t.Errorf("ForceRefresh() = %q, %v, want second", token, err)
+	}
+}
+
+func TestStaticTokenForceRefresh(t *testing.T) {
+	source := StaticToken(EnvAccessToken, "static")
+
+	token, err := source.EnsureValidToken(context.Background())
+	if err != nil || token != "static" {
+		t.Fatalf("EnsureValidToken() = %q, %v, want static", token, err)
+	}
+	if source.Refreshable() {
+		t.Error("static token should not be refreshable")
+	}
+	if _, err := source.ForceRefresh(context.Background(), "static"); err == nil {
+		t.Error("ForceRefresh() should fail for a static token")
+	}
+}
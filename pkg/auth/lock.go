@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	lockSuffix       = ".lock"
+	lockPollInterval = 50 * time.Millisecond
+	lockTimeout      = 30 * time.Second
+)
+
+// fileLock is an advisory lock shared by every process using the same token file.
+type fileLock struct {
+	file *os.File
+	path string
+}
+
+// acquireFileLock takes an exclusive advisory lock on path+".lock", polling
+// until it succeeds, lockTimeout elapses, or ctx is cancelled.
+func acquireFileLock(ctx context.Context, path string) (*fileLock, error) {
+	lockPath := path + lockSuffix
+	if err := os.MkdirAll(filepath.Dir(lockPath), dirPerm); err != nil {
+		return nil, fmt.Errorf("creating lock directory: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, lockTimeout)
+	defer cancel()
+
+	for {
+		lock, err := tryLock(lockPath)
+		if err != nil {
+			return nil, fmt.Errorf("locking %s: %w", lockPath, err)
+		}
+		if lock != nil {
+			return lock, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for token lock %s: %w", lockPath, ctx.Err())
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
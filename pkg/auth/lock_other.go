@@ -0,0 +1,36 @@
+//go:build !unix
+
+package auth
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// staleLockAge is how old a lock file must be before it is assumed to belong
+// to a crashed process.
+const staleLockAge = 2 * lockTimeout
+
+// tryLock creates the lock file exclusively. It returns a nil lock without
+// error when another process holds it.
+func tryLock(lockPath string) (*fileLock, error) {
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_RDWR, filePerm)
+	if err != nil {
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > staleLockAge {
+			_ = os.Remove(lockPath)
+		}
+		return nil, nil
+	}
+
+	return &fileLock{file: file, path: lockPath}, nil
+}
+
+// Unlock releases the lock by removing the lock file.
+func (l *fileLock) Unlock() error {
+	l.file.Close()
+	return os.Remove(l.path)
+}
@@ -1,12 +1,14 @@
 package auth
 
 import (
+	"bufio"
 	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"html"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
@@ -19,30 +21,82 @@ import (
 )
 
 const (
-	callbackPort  = 8080
-	callbackPath  = "/callback"
-	authTimeout   = 5 * time.Minute
-	defaultScopes = "read:profile read:body_measurement read:cycles read:recovery read:sleep read:workout offline"
-	redirectURI   = "http://localhost:8080/callback"
+	callbackPort = 8080
+	callbackPath = "/callback"
+	// callbackAddr is loopback only: the redirect goes to localhost, and
+	// nothing else should be able to deliver a code.
+	callbackAddr = "127.0.0.1:8080"
+	authTimeout  = 5 * time.Minute
+	redirectURI  = "http://localhost:8080/callback"
 )
 
+// defaultScopes requests every data scope plus offline access.
+var defaultScopes = strings.Join(append(append([]string{}, whoop.ReadScopes...), whoop.ScopeOffline), " ")
+
 // OAuthConfig contains OAuth configuration.
 type OAuthConfig struct {
 	ClientID     string
 	ClientSecret string
-	Scopes       string
+	// Scopes is a space-separated subset of scopes to request. Empty means
+	// every data scope. The offline scope is always added so that a
+	// refresh token is issued.
+	Scopes string
+
+	// ShowURL, if set, is called with the authorization URL when the
+	// browser is not opened (Headless) or fails to open, and the flow keeps
+	// waiting instead of giving up.
+	ShowURL func(authURL string)
+	// Headless skips opening a browser.
+	Headless bool
+	// RedirectInput, if set, is read line by line for the URL the browser
+	// was redirected to. This completes the flow on machines where the
+	// browser cannot reach the local callback server.
+	RedirectInput io.Reader
+}
+
+// NormalizeScopes validates a space-separated scope list and adds the
+// offline scope. An empty list selects the default scopes.
+func NormalizeScopes(scopes string) (string, error) {
+	requested := strings.Fields(scopes)
+	if len(requested) == 0 {
+		return defaultScopes, nil
+	}
+
+	seen := make(map[string]bool, len(requested)+1)
+	normalized := make([]string, 0, len(requested)+1)
+	for _, scope := range requested {
+		if _, ok := whoop.ScopeDescriptions[scope]; !ok {
+			return "", fmt.Errorf("unknown scope %q", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	if !seen[whoop.ScopeOffline] {
+		normalized = append(normalized, whoop.ScopeOffline)
+	}
+
+	return strings.Join(normalized, " "), nil
 }
 
 // AuthResult contains the result of the OAuth authorization flow.
 type AuthResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	Email   string `json:"email,omitempty"`
+	Success bool     `json:"success"`
+	Message string   `json:"message"`
+	Email   string   `json:"email,omitempty"`
+	Scopes  []string `json:"scopes,omitempty"`
 }
 
 // StartAuthFlow initiates the OAuth authorization flow.
 // It starts a local HTTP server, opens the browser, and waits for the callback.
 func StartAuthFlow(ctx context.Context, config OAuthConfig, tokenManager *TokenManager) (*AuthResult, error) {
+	scopes, err := NormalizeScopes(config.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	config.Scopes = scopes
+
 	state, err := generateState()
 	if err != nil {
 		return nil, fmt.Errorf("generating state: %w", err)
@@ -59,11 +113,25 @@ func StartAuthFlow(ctx context.Context, config OAuthConfig, tokenManager *TokenM
 
 	authURL := buildAuthURL(config, state)
 
-	if err := openBrowser(authURL); err != nil {
-		return &AuthResult{
-			Success: false,
-			Message: fmt.Sprintf("Failed to open browser. Please visit this URL manually:\n%s", authURL),
-		}, nil
+	switch {
+	case config.Headless && config.ShowURL != nil:
+		config.ShowURL(authURL)
+	case config.Headless:
+		return nil, fmt.Errorf("headless authorization requires ShowURL")
+	default:
+		if err := openBrowser(authURL); err != nil {
+			if config.ShowURL == nil {
+				return &AuthResult{
+					Success: false,
+					Message: fmt.Sprintf("Failed to open browser. Please visit this URL manually:\n%s", authURL),
+				}, nil
+			}
+			config.ShowURL(authURL)
+		}
+	}
+
+	if config.RedirectInput != nil {
+		go readRedirect(config.RedirectInput, state, codeChan, errChan)
 	}
 
 	select {
@@ -80,6 +148,7 @@ func StartAuthFlow(ctx context.Context, config OAuthConfig, tokenManager *TokenM
 		return &AuthResult{
 			Success: true,
 			Message: "Authorization successful! Token saved.",
+			Scopes:  token.Scopes,
 		}, nil
 
 	case err := <-errChan:
@@ -120,20 +189,92 @@ func buildAuthURL(config OAuthConfig, state string) string {
 	return whoop.AuthURL + "?" + params.Encode()
 }
 
-func startCallbackServer(expectedState string, codeChan chan<- string, errChan chan<- error) (*http.Server, error) {
+// readRedirect reads the pasted redirect URL. Blank lines are skipped; the
+// first non-blank line either completes or fails the flow.
+func readRedirect(r io.Reader, expectedState string, codeChan chan<- string, errChan chan<- error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		code, err := ParseRedirect(line, expectedState)
+		if err != nil {
+			trySend(errChan, err)
+			return
+		}
+		trySend(codeChan, code)
+		return
+	}
+}
+
+// ParseRedirect extracts the authorization code from the URL the browser
+// was redirected to, checking the state parameter. A bare code is refused:
+// without the state there is no telling it came from this flow.
+func ParseRedirect(input, expectedState string) (string, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return "", fmt.Errorf("no authorization code received")
+	}
+	if !strings.Contains(input, "?") {
+		return "", fmt.Errorf("paste the full URL you were redirected to, not just the code")
+	}
+
+	u, err := url.Parse(input)
+	if err != nil {
+		return "", fmt.Errorf("parsing redirect URL: %w", err)
+	}
+	query := u.Query()
+
+	if errMsg := query.Get("error"); errMsg != "" {
+		return "", fmt.Errorf("authorization error: %s - %s", errMsg, query.Get("error_description"))
+	}
+	if query.Get("state") != expectedState {
+		return "", fmt.Errorf("invalid state parameter")
+	}
+
+	code := query.Get("code")
+	if code == "" {
+		return "", fmt.Errorf("no authorization code received")
+	}
+	return code, nil
+}
+
+// CheckCallbackPort reports whether the OAuth callback port is free to
+// receive the authorization redirect.
+func CheckCallbackPort() error {
+	listener, err := net.Listen("tcp", callbackAddr)
+	if err != nil {
+		return fmt.Errorf("port %d is already in use: %w", callbackPort, err)
+	}
+	return listener.Close()
+}
+
+// trySend delivers v unless ch already holds a value. The browser and the
+// pasted redirect both feed the same channels, and only the first result
+// counts; a later one must not block its sender.
+func trySend[T any](ch chan<- T, v T) {
+	select {
+	case ch <- v:
+	default:
+	}
+}
+
+// callbackHandler receives the authorization redirect.
+func callbackHandler(expectedState string, codeChan chan<- string, errChan chan<- error) http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc(callbackPath, func(w http.ResponseWriter, r *http.Request) {
 		state := r.URL.Query().Get("state")
 		if state != expectedState {
-			errChan <- fmt.Errorf("invalid state parameter")
+			trySend(errChan, fmt.Errorf("invalid state parameter"))
 			http.Error(w, "Invalid state", http.StatusBadRequest)
 			return
 		}
 
 		if errMsg := r.URL.Query().Get("error"); errMsg != "" {
 			errDesc := r.URL.Query().Get("error_description")
-			errChan <- fmt.Errorf("authorization error: %s - %s", errMsg, errDesc)
+			trySend(errChan, fmt.Errorf("authorization error: %s - %s", errMsg, errDesc))
 			w.Header().Set("Content-Type", "text/html")
 			fmt.Fprintf(w, `<html><body><h1>Authorization Failed</h1><p>%s</p><script>setTimeout(function(){window.close();},3000);</script></body></html>`, html.EscapeString(errDesc))
 			return
@@ -141,30 +282,34 @@ func startCallbackServer(expectedState string, codeChan chan<- string, errChan c
 
 		code := r.URL.Query().Get("code")
 		if code == "" {
-			errChan <- fmt.Errorf("no authorization code received")
+			trySend(errChan, fmt.Errorf("no authorization code received"))
 			http.Error(w, "No code received", http.StatusBadRequest)
 			return
 		}
 
 		w.Header().Set("Content-Type", "text/html")
 		fmt.Fprint(w, `<html><body><h1>Authorization Successful!</h1><p>You can close this window.</p><script>setTimeout(function(){window.close();},3000);</script></body></html>`)
-		codeChan <- code
+		trySend(codeChan, code)
 	})
 
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", callbackPort))
+	return mux
+}
+
+func startCallbackServer(expectedState string, codeChan chan<- string, errChan chan<- error) (*http.Server, error) {
+	listener, err := net.Listen("tcp", callbackAddr)
 	if err != nil {
 		return nil, fmt.Errorf("port %d is already in use: %w", callbackPort, err)
 	}
 
 	server := &http.Server{
-		Handler:      mux,
+		Handler:      callbackHandler(expectedState, codeChan, errChan),
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 	}
 
 	go func() {
 		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
-			errChan <- fmt.Errorf("callback server error: %w", err)
+			trySend(errChan, fmt.Errorf("callback server error: %w", err))
 		}
 	}()
 
@@ -218,10 +363,9 @@ func exchangeCode(ctx context.Context, config OAuthConfig, code string) (*Token,
 		return nil, fmt.Errorf("parsing token response: %w", err)
 	}
 
-	return &Token{
-		AccessToken:  tokenResp.AccessToken,
-		RefreshToken: tokenResp.RefreshToken,
-		TokenType:    tokenResp.TokenType,
-		Expiry:       time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second),
-	}, nil
+	token := tokenResp.toToken(time.Now())
+	if !token.ScopesKnown() {
+		token.Scopes = strings.Fields(config.Scopes)
+	}
+	return token, nil
 }
@@ -1,8 +1,12 @@
 package auth
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/xokvictor/whoop-mcp/pkg/whoop"
 )
@@ -84,3 +88,109 @@ func TestBuildAuthURLDefaultScopes(t *testing.T) {
 		t.Error("URL should contain offline scope for refresh tokens")
 	}
 }
+
+func TestNormalizeScopes(t *testing.T) {
+	tests := []struct {
+		name     string
+		scopes   string
+		expected string
+		wantErr  bool
+	}{
+		{"empty uses defaults", "", defaultScopes, false},
+		{"adds offline", "read:sleep", "read:sleep offline", false},
+		{"keeps offline once", "offline read:cycles offline", "offline read:cycles", false},
+		{"dedupes", "read:sleep  read:sleep read:recovery", "read:sleep read:recovery offline", false},
+		{"unknown scope", "read:sleep write:everything", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeScopes(tt.scopes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeScopes(%q) error = %v, wantErr %v", tt.scopes, err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("NormalizeScopes(%q) = %q, want %q", tt.scopes, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseRedirect(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"redirect URL", "http://localhost:8080/callback?code=abc&state=s1", "abc", false},
+		{"surrounding whitespace", "  http://localhost:8080/callback?state=s1&code=abc \n", "abc", false},
+		{"bare code", "abc", "", true},
+		{"wrong state", "http://localhost:8080/callback?code=abc&state=other", "", true},
+		{"authorization error", "http://localhost:8080/callback?error=access_denied&error_description=denied&state=s1", "", true},
+		{"missing code", "http://localhost:8080/callback?state=s1", "", true},
+		{"empty", "   ", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRedirect(tt.input, "s1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRedirect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseRedirect() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReadRedirect(t *testing.T) {
+	codeChan := make(chan string, 1)
+	errChan := make(chan error, 1)
+
+	readRedirect(strings.NewReader("\n\nhttp://localhost:8080/callback?code=abc&state=s1\n"), "s1", codeChan, errChan)
+
+	select {
+	case code := <-codeChan:
+		if code != "abc" {
+			t.Errorf("code = %q, want abc", code)
+		}
+	case err := <-errChan:
+		t.Fatalf("readRedirect() error = %v", err)
+	default:
+		t.Fatal("readRedirect() delivered nothing")
+	}
+
+	readRedirect(strings.NewReader("http://localhost:8080/callback?code=abc&state=forged\n"), "s1", codeChan, errChan)
+	select {
+	case <-errChan:
+	default:
+		t.Error("readRedirect() should reject a mismatched state")
+	}
+}
+
+func TestCallbackHandlerDoesNotBlock(t *testing.T) {
+	codeChan := make(chan string, 1)
+	errChan := make(chan error, 1)
+	codeChan <- "pasted"
+	errChan <- errors.New("earlier failure")
+	handler := callbackHandler("s1", codeChan, errChan)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, target := range []string{"/callback?code=abc&state=s1", "/callback?code=abc&state=forged"} {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback handler blocked on a channel that already holds a result")
+	}
+	if code := <-codeChan; code != "pasted" {
+		t.Errorf("code = %q, want the first result to win", code)
+	}
+}
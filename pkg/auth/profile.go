@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+const (
+	// DefaultProfile is the profile used when none is selected. Its token is
+	// stored at ~/.whoop/token.json so single-account setups keep working.
+	DefaultProfile = "default"
+
+	profilesDirName = "profiles"
+)
+
+// Profile names become directory names, so keep them to a safe character set.
+var profileNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// ValidateProfileName returns an error if name cannot be used as a profile name.
+func ValidateProfileName(name string) error {
+	if !profileNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, '.', '_' or '-' (max 64 chars)", name)
+	}
+	return nil
+}
+
+// ProfileTokenPath returns the token file location for the given profile.
+// The default profile uses ~/.whoop/token.json, named profiles use
+// ~/.whoop/profiles/<name>/token.json.
+func ProfileTokenPath(profile string) (string, error) {
+	if profile == "" {
+		profile = DefaultProfile
+	}
+	if err := ValidateProfileName(profile); err != nil {
+		return "", err
+	}
+
+	baseDir, err := whoopDir()
+	if err != nil {
+		return "", err
+	}
+
+	if profile == DefaultProfile {
+		return filepath.Join(baseDir, tokenFileName), nil
+	}
+	return filepath.Join(baseDir, profilesDirName, profile, tokenFileName), nil
+}
+
+// ListProfiles returns the default profile followed by every named profile
+// directory under ~/.whoop/profiles, sorted by name.
+func ListProfiles() ([]string, error) {
+	baseDir, err := whoopDir()
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := []string{DefaultProfile}
+
+	entries, err := os.ReadDir(filepath.Join(baseDir, profilesDirName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return profiles, nil
+		}
+		return nil, fmt.Errorf("reading profiles directory: %w", err)
+	}
+
+	var named []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || name == DefaultProfile || ValidateProfileName(name) != nil {
+			continue
+		}
+		named = append(named, name)
+	}
+	sort.Strings(named)
+
+	return append(profiles, named...), nil
+}
+
+// whoopDir returns the ~/.whoop directory.
+func whoopDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("getting home directory: %w", err)
+	}
+	return filepath.Join(homeDir, dirName), nil
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestValidateProfileName(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		wantErr bool
+	}{
+		{"simple", "alice", false},
+		{"with dash and digits", "team-2", false},
+		{"with dot and underscore", "bob.work_1", false},
+		{"empty", "", true},
+		{"path traversal", "../evil", true},
+		{"slash", "a/b", true},
+		{"leading dot", ".hidden", true},
+		{"space", "my profile", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateProfileName(tt.profile)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateProfileName(%q) error = %v, wantErr %v", tt.profile, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProfileTokenPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	tests := []struct {
+		profile  string
+		expected string
+	}{
+		{"", filepath.Join(home, ".whoop", "token.json")},
+		{"default", filepath.Join(home, ".whoop", "token.json")},
+		{"alice", filepath.Join(home, ".whoop", "profiles", "alice", "token.json")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			got, err := ProfileTokenPath(tt.profile)
+			if err != nil {
+				t.Fatalf("ProfileTokenPath() error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("ProfileTokenPath() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := ProfileTokenPath("../x"); err == nil {
+		t.Error("ProfileTokenPath() should reject invalid profile names")
+	}
+}
+
+func TestListProfiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	profiles, err := ListProfiles()
+	if err != nil {
+		t.Fatalf("ListProfiles() error = %v", err)
+	}
+	if !reflect.DeepEqual(profiles, []string{"default"}) {
+		t.Errorf("ListProfiles() = %v, want [default]", profiles)
+	}
+
+	profilesDir := filepath.Join(home, ".whoop", "profiles")
+	for _, name := range []string{"zoe", "alice", ".tmp"} {
+		if err := os.MkdirAll(filepath.Join(profilesDir, name), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(profilesDir, "stray-file"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	profiles, err = ListProfiles()
+	if err != nil {
+		t.Fatalf("ListProfiles() error = %v", err)
+	}
+	expected := []string{"default", "alice", "zoe"}
+	if !reflect.DeepEqual(profiles, expected) {
+		t.Errorf("ListProfiles() = %v, want %v", profiles, expected)
+	}
+}
+
+func TestNewProfileTokenManager(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	tm, err := NewProfileTokenManager("id", "secret", "alice")
+	if err != nil {
+		t.Fatalf("NewProfileTokenManager() error = %v", err)
+	}
+	if tm.Profile() != "alice" {
+		t.Errorf("Profile() = %v, want alice", tm.Profile())
+	}
+	expected := filepath.Join(home, ".whoop", "profiles", "alice", "token.json")
+	if tm.TokenPath() != expected {
+		t.Errorf("TokenPath() = %v, want %v", tm.TokenPath(), expected)
+	}
+
+	tm, err = NewProfileTokenManager("id", "secret", "")
+	if err != nil {
+		t.Fatalf("NewProfileTokenManager() error = %v", err)
+	}
+	if tm.Profile() != DefaultProfile {
+		t.Errorf("Profile() = %v, want %v", tm.Profile(), DefaultProfile)
+	}
+
+	if _, err := NewProfileTokenManager("id", "secret", "a/b"); err == nil {
+		t.Error("NewProfileTokenManager() should reject invalid profile names")
+	}
+}
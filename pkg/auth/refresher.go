@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const (
+	defaultRefreshFraction = 0.8
+	defaultMinBackoff      = 30 * time.Second
+	defaultMaxBackoff      = 15 * time.Minute
+	defaultIdleInterval    = time.Minute
+)
+
+// RefresherConfig controls the background token refresher.
+type RefresherConfig struct {
+	// RefreshFraction is the fraction of the token lifetime after which the
+	// token is refreshed, between 0 and 1 exclusive. Defaults to 0.8.
+	RefreshFraction float64
+	// MinBackoff and MaxBackoff bound the exponential backoff between
+	// failed refresh attempts. Default to 30s and 15m.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// IdleInterval is how often to check for a token when none is stored
+	// or it carries no expiry. Defaults to 1m.
+	IdleInterval time.Duration
+	// Logger receives refresh events. Defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+func (c RefresherConfig) withDefaults() RefresherConfig {
+	if c.RefreshFraction <= 0 || c.RefreshFraction >= 1 {
+		c.RefreshFraction = defaultRefreshFraction
+	}
+	if c.MinBackoff <= 0 {
+		c.MinBackoff = defaultMinBackoff
+	}
+	if c.MaxBackoff < c.MinBackoff {
+		c.MaxBackoff = max(defaultMaxBackoff, c.MinBackoff)
+	}
+	if c.IdleInterval <= 0 {
+		c.IdleInterval = defaultIdleInterval
+	}
+	if c.Logger == nil {
+		c.Logger = slog.Default()
+	}
+	return c
+}
+
+// RefreshStatus describes the most recent token refresh activity, whether
+// triggered by a request or by the background refresher.
+type RefreshStatus struct {
+	LastRefresh      time.Time `json:"last_refresh,omitempty"`
+	LastError        string    `json:"last_error,omitempty"`
+	LastErrorAt      time.Time `json:"last_error_at,omitempty"`
+	NextRefresh      time.Time `json:"next_refresh,omitempty"`
+	BackgroundActive bool      `json:"background_active"`
+}
+
+// RefreshStatus returns a snapshot of the refresh status.
+func (tm *TokenManager) RefreshStatus() RefreshStatus {
+	tm.statusMu.Lock()
+	defer tm.statusMu.Unlock()
+	return tm.status
+}
+
+func (tm *TokenManager) recordRefresh(trigger string, err error) {
+	if tm.observer != nil {
+		tm.observer.TokenRefreshed(tm.profile, trigger, err)
+	}
+
+	tm.statusMu.Lock()
+	defer tm.statusMu.Unlock()
+	if err != nil {
+		tm.status.LastError = err.Error()
+		tm.status.LastErrorAt = time.Now()
+		return
+	}
+	tm.status.LastRefresh = time.Now()
+	tm.status.LastError = ""
+	tm.status.LastErrorAt = time.Time{}
+}
+
+func (tm *TokenManager) updateStatus(update func(*RefreshStatus)) {
+	tm.statusMu.Lock()
+	defer tm.statusMu.Unlock()
+	update(&tm.status)
+}
+
+// StartRefresher refreshes the token in the background before it expires,
+// so requests rarely pay for a token round-trip. It returns immediately;
+// the goroutine stops when ctx is cancelled.
+func (tm *TokenManager) StartRefresher(ctx context.Context, config RefresherConfig) {
+	config = config.withDefaults()
+	tm.updateStatus(func(s *RefreshStatus) { s.BackgroundActive = true })
+	go tm.runRefresher(ctx, config)
+}
+
+func (tm *TokenManager) runRefresher(ctx context.Context, config RefresherConfig) {
+	logger := config.Logger.With("profile", tm.profile)
+	logger.Debug("background token refresher started")
+	defer func() {
+		tm.updateStatus(func(s *RefreshStatus) {
+			s.BackgroundActive = false
+			s.NextRefresh = time.Time{}
+		})
+		logger.Debug("background token refresher stopped")
+	}()
+
+	backoff := time.Duration(0)
+	for {
+		var wait time.Duration
+		if backoff > 0 {
+			wait = backoff
+		} else {
+			wait = tm.nextRefreshDelay(config)
+		}
+		tm.updateStatus(func(s *RefreshStatus) { s.NextRefresh = time.Now().Add(wait) })
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		token, err := tm.Load()
+		if err != nil {
+			logger.Warn("background token refresh: reading token failed", "error", err)
+			backoff = nextBackoff(backoff, config)
+			continue
+		}
+		if token == nil || !refreshDue(token, config.RefreshFraction, time.Now()) {
+			backoff = 0
+			continue
+		}
+
+		_, err = tm.sharedRefresh(ctx, RefreshBackground, "background", func(t *Token) bool {
+			return refreshDue(t, config.RefreshFraction, time.Now())
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			backoff = nextBackoff(backoff, config)
+			logger.Warn("background token refresh failed", "error", err, "retry_in", backoff)
+			continue
+		}
+
+		backoff = 0
+		logger.Info("background token refresh succeeded")
+	}
+}
+
+// nextRefreshDelay returns how long to wait before the stored token is due.
+func (tm *TokenManager) nextRefreshDelay(config RefresherConfig) time.Duration {
+	token, err := tm.Load()
+	if err != nil || token == nil || token.Expiry.IsZero() {
+		return config.IdleInterval
+	}
+
+	delay := time.Until(refreshDueAt(token, config.RefreshFraction))
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+// refreshDueAt returns when the token reaches the given fraction of its
+// lifetime. Tokens without an issue time are refreshed at the same point
+// IsExpired would trigger.
+func refreshDueAt(t *Token, fraction float64) time.Time {
+	if t.IssuedAt.IsZero() || !t.Expiry.After(t.IssuedAt) {
+		return t.Expiry.Add(-expiryBuffer)
+	}
+	lifetime := t.Expiry.Sub(t.IssuedAt)
+	due := t.IssuedAt.Add(time.Duration(float64(lifetime) * fraction))
+	if buffered := t.Expiry.Add(-expiryBuffer); buffered.Before(due) {
+		return buffered
+	}
+	return due
+}
+
+func refreshDue(t *Token, fraction float64, now time.Time) bool {
+	if t.Expiry.IsZero() {
+		return false
+	}
+	return !now.Before(refreshDueAt(t, fraction))
+}
+
+func nextBackoff(current time.Duration, config RefresherConfig) time.Duration {
+	if current <= 0 {
+		return config.MinBackoff
+	}
+	return min(current*2, config.MaxBackoff)
+}
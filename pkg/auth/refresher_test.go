@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRefreshDueAt(t *testing.T) {
+	issued := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		token    Token
+		fraction float64
+		expected time.Time
+	}{
+		{
+			name:     "fraction of lifetime",
+			token:    Token{IssuedAt: issued, Expiry: issued.Add(10 * time.Hour)},
+			fraction: 0.8,
+			expected: issued.Add(8 * time.Hour),
+		},
+		{
+			name:     "capped by expiry buffer",
+			token:    Token{IssuedAt: issued, Expiry: issued.Add(10 * time.Minute)},
+			fraction: 0.9,
+			expected: issued.Add(5 * time.Minute),
+		},
+		{
+			name:     "unknown issue time",
+			token:    Token{Expiry: issued.Add(time.Hour)},
+			fraction: 0.5,
+			expected: issued.Add(55 * time.Minute),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := refreshDueAt(&tt.token, tt.fraction); !got.Equal(tt.expected) {
+				t.Errorf("refreshDueAt() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNextBackoff(t *testing.T) {
+	config := RefresherConfig{MinBackoff: time.Second, MaxBackoff: 5 * time.Second}.withDefaults()
+
+	backoff := time.Duration(0)
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, want := range expected {
+		backoff = nextBackoff(backoff, config)
+		if backoff != want {
+			t.Errorf("step %d: nextBackoff() = %v, want %v", i, backoff, want)
+		}
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before deadline")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func testRefresherConfig() RefresherConfig {
+	return RefresherConfig{
+		RefreshFraction: 0.5,
+		MinBackoff:      50 * time.Millisecond,
+		MaxBackoff:      100 * time.Millisecond,
+		IdleInterval:    50 * time.Millisecond,
+		Logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+}
+
+func TestStartRefresher(t *testing.T) {
+	var calls int32
+	server := newRefreshServer(t, &calls)
+	tm := newTestTokenManager(t, server.URL)
+
+	// Past half of its lifetime, but not yet expired.
+	now := time.Now()
+	if err := tm.Save(&Token{
+		AccessToken:  "access-old",
+		RefreshToken: "refresh-0",
+		IssuedAt:     now.Add(-2 * time.Hour),
+		Expiry:       now.Add(time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tm.StartRefresher(ctx, testRefresherConfig())
+
+	waitFor(t, func() bool { return !tm.RefreshStatus().LastRefresh.IsZero() })
+
+	token, err := tm.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "access-new" {
+		t.Errorf("AccessToken = %q, want access-new", token.AccessToken)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("refresh requests = %d, want 1", got)
+	}
+
+	status := tm.RefreshStatus()
+	if !status.BackgroundActive {
+		t.Error("BackgroundActive should be true while running")
+	}
+	if status.NextRefresh.IsZero() {
+		t.Error("NextRefresh should be scheduled after a successful refresh")
+	}
+
+	cancel()
+	waitFor(t, func() bool { return !tm.RefreshStatus().BackgroundActive })
+}
+
+func TestStartRefresherRecordsFailures(t *testing.T) {
+	var calls int32
+	server := newRefreshServer(t, &calls)
+	tm := newTestTokenManager(t, server.URL)
+
+	if err := tm.Save(&Token{
+		AccessToken:  "access-old",
+		RefreshToken: "revoked",
+		Expiry:       time.Now().Add(time.Minute),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tm.StartRefresher(ctx, testRefresherConfig())
+
+	// The refresher keeps retrying with backoff after the first failure.
+	waitFor(t, func() bool { return atomic.LoadInt32(&calls) >= 2 })
+
+	status := tm.RefreshStatus()
+	if status.LastError == "" {
+		t.Error("LastError should be recorded after a failed refresh")
+	}
+	if !status.LastRefresh.IsZero() {
+		t.Error("LastRefresh should stay unset when every refresh fails")
+	}
+}
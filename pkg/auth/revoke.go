@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// LogoutResult reports what Logout managed to revoke and remove.
+type LogoutResult struct {
+	Profile        string   `json:"profile"`
+	AccessRevoked  bool     `json:"access_revoked"`
+	RefreshRevoked bool     `json:"refresh_revoked"`
+	TokenDeleted   bool     `json:"token_deleted"`
+	Warnings       []string `json:"warnings,omitempty"`
+}
+
+// RevokeAccessToken revokes the user's grant for this application on
+// WHOOP's side, invalidating the access token.
+func (tm *TokenManager) RevokeAccessToken(ctx context.Context, accessToken string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, tm.userAccessURL, nil)
+	if err != nil {
+		return fmt.Errorf("creating revoke request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := tm.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing revoke request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("revoking access failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// RevokeRefreshToken revokes the refresh token through the OAuth 2.0 token
+// revocation endpoint (RFC 7009).
+func (tm *TokenManager) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	data := url.Values{}
+	data.Set("token", refreshToken)
+	data.Set("token_type_hint", "refresh_token")
+	data.Set("client_id", tm.clientID)
+	data.Set("client_secret", tm.clientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tm.revokeURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return fmt.Errorf("creating revoke request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := tm.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("executing revoke request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("revoking refresh token failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Logout revokes the stored access and refresh tokens on WHOOP's side and
+// deletes the token file. Revocation failures are reported as warnings so
+// that the local token is always removed; only a failure to delete the
+// file is returned as an error.
+func (tm *TokenManager) Logout(ctx context.Context) (*LogoutResult, error) {
+	result := &LogoutResult{Profile: tm.profile}
+
+	lock, err := acquireFileLock(ctx, tm.tokenPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = lock.Unlock() }()
+
+	token, err := tm.Load()
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("reading token: %v", err))
+	}
+
+	if token != nil {
+		if token.AccessToken != "" {
+			if err := tm.RevokeAccessToken(ctx, token.AccessToken); err != nil {
+				result.Warnings = append(result.Warnings, err.Error())
+			} else {
+				result.AccessRevoked = true
+			}
+		}
+		if token.RefreshToken != "" {
+			if err := tm.RevokeRefreshToken(ctx, token.RefreshToken); err != nil {
+				result.Warnings = append(result.Warnings, err.Error())
+			} else {
+				result.RefreshRevoked = true
+			}
+		}
+	}
+
+	if err := tm.Delete(); err != nil {
+		return result, err
+	}
+	result.TokenDeleted = token != nil
+
+	return result, nil
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func newRevokeServer(t *testing.T, accessStatus, refreshStatus int, seen map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodDelete && r.URL.Path == "/v2/user/access":
+			seen["access"] = r.Header.Get("Authorization")
+			w.WriteHeader(accessStatus)
+		case r.Method == http.MethodPost && r.URL.Path == "/revoke":
+			if err := r.ParseForm(); err != nil {
+				t.Errorf("ParseForm() error = %v", err)
+			}
+			seen["refresh"] = r.PostForm.Get("token")
+			seen["hint"] = r.PostForm.Get("token_type_hint")
+			w.WriteHeader(refreshStatus)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestLogout(t *testing.T) {
+	t.Run("revokes both tokens and deletes the file", func(t *testing.T) {
+		seen := map[string]string{}
+		server := newRevokeServer(t, http.StatusNoContent, http.StatusOK, seen)
+		tm := newTestTokenManager(t, server.URL+"/token")
+		tm.revokeURL = server.URL + "/revoke"
+		tm.userAccessURL = server.URL + "/v2/user/access"
+
+		if err := tm.Save(&Token{AccessToken: "access", RefreshToken: "refresh", Expiry: time.Now().Add(time.Hour)}); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := tm.Logout(context.Background())
+		if err != nil {
+			t.Fatalf("Logout() error = %v", err)
+		}
+		if !result.AccessRevoked || !result.RefreshRevoked || !result.TokenDeleted {
+			t.Errorf("Logout() = %+v, want everything revoked and deleted", result)
+		}
+		if len(result.Warnings) != 0 {
+			t.Errorf("unexpected warnings: %v", result.Warnings)
+		}
+		if seen["access"] != "Bearer access" {
+			t.Errorf("access revoke Authorization = %q", seen["access"])
+		}
+		if seen["refresh"] != "refresh" || seen["hint"] != "refresh_token" {
+			t.Errorf("refresh revoke form token=%q hint=%q", seen["refresh"], seen["hint"])
+		}
+		if _, err := os.Stat(tm.TokenPath()); !os.IsNotExist(err) {
+			t.Error("token file should be deleted")
+		}
+	})
+
+	t.Run("deletes the file even when revocation fails", func(t *testing.T) {
+		seen := map[string]string{}
+		server := newRevokeServer(t, http.StatusUnauthorized, http.StatusNotFound, seen)
+		tm := newTestTokenManager(t, server.URL+"/token")
+		tm.revokeURL = server.URL + "/revoke"
+		tm.userAccessURL = server.URL + "/v2/user/access"
+
+		if err := tm.Save(&Token{AccessToken: "access", RefreshToken: "refresh"}); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := tm.Logout(context.Background())
+		if err != nil {
+			t.Fatalf("Logout() error = %v", err)
+		}
+		if result.AccessRevoked || result.RefreshRevoked {
+			t.Errorf("Logout() = %+v, revocations should have failed", result)
+		}
+		if !result.TokenDeleted {
+			t.Error("TokenDeleted should be true")
+		}
+		if len(result.Warnings) != 2 {
+			t.Errorf("Warnings = %v, want 2 entries", result.Warnings)
+		}
+	})
+
+	t.Run("no stored token", func(t *testing.T) {
+		tm := newTestTokenManager(t, "http://127.0.0.1:0")
+
+		result, err := tm.Logout(context.Background())
+		if err != nil {
+			t.Fatalf("Logout() error = %v", err)
+		}
+		if result.TokenDeleted {
+			t.Error("TokenDeleted should be false when there was no token")
+		}
+	})
+}
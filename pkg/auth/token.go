@@ -3,14 +3,24 @@ package auth
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+
 	"github.com/xokvictor/whoop-mcp/pkg/whoop"
 )
 
@@ -19,6 +29,12 @@ const (
 	dirName       = ".whoop"
 	dirPerm       = 0700
 	filePerm      = 0600
+
+	// expiryBuffer is how long before expiry a token is treated as expired.
+	expiryBuffer = 5 * time.Minute
+
+	// tracerName identifies the package's spans.
+	tracerName = "github.com/xokvictor/whoop-mcp/pkg/auth"
 )
 
 // Token represents OAuth2 token data stored on disk.
@@ -27,6 +43,10 @@ type Token struct {
 	RefreshToken string    `json:"refresh_token"`
 	TokenType    string    `json:"token_type"`
 	Expiry       time.Time `json:"expiry"`
+	IssuedAt     time.Time `json:"issued_at,omitempty"`
+	// Scopes lists the scopes WHOOP granted. It is nil for tokens saved
+	// before scopes were recorded, in which case they are unknown.
+	Scopes []string `json:"scopes,omitempty"`
 }
 
 // IsExpired returns true if the token has expired or will expire within 5 minutes.
@@ -34,7 +54,37 @@ func (t *Token) IsExpired() bool {
 	if t.Expiry.IsZero() {
 		return false
 	}
-	return time.Now().Add(5 * time.Minute).After(t.Expiry)
+	return time.Now().Add(expiryBuffer).After(t.Expiry)
+}
+
+// ScopesKnown returns true if the granted scopes were recorded.
+func (t *Token) ScopesKnown() bool {
+	return len(t.Scopes) > 0
+}
+
+// HasScope returns true if the scope was granted. Unknown scopes are
+// assumed granted so that older token files keep working.
+func (t *Token) HasScope(scope string) bool {
+	if !t.ScopesKnown() {
+		return true
+	}
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// MissingScopes returns the required scopes that were not granted.
+func (t *Token) MissingScopes(required ...string) []string {
+	var missing []string
+	for _, scope := range required {
+		if !t.HasScope(scope) {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
 }
 
 // ExpiresIn returns the duration until the token expires.
@@ -46,31 +96,87 @@ func (t *Token) ExpiresIn() time.Duration {
 }
 
 // TokenManager handles loading, saving, and refreshing OAuth tokens.
+// Each profile has its own TokenManager and token file.
 type TokenManager struct {
-	tokenPath    string
-	clientID     string
-	clientSecret string
-	httpClient   *http.Client
+	profile       string
+	tokenPath     string
+	tokenURL      string
+	revokeURL     string
+	userAccessURL string
+	clientID      string
+	clientSecret  string
+	httpClient    *http.Client
+
+	// refreshGroup collapses concurrent refreshes within this process.
+	refreshGroup singleflight.Group
+
+	statusMu sync.Mutex
+	status   RefreshStatus
+
+	observer TokenObserver
 }
 
-// NewTokenManager creates a new TokenManager.
+// Refresh triggers reported to a TokenObserver.
+const (
+	// RefreshExpired is a refresh for a request whose token had expired.
+	RefreshExpired = "expired"
+	// RefreshRejected is a refresh after WHOOP rejected the token with 401.
+	RefreshRejected = "rejected"
+	// RefreshBackground is a refresh by the background refresher.
+	RefreshBackground = "background"
+)
+
+// TokenObserver receives token cache and refresh events, for example to
+// record metrics. It is called synchronously and must not block.
+type TokenObserver interface {
+	// TokenLookup is called when a request asks for the access token;
+	// cached reports whether the stored token was still valid.
+	TokenLookup(profile string, cached bool)
+	// TokenRefreshed is called after each refresh attempt with its trigger
+	// (RefreshExpired, RefreshRejected or RefreshBackground) and outcome.
+	TokenRefreshed(profile, trigger string, err error)
+}
+
+// SetObserver sets the observer notified of token lookups and refreshes.
+// It must be called before the manager is used.
+func (tm *TokenManager) SetObserver(observer TokenObserver) {
+	tm.observer = observer
+}
+
+// NewTokenManager creates a new TokenManager for the default profile.
 func NewTokenManager(clientID, clientSecret string) (*TokenManager, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("getting home directory: %w", err)
+	return NewProfileTokenManager(clientID, clientSecret, DefaultProfile)
+}
+
+// NewProfileTokenManager creates a new TokenManager for the named profile.
+// An empty profile name selects the default profile.
+func NewProfileTokenManager(clientID, clientSecret, profile string) (*TokenManager, error) {
+	if profile == "" {
+		profile = DefaultProfile
 	}
 
-	tokenDir := filepath.Join(homeDir, dirName)
-	tokenPath := filepath.Join(tokenDir, tokenFileName)
+	tokenPath, err := ProfileTokenPath(profile)
+	if err != nil {
+		return nil, err
+	}
 
 	return &TokenManager{
-		tokenPath:    tokenPath,
-		clientID:     clientID,
-		clientSecret: clientSecret,
-		httpClient:   &http.Client{Timeout: 30 * time.Second},
+		profile:       profile,
+		tokenPath:     tokenPath,
+		tokenURL:      whoop.TokenURL,
+		revokeURL:     whoop.RevokeURL,
+		userAccessURL: whoop.BaseURL + "/v2/user/access",
+		clientID:      clientID,
+		clientSecret:  clientSecret,
+		httpClient:    &http.Client{Timeout: 30 * time.Second},
 	}, nil
 }
 
+// Profile returns the name of the profile this manager belongs to.
+func (tm *TokenManager) Profile() string {
+	return tm.profile
+}
+
 // TokenPath returns the path to the token file.
 func (tm *TokenManager) TokenPath() string {
 	return tm.tokenPath
@@ -95,6 +201,8 @@ func (tm *TokenManager) Load() (*Token, error) {
 }
 
 // Save writes the token to disk with appropriate permissions.
+// The file is written to a temporary file and renamed into place so that
+// concurrent readers never observe a partially written token.
 func (tm *TokenManager) Save(token *Token) error {
 	dir := filepath.Dir(tm.tokenPath)
 	if err := os.MkdirAll(dir, dirPerm); err != nil {
@@ -106,9 +214,32 @@ func (tm *TokenManager) Save(token *Token) error {
 		return fmt.Errorf("encoding token: %w", err)
 	}
 
-	if err := os.WriteFile(tm.tokenPath, data, filePerm); err != nil {
+	tmp, err := os.CreateTemp(dir, tokenFileName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating temporary token file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if err := tmp.Chmod(filePerm); err != nil {
+		tmp.Close()
+		return fmt.Errorf("setting token file permissions: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
 		return fmt.Errorf("writing token file: %w", err)
 	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("syncing token file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("closing token file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, tm.tokenPath); err != nil {
+		return fmt.Errorf("replacing token file: %w", err)
+	}
 
 	return nil
 }
@@ -130,7 +261,7 @@ func (tm *TokenManager) Refresh(ctx context.Context, refreshToken string) (*Toke
 	data.Set("client_id", tm.clientID)
 	data.Set("client_secret", tm.clientSecret)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, whoop.TokenURL, strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tm.tokenURL, strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, fmt.Errorf("creating refresh request: %w", err)
 	}
@@ -138,12 +269,12 @@ func (tm *TokenManager) Refresh(ctx context.Context, refreshToken string) (*Toke
 
 	resp, err := tm.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("executing refresh request: %w", err)
+		return nil, fmt.Errorf("%w: executing refresh request: %w", whoop.ErrUpstream, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("refresh failed with status %d", resp.StatusCode)
+		return nil, refreshError(resp)
 	}
 
 	var tokenResp tokenResponse
@@ -151,11 +282,13 @@ func (tm *TokenManager) Refresh(ctx context.Context, refreshToken string) (*Toke
 		return nil, fmt.Errorf("parsing refresh response: %w", err)
 	}
 
-	token := &Token{
-		AccessToken:  tokenResp.AccessToken,
-		RefreshToken: tokenResp.RefreshToken,
-		TokenType:    tokenResp.TokenType,
-		Expiry:       time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second),
+	token := tokenResp.toToken(time.Now())
+
+	// WHOOP may omit the scope on refresh; the grant itself is unchanged.
+	if !token.ScopesKnown() {
+		if previous, err := tm.Load(); err == nil && previous != nil {
+			token.Scopes = previous.Scopes
+		}
 	}
 
 	if err := tm.Save(token); err != nil {
@@ -165,8 +298,47 @@ func (tm *TokenManager) Refresh(ctx context.Context, refreshToken string) (*Toke
 	return token, nil
 }
 
+// refreshError classifies a failed refresh response. Only invalid_grant
+// means the refresh token itself is no longer valid and wraps
+// whoop.ErrRefreshRejected; rate limits and server errors are transient.
+func refreshError(resp *http.Response) error {
+	var body struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&body)
+
+	msg := fmt.Sprintf("refresh failed with status %d", resp.StatusCode)
+	if body.Error != "" {
+		msg += ": " + body.Error
+	}
+	if body.ErrorDescription != "" {
+		msg += " (" + body.ErrorDescription + ")"
+	}
+
+	switch {
+	case body.Error == "invalid_grant" && (resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized):
+		return fmt.Errorf("%w: %s", whoop.ErrRefreshRejected, msg)
+	case resp.StatusCode == http.StatusTooManyRequests:
+		var wait time.Duration
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			wait = time.Duration(seconds) * time.Second
+		}
+		return fmt.Errorf("%s: %w", msg, &whoop.ErrRateLimited{RetryAfter: wait})
+	case resp.StatusCode >= 500:
+		return fmt.Errorf("%w: %s", whoop.ErrUpstream, msg)
+	default:
+		return errors.New(msg)
+	}
+}
+
 // EnsureValidToken loads the token and refreshes it if expired.
 // Returns the valid access token or an error if not authenticated.
+//
+// Refreshes are serialized: goroutines in this process share a single
+// in-flight refresh, and processes sharing the token file coordinate through
+// an advisory file lock. WHOOP rotates refresh tokens, so only one refresh
+// per expiry may reach the server.
 func (tm *TokenManager) EnsureValidToken(ctx context.Context) (string, error) {
 	token, err := tm.Load()
 	if err != nil {
@@ -176,17 +348,99 @@ func (tm *TokenManager) EnsureValidToken(ctx context.Context) (string, error) {
 		return "", nil
 	}
 
-	if token.IsExpired() {
-		if token.RefreshToken == "" {
-			return "", fmt.Errorf("token expired and no refresh token available")
+	if tm.observer != nil {
+		tm.observer.TokenLookup(tm.profile, !token.IsExpired())
+	}
+	if !token.IsExpired() {
+		return token.AccessToken, nil
+	}
+
+	return tm.sharedRefresh(ctx, RefreshExpired, "refresh", func(t *Token) bool {
+		return t.IsExpired()
+	})
+}
+
+// ForceRefresh returns an access token other than rejected. If the stored
+// token is still the rejected one, it is refreshed even though its expiry
+// has not passed; if another caller already replaced it, the replacement is
+// returned without contacting the server.
+func (tm *TokenManager) ForceRefresh(ctx context.Context, rejected string) (string, error) {
+	return tm.sharedRefresh(ctx, RefreshRejected, "force:"+rejected, func(t *Token) bool {
+		return t.IsExpired() || (rejected != "" && t.AccessToken == rejected)
+	})
+}
+
+// sharedRefresh runs refreshWithLock at most once at a time per key and
+// hands the result to every waiting caller.
+func (tm *TokenManager) sharedRefresh(ctx context.Context, trigger, key string, needsRefresh func(*Token) bool) (string, error) {
+	// Detach from the caller's cancellation so that one abandoned request
+	// does not fail the refresh shared by every other waiter.
+	refreshCtx := context.WithoutCancel(ctx)
+	ch := tm.refreshGroup.DoChan(key, func() (interface{}, error) {
+		return tm.refreshWithLock(refreshCtx, trigger, needsRefresh)
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return "", res.Err
 		}
-		token, err = tm.Refresh(ctx, token.RefreshToken)
+		return res.Val.(*Token).AccessToken, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// refreshWithLock refreshes the token while holding the cross-process lock.
+// The token file is re-read after the lock is acquired and needsRefresh is
+// evaluated against it, so a refresh that another process completed in the
+// meantime is reused instead of repeated.
+func (tm *TokenManager) refreshWithLock(ctx context.Context, trigger string, needsRefresh func(*Token) bool) (token *Token, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "refresh access token", trace.WithAttributes(
+		attribute.String("whoop.profile", tm.profile),
+		attribute.String("whoop.refresh.trigger", trigger),
+	))
+	defer func() {
 		if err != nil {
-			return "", fmt.Errorf("refreshing token: %w", err)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
 		}
+		span.End()
+	}()
+
+	lock, err := acquireFileLock(ctx, tm.tokenPath)
+	if err != nil {
+		return nil, err
 	}
+	defer func() { _ = lock.Unlock() }()
 
-	return token.AccessToken, nil
+	stored, err := tm.Load()
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, fmt.Errorf("no stored token for profile %q", tm.profile)
+	}
+
+	if !needsRefresh(stored) {
+		// Another caller or process refreshed it while we waited
+		span.SetAttributes(attribute.Bool("whoop.refresh.reused", true))
+		return stored, nil
+	}
+
+	if stored.RefreshToken == "" {
+		err := fmt.Errorf("%w: token needs refresh but no refresh token is available", whoop.ErrRefreshRejected)
+		tm.recordRefresh(trigger, err)
+		return nil, err
+	}
+
+	token, err = tm.Refresh(ctx, stored.RefreshToken)
+	if err != nil {
+		err = fmt.Errorf("refreshing token: %w", err)
+	}
+	tm.recordRefresh(trigger, err)
+
+	return token, err
 }
 
 // tokenResponse represents the OAuth2 token response from WHOOP API.
@@ -197,3 +451,15 @@ type tokenResponse struct {
 	ExpiresIn    int    `json:"expires_in"`
 	Scope        string `json:"scope"`
 }
+
+// toToken converts the response into a Token issued at now.
+func (r *tokenResponse) toToken(now time.Time) *Token {
+	return &Token{
+		AccessToken:  r.AccessToken,
+		RefreshToken: r.RefreshToken,
+		TokenType:    r.TokenType,
+		Expiry:       now.Add(time.Duration(r.ExpiresIn) * time.Second),
+		IssuedAt:     now,
+		Scopes:       strings.Fields(r.Scope),
+	}
+}
@@ -1,10 +1,27 @@
 package auth
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
 )
 
 func TestTokenIsExpired(t *testing.T) {
@@ -64,6 +81,32 @@ func TestTokenExpiresIn(t *testing.T) {
 	})
 }
 
+func TestTokenMissingScopes(t *testing.T) {
+	unknown := &Token{}
+	if missing := unknown.MissingScopes("read:sleep"); len(missing) != 0 {
+		t.Errorf("unknown scopes should be assumed granted, got missing %v", missing)
+	}
+
+	token := &Token{Scopes: []string{"read:sleep", "offline"}}
+	if !token.HasScope("read:sleep") {
+		t.Error("HasScope(read:sleep) = false, want true")
+	}
+	if token.HasScope("read:recovery") {
+		t.Error("HasScope(read:recovery) = true, want false")
+	}
+
+	missing := token.MissingScopes("read:sleep", "read:recovery", "read:cycles")
+	expected := []string{"read:recovery", "read:cycles"}
+	if len(missing) != len(expected) {
+		t.Fatalf("MissingScopes() = %v, want %v", missing, expected)
+	}
+	for i := range expected {
+		if missing[i] != expected[i] {
+			t.Errorf("MissingScopes()[%d] = %v, want %v", i, missing[i], expected[i])
+		}
+	}
+}
+
 func TestTokenManagerLoadSave(t *testing.T) {
 	// Create temp directory
 	tmpDir := t.TempDir()
@@ -173,3 +216,440 @@ func TestNewTokenManager(t *testing.T) {
 		t.Errorf("tokenPath should end with token.json, got %v", tm.tokenPath)
 	}
 }
+
+func TestTokenManagerSaveLeavesNoTempFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	tm := &TokenManager{tokenPath: filepath.Join(tmpDir, "token.json")}
+
+	for i := 0; i < 3; i++ {
+		if err := tm.Save(&Token{AccessToken: "token"}); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "token.json" {
+		names := make([]string, 0, len(entries))
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("directory contents = %v, want only token.json", names)
+	}
+}
+
+// newRefreshServer returns a token endpoint that counts refresh requests and
+// rotates the refresh token on every call, like WHOOP does.
+func newRefreshServer(t *testing.T, calls *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(calls, 1)
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("ParseForm() error = %v", err)
+		}
+		if r.PostForm.Get("refresh_token") != "refresh-0" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant","error_description":"refresh token revoked"}`))
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tokenResponse{
+			AccessToken:  "access-new",
+			RefreshToken: fmt.Sprintf("refresh-%d", n),
+			TokenType:    "bearer",
+			ExpiresIn:    3600,
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestTokenManager(t *testing.T, tokenURL string) *TokenManager {
+	t.Helper()
+	return &TokenManager{
+		tokenPath:    filepath.Join(t.TempDir(), "token.json"),
+		tokenURL:     tokenURL,
+		clientID:     "test-client",
+		clientSecret: "test-secret",
+		httpClient:   &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func TestEnsureValidTokenConcurrentRefresh(t *testing.T) {
+	var calls int32
+	server := newRefreshServer(t, &calls)
+	tm := newTestTokenManager(t, server.URL)
+
+	expired := &Token{
+		AccessToken:  "access-old",
+		RefreshToken: "refresh-0",
+		Expiry:       time.Now().Add(-time.Hour),
+	}
+	if err := tm.Save(expired); err != nil {
+		t.Fatal(err)
+	}
+
+	const workers = 10
+	var wg sync.WaitGroup
+	results := make([]string, workers)
+	errs := make([]error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = tm.EnsureValidToken(context.Background())
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < workers; i++ {
+		if errs[i] != nil {
+			t.Errorf("worker %d: EnsureValidToken() error = %v", i, errs[i])
+		}
+		if results[i] != "access-new" {
+			t.Errorf("worker %d: token = %q, want access-new", i, results[i])
+		}
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("refresh requests = %d, want 1", got)
+	}
+}
+
+func TestEnsureValidTokenRereadsAfterLock(t *testing.T) {
+	var calls int32
+	server := newRefreshServer(t, &calls)
+	tm := newTestTokenManager(t, server.URL)
+
+	if err := tm.Save(&Token{
+		AccessToken:  "access-old",
+		RefreshToken: "refresh-0",
+		Expiry:       time.Now().Add(-time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate another process that holds the lock while it refreshes.
+	lock, err := acquireFileLock(context.Background(), tm.tokenPath)
+	if err != nil {
+		t.Fatalf("acquireFileLock() error = %v", err)
+	}
+
+	done := make(chan string, 1)
+	go func() {
+		token, err := tm.EnsureValidToken(context.Background())
+		if err != nil {
+			t.Errorf("EnsureValidToken() error = %v", err)
+		}
+		done <- token
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	if err := tm.Save(&Token{
+		AccessToken:  "access-from-other-process",
+		RefreshToken: "refresh-1",
+		Expiry:       time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := lock.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case token := <-done:
+		if token != "access-from-other-process" {
+			t.Errorf("token = %q, want access-from-other-process", token)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("EnsureValidToken() did not return after lock release")
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 0 {
+		t.Errorf("refresh requests = %d, want 0", got)
+	}
+}
+
+func TestEnsureValidTokenContextCancelled(t *testing.T) {
+	tm := newTestTokenManager(t, "http://127.0.0.1:0")
+	if err := tm.Save(&Token{
+		AccessToken:  "access-old",
+		RefreshToken: "refresh-0",
+		Expiry:       time.Now().Add(-time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := acquireFileLock(context.Background(), tm.tokenPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if _, err := tm.EnsureValidToken(ctx); err == nil {
+		t.Error("EnsureValidToken() should fail when the context is cancelled while waiting")
+	}
+}
+
+func TestForceRefresh(t *testing.T) {
+	t.Run("refreshes a rejected token before expiry", func(t *testing.T) {
+		var calls int32
+		server := newRefreshServer(t, &calls)
+		tm := newTestTokenManager(t, server.URL)
+
+		if err := tm.Save(&Token{
+			AccessToken:  "access-old",
+			RefreshToken: "refresh-0",
+			Expiry:       time.Now().Add(time.Hour),
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		token, err := tm.ForceRefresh(context.Background(), "access-old")
+		if err != nil {
+			t.Fatalf("ForceRefresh() error = %v", err)
+		}
+		if token != "access-new" {
+			t.Errorf("ForceRefresh() = %q, want access-new", token)
+		}
+		if got := atomic.LoadInt32(&calls); got != 1 {
+			t.Errorf("refresh requests = %d, want 1", got)
+		}
+	})
+
+	t.Run("reuses a token already replaced", func(t *testing.T) {
+		var calls int32
+		server := newRefreshServer(t, &calls)
+		tm := newTestTokenManager(t, server.URL)
+
+		if err := tm.Save(&Token{
+			AccessToken:  "access-replaced",
+			RefreshToken: "refresh-1",
+			Expiry:       time.Now().Add(time.Hour),
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		token, err := tm.ForceRefresh(context.Background(), "access-old")
+		if err != nil {
+			t.Fatalf("ForceRefresh() error = %v", err)
+		}
+		if token != "access-replaced" {
+			t.Errorf("ForceRefresh() = %q, want access-replaced", token)
+		}
+		if got := atomic.LoadInt32(&calls); got != 0 {
+			t.Errorf("refresh requests = %d, want 0", got)
+		}
+	})
+
+	t.Run("refresh rejected by server", func(t *testing.T) {
+		var calls int32
+		server := newRefreshServer(t, &calls)
+		tm := newTestTokenManager(t, server.URL)
+
+		if err := tm.Save(&Token{
+			AccessToken:  "access-old",
+			RefreshToken: "revoked",
+			Expiry:       time.Now().Add(time.Hour),
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := tm.ForceRefresh(context.Background(), "access-old")
+		if !errors.Is(err, whoop.ErrRefreshRejected) {
+			t.Errorf("ForceRefresh() error = %v, want ErrRefreshRejected", err)
+		}
+	})
+}
+
+func TestRefreshFailureClassification(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantReauth bool
+		wantCode   string
+	}{
+		{name: "invalid grant", status: http.StatusBadRequest, body: `{"error":"invalid_grant"}`, wantReauth: true, wantCode: whoop.CodeReauthenticationRequired},
+		{name: "token endpoint unavailable", status: http.StatusServiceUnavailable, wantCode: whoop.CodeUpstream},
+		{name: "rate limited", status: http.StatusTooManyRequests, wantCode: whoop.CodeRateLimited},
+		{name: "invalid client", status: http.StatusUnauthorized, body: `{"error":"invalid_client"}`, wantCode: whoop.CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer tokenServer.Close()
+			apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			}))
+			defer apiServer.Close()
+
+			tm := newTestTokenManager(t, tokenServer.URL)
+			if err := tm.Save(&Token{
+				AccessToken:  "access-old",
+				RefreshToken: "refresh-0",
+				Expiry:       time.Now().Add(time.Hour),
+			}); err != nil {
+				t.Fatal(err)
+			}
+			client := whoop.NewClientWithTokenProvider("", tm)
+			client.SetBaseURL(apiServer.URL)
+
+			_, err := client.GetUserProfile(context.Background())
+			if got := errors.Is(err, whoop.ErrReauthenticationRequired); got != tt.wantReauth {
+				t.Errorf("errors.Is(%v, ErrReauthenticationRequired) = %v, want %v", err, got, tt.wantReauth)
+			}
+			if code := whoop.ErrorCode(err); code != tt.wantCode {
+				t.Errorf("ErrorCode(%v) = %s, want %s", err, code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestRefreshKeepsGrantedScopes(t *testing.T) {
+	var calls int32
+	server := newRefreshServer(t, &calls)
+	tm := newTestTokenManager(t, server.URL)
+
+	expired := &Token{
+		AccessToken:  "access-old",
+		RefreshToken: "refresh-0",
+		Expiry:       time.Now().Add(-time.Hour),
+		Scopes:       []string{"read:sleep", "offline"},
+	}
+	if err := tm.Save(expired); err != nil {
+		t.Fatal(err)
+	}
+
+	// The refresh response carries no scope, so the granted scopes must
+	// survive the refresh.
+	if _, err := tm.EnsureValidToken(context.Background()); err != nil {
+		t.Fatalf("EnsureValidToken() error = %v", err)
+	}
+
+	loaded, err := tm.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Scopes) != 2 || loaded.Scopes[0] != "read:sleep" {
+		t.Errorf("Scopes after refresh = %v, want [read:sleep offline]", loaded.Scopes)
+	}
+	if loaded.IssuedAt.IsZero() {
+		t.Error("IssuedAt should be set after refresh")
+	}
+}
+
+type recordingTokenObserver struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (o *recordingTokenObserver) TokenLookup(_ string, cached bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, fmt.Sprintf("lookup cached=%v", cached))
+}
+
+func (o *recordingTokenObserver) TokenRefreshed(_, trigger string, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, fmt.Sprintf("refresh %s failed=%v", trigger, err != nil))
+}
+
+func TestTokenObserver(t *testing.T) {
+	var calls int32
+	server := newRefreshServer(t, &calls)
+	tm := newTestTokenManager(t, server.URL)
+	observer := &recordingTokenObserver{}
+	tm.SetObserver(observer)
+
+	if err := tm.Save(&Token{
+		AccessToken:  "access-old",
+		RefreshToken: "refresh-0",
+		Expiry:       time.Now().Add(-time.Minute),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := tm.EnsureValidToken(ctx); err != nil {
+			t.Fatalf("EnsureValidToken() error = %v", err)
+		}
+	}
+	// The stored refresh token is now refresh-1, which the server rejects
+	if _, err := tm.ForceRefresh(ctx, "access-new"); err == nil {
+		t.Fatal("ForceRefresh() should fail")
+	}
+
+	want := []string{
+		"lookup cached=false",
+		"refresh expired failed=false",
+		"lookup cached=true",
+		"refresh rejected failed=true",
+	}
+	if strings.Join(observer.events, "\n") != strings.Join(want, "\n") {
+		t.Errorf("events = %q, want %q", observer.events, want)
+	}
+}
+
+func TestRefreshTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	var calls int32
+	server := newRefreshServer(t, &calls)
+	tm := newTestTokenManager(t, server.URL)
+	tm.profile = "work"
+	if err := tm.Save(&Token{
+		AccessToken:  "access-old",
+		RefreshToken: "refresh-0",
+		Expiry:       time.Now().Add(-time.Minute),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, err := tm.EnsureValidToken(ctx); err != nil {
+		t.Fatalf("EnsureValidToken() error = %v", err)
+	}
+	if _, err := tm.ForceRefresh(ctx, "access-new"); err == nil {
+		t.Fatal("ForceRefresh() should fail")
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want one per refresh", len(spans))
+	}
+	for i, want := range []struct {
+		trigger string
+		status  codes.Code
+	}{
+		{RefreshExpired, codes.Unset},
+		{RefreshRejected, codes.Error},
+	} {
+		span := spans[i]
+		attrs := make(map[attribute.Key]string)
+		for _, kv := range span.Attributes {
+			attrs[kv.Key] = kv.Value.Emit()
+		}
+		if span.Name != "refresh access token" || attrs["whoop.profile"] != "work" || attrs["whoop.refresh.trigger"] != want.trigger {
+			t.Errorf("span %d = %s %v", i, span.Name, span.Attributes)
+		}
+		if span.Status.Code != want.status {
+			t.Errorf("span %d status = %v, want %v", i, span.Status, want.status)
+		}
+	}
+}
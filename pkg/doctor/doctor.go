@@ -0,0 +1,432 @@
+// Package doctor diagnoses a WHOOP MCP installation: credential sources,
+// token file permissions and expiry, granted scopes, API reachability and
+// the OAuth callback port.
+package doctor
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xokvictor/whoop-mcp/pkg/auth"
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip"
+)
+
+const (
+	// expiryWarning is how close to expiry a token without a refresh
+	// token is reported as a warning.
+	expiryWarning = 24 * time.Hour
+	// rateLimitWarning is the fraction of remaining requests below which
+	// the rate-limit check warns.
+	rateLimitWarning = 0.1
+)
+
+// Check is the result of one diagnostic.
+type Check struct {
+	Name    string                 `json:"name"`
+	Status  Status                 `json:"status"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Report is the machine-readable result of Run.
+type Report struct {
+	Profile     string    `json:"profile"`
+	GeneratedAt time.Time `json:"generated_at"`
+	// Status is the worst status of any check.
+	Status Status  `json:"status"`
+	Checks []Check `json:"checks"`
+	// Config is the server's effective configuration, if provided.
+	Config interface{} `json:"config,omitempty"`
+}
+
+// Options selects what Run inspects.
+type Options struct {
+	Profile     string
+	Credentials *auth.Credentials
+	// External is the profile's access token from outside the token file,
+	// if any. It takes precedence over TokenManager.
+	External     *auth.ExternalToken
+	TokenManager *auth.TokenManager
+	Client       *whoop.Client
+	// ToolScopes maps each tool to the scopes it requires.
+	ToolScopes map[string][]string
+	// Offline skips the API probes.
+	Offline bool
+	// SkipCallbackPort skips the OAuth callback port check, e.g. while an
+	// authorization flow is running.
+	SkipCallbackPort bool
+	// Config is reported as is; callers must redact secrets.
+	Config interface{}
+	// ConfigErr is the result of validating Config. A non-nil error fails
+	// the config check.
+	ConfigErr error
+}
+
+// Run performs every check and returns the report.
+func Run(ctx context.Context, opts Options) *Report {
+	report := &Report{
+		Profile:     opts.Profile,
+		GeneratedAt: time.Now().UTC(),
+		Config:      opts.Config,
+	}
+
+	token, tokenErr := loadToken(opts)
+
+	report.add(checkConfig(opts))
+	report.add(checkCredentials(opts))
+	report.add(checkTokenFile(opts))
+	report.add(checkTokenExpiry(opts, token, tokenErr))
+	report.add(checkScopes(opts, token))
+	for _, check := range checkEndpoints(ctx, opts) {
+		report.add(check)
+	}
+	if !opts.SkipCallbackPort {
+		report.add(checkCallbackPort())
+	}
+
+	return report
+}
+
+func (r *Report) add(check Check) {
+	r.Checks = append(r.Checks, check)
+	if severity(check.Status) > severity(r.Status) {
+		r.Status = check.Status
+	}
+}
+
+func severity(s Status) int {
+	switch s {
+	case StatusFail:
+		return 3
+	case StatusWarn:
+		return 2
+	case StatusOK, StatusSkip:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// loadToken returns the token the profile uses: the external token when
+// configured, otherwise the token file.
+func loadToken(opts Options) (*auth.Token, error) {
+	if opts.External != nil {
+		access, expiry := opts.External.Current()
+		if access == "" {
+			return nil, nil
+		}
+		return &auth.Token{AccessToken: access, Expiry: expiry}, nil
+	}
+	if opts.TokenManager == nil {
+		return nil, nil
+	}
+	return opts.TokenManager.Load()
+}
+
+func checkCredentials(opts Options) Check {
+	check := Check{Name: "credentials"}
+
+	var sources []auth.CredentialSource
+	hasClient := false
+	if opts.Credentials != nil {
+		sources = opts.Credentials.Sources
+		hasClient = opts.Credentials.ClientID != "" && opts.Credentials.ClientSecret != ""
+	}
+	check.Details = map[string]interface{}{"sources": sources}
+
+	switch {
+	case opts.External != nil && hasClient:
+		check.Status = StatusOK
+		check.Message = fmt.Sprintf("Access token from %s; client credentials configured", opts.External.Source())
+	case opts.External != nil:
+		check.Status = StatusWarn
+		check.Message = fmt.Sprintf("Access token from %s, but no client credentials: whoop_authorize and token refresh are unavailable", opts.External.Source())
+	case hasClient:
+		check.Status = StatusOK
+		check.Message = "Client credentials configured; using the profile token file"
+	default:
+		check.Status = StatusFail
+		check.Message = "No credentials: set WHOOP_CLIENT_ID and WHOOP_CLIENT_SECRET, or an access token source"
+	}
+	return check
+}
+
+func checkTokenFile(opts Options) Check {
+	check := Check{Name: "token_file"}
+	if opts.TokenManager == nil {
+		check.Status = StatusSkip
+		check.Message = "No token manager (client credentials not configured)"
+		return check
+	}
+
+	path := opts.TokenManager.TokenPath()
+	check.Details = map[string]interface{}{"path": path}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		check.Status = StatusSkip
+		if opts.External == nil {
+			check.Status = StatusWarn
+		}
+		check.Message = "No token file; run whoop_authorize or make auth"
+		return check
+	}
+	if err != nil {
+		check.Status = StatusFail
+		check.Message = fmt.Sprintf("Cannot stat token file: %v", err)
+		return check
+	}
+
+	if runtime.GOOS == "windows" {
+		check.Status = StatusOK
+		check.Message = "Token file present (permissions not checked on Windows)"
+		return check
+	}
+
+	var problems []string
+	filePerm := info.Mode().Perm()
+	check.Details["file_mode"] = fmt.Sprintf("%04o", filePerm)
+	if filePerm&0077 != 0 {
+		problems = append(problems, fmt.Sprintf("token file mode is %04o, want 0600", filePerm))
+	}
+
+	if dirInfo, err := os.Stat(filepath.Dir(path)); err == nil {
+		dirPerm := dirInfo.Mode().Perm()
+		check.Details["dir_mode"] = fmt.Sprintf("%04o", dirPerm)
+		if dirPerm&0077 != 0 {
+			problems = append(problems, fmt.Sprintf("token directory mode is %04o, want 0700", dirPerm))
+		}
+	}
+
+	if len(problems) > 0 {
+		check.Status = StatusFail
+		check.Message = strings.Join(problems, "; ")
+		return check
+	}
+	check.Status = StatusOK
+	check.Message = "Token file present with private permissions"
+	return check
+}
+
+func checkTokenExpiry(opts Options, token *auth.Token, loadErr error) Check {
+	check := Check{Name: "token_expiry"}
+	if loadErr != nil {
+		check.Status = StatusFail
+		check.Message = fmt.Sprintf("Cannot read token: %v", loadErr)
+		return check
+	}
+	if token == nil {
+		check.Status = StatusSkip
+		check.Message = "No token"
+		return check
+	}
+
+	expiry := token.Expiry
+	source := "recorded"
+	if expiry.IsZero() {
+		if exp, ok := jwtExpiry(token.AccessToken); ok {
+			expiry, source = exp, "jwt"
+		}
+	}
+	refreshable := token.RefreshToken != "" || (opts.External != nil && opts.External.Refreshable())
+
+	check.Details = map[string]interface{}{"refreshable": refreshable}
+	if expiry.IsZero() {
+		check.Status = StatusOK
+		check.Message = "Token expiry unknown"
+		return check
+	}
+	check.Details["expires_at"] = expiry.Format(time.RFC3339)
+	check.Details["expiry_source"] = source
+
+	remaining := time.Until(expiry)
+	switch {
+	case remaining <= 0 && refreshable:
+		check.Status = StatusWarn
+		check.Message = "Access token expired; it will be refreshed on the next request"
+	case remaining <= 0:
+		check.Status = StatusFail
+		check.Message = "Access token expired and cannot be refreshed; authorize again"
+	case remaining < expiryWarning && !refreshable:
+		check.Status = StatusWarn
+		check.Message = fmt.Sprintf("Access token expires in %s and cannot be refreshed", remaining.Round(time.Minute))
+	default:
+		check.Status = StatusOK
+		check.Message = fmt.Sprintf("Access token valid for %s", remaining.Round(time.Minute))
+	}
+	return check
+}
+
+// jwtExpiry decodes the exp claim of a JWT access token without verifying it.
+func jwtExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.Exp, 0), true
+}
+
+func checkScopes(opts Options, token *auth.Token) Check {
+	check := Check{Name: "scopes"}
+	if token == nil {
+		check.Status = StatusSkip
+		check.Message = "No token"
+		return check
+	}
+	if !token.ScopesKnown() {
+		check.Status = StatusWarn
+		check.Message = "Granted scopes are unknown for this token; the endpoint probes show what is accessible"
+		return check
+	}
+
+	unavailable := make(map[string][]string)
+	var tools []string
+	for tool, scopes := range opts.ToolScopes {
+		if missing := token.MissingScopes(scopes...); len(missing) > 0 {
+			unavailable[tool] = missing
+			tools = append(tools, tool)
+		}
+	}
+	sort.Strings(tools)
+
+	check.Details = map[string]interface{}{"granted": token.Scopes}
+	if len(tools) > 0 {
+		check.Details["unavailable_tools"] = unavailable
+		check.Status = StatusWarn
+		check.Message = fmt.Sprintf("%d tool(s) lack scopes: %s", len(tools), strings.Join(tools, ", "))
+		return check
+	}
+	check.Status = StatusOK
+	check.Message = "Granted scopes cover every tool"
+	return check
+}
+
+// checkEndpoints probes each API area once and summarizes the rate limit.
+func checkEndpoints(ctx context.Context, opts Options) []Check {
+	if opts.Offline {
+		return []Check{{Name: "endpoints", Status: StatusSkip, Message: "Skipped (offline)"}}
+	}
+	if opts.Client == nil || !opts.Client.HasToken() {
+		return []Check{{Name: "endpoints", Status: StatusSkip, Message: "No access token to probe with"}}
+	}
+
+	var checks []Check
+	var lowest *whoop.RateLimit
+	for _, endpoint := range whoop.ProbeEndpoints {
+		check := Check{
+			Name:    "endpoint:" + endpoint.Name,
+			Details: map[string]interface{}{"path": endpoint.Path},
+		}
+
+		result, err := opts.Client.Probe(ctx, endpoint.Path)
+		if err != nil {
+			check.Status = StatusFail
+			check.Message = err.Error()
+			checks = append(checks, check)
+			continue
+		}
+
+		check.Details["status_code"] = result.StatusCode
+		check.Details["latency_ms"] = result.Latency.Milliseconds()
+		check.Status, check.Message = probeStatus(endpoint, result)
+		checks = append(checks, check)
+
+		if rl := result.RateLimit; rl != nil && (lowest == nil || rl.Remaining < lowest.Remaining) {
+			lowest = rl
+		}
+	}
+
+	return append(checks, rateLimitCheck(lowest))
+}
+
+func probeStatus(endpoint whoop.ProbeEndpoint, result *whoop.ProbeResult) (Status, string) {
+	latency := result.Latency.Round(time.Millisecond)
+	switch {
+	case result.StatusCode >= 200 && result.StatusCode < 300:
+		return StatusOK, fmt.Sprintf("OK in %s", latency)
+	case result.StatusCode == http.StatusUnauthorized:
+		return StatusFail, "Access token rejected (401)"
+	case result.StatusCode == http.StatusForbidden:
+		return StatusWarn, fmt.Sprintf("Forbidden (403): scope %s is probably not granted", endpoint.Scope)
+	case result.StatusCode == http.StatusTooManyRequests:
+		return StatusWarn, "Rate limited (429)"
+	default:
+		return StatusFail, fmt.Sprintf("Unexpected status %d", result.StatusCode)
+	}
+}
+
+func rateLimitCheck(rl *whoop.RateLimit) Check {
+	check := Check{Name: "rate_limit"}
+	if rl == nil {
+		check.Status = StatusSkip
+		check.Message = "No rate-limit headers returned"
+		return check
+	}
+
+	check.Details = map[string]interface{}{
+		"limit":         rl.Limit,
+		"remaining":     rl.Remaining,
+		"reset_seconds": int(rl.Reset.Seconds()),
+	}
+	if rl.Limit > 0 && float64(rl.Remaining) < float64(rl.Limit)*rateLimitWarning {
+		check.Status = StatusWarn
+		check.Message = fmt.Sprintf("Only %d of %d requests left in the current window", rl.Remaining, rl.Limit)
+		return check
+	}
+	check.Status = StatusOK
+	check.Message = fmt.Sprintf("%d requests remaining in the current window", rl.Remaining)
+	return check
+}
+
+func checkConfig(opts Options) Check {
+	check := Check{Name: "config"}
+	if opts.ConfigErr != nil {
+		check.Status = StatusFail
+		check.Message = fmt.Sprintf("Invalid configuration: %v", opts.ConfigErr)
+		return check
+	}
+	check.Status = StatusOK
+	check.Message = "Configuration is valid"
+	return check
+}
+
+func checkCallbackPort() Check {
+	check := Check{Name: "oauth_callback_port"}
+	if err := auth.CheckCallbackPort(); err != nil {
+		check.Status = StatusWarn
+		check.Message = fmt.Sprintf("%v; whoop_authorize cannot receive the redirect until it is free", err)
+		return check
+	}
+	check.Status = StatusOK
+	check.Message = "OAuth callback port is free"
+	return check
+}
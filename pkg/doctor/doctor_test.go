@@ -0,0 +1,194 @@
+package doctor
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xokvictor/whoop-mcp/pkg/auth"
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+func newTokenManager(t *testing.T) *auth.TokenManager {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	tm, err := auth.NewProfileTokenManager("id", "secret", auth.DefaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tm
+}
+
+func findCheck(t *testing.T, report *Report, name string) Check {
+	t.Helper()
+	for _, check := range report.Checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	t.Fatalf("check %q not in report", name)
+	return Check{}
+}
+
+func TestRunHealthyTokenFile(t *testing.T) {
+	tm := newTokenManager(t)
+	if err := tm.Save(&auth.Token{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		Expiry:       time.Now().Add(48 * time.Hour),
+		Scopes:       []string{whoop.ScopeSleep, whoop.ScopeOffline},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	report := Run(context.Background(), Options{
+		Profile:      auth.DefaultProfile,
+		Credentials:  &auth.Credentials{ClientID: "id", ClientSecret: "secret"},
+		TokenManager: tm,
+		ToolScopes: map[string][]string{
+			"get_sleeps":     {whoop.ScopeSleep},
+			"get_recoveries": {whoop.ScopeRecovery},
+		},
+		Offline:          true,
+		SkipCallbackPort: true,
+	})
+
+	if check := findCheck(t, report, "credentials"); check.Status != StatusOK {
+		t.Errorf("credentials = %+v, want ok", check)
+	}
+	if check := findCheck(t, report, "token_expiry"); check.Status != StatusOK {
+		t.Errorf("token_expiry = %+v, want ok", check)
+	}
+
+	scopes := findCheck(t, report, "scopes")
+	if scopes.Status != StatusWarn {
+		t.Errorf("scopes = %+v, want warn", scopes)
+	}
+	unavailable, _ := scopes.Details["unavailable_tools"].(map[string][]string)
+	if _, ok := unavailable["get_recoveries"]; !ok || len(unavailable) != 1 {
+		t.Errorf("unavailable_tools = %v, want only get_recoveries", unavailable)
+	}
+
+	if report.Status != StatusWarn {
+		t.Errorf("report status = %v, want warn", report.Status)
+	}
+}
+
+func TestCheckTokenFilePermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not checked on Windows")
+	}
+
+	tm := newTokenManager(t)
+	if err := tm.Save(&auth.Token{AccessToken: "access"}); err != nil {
+		t.Fatal(err)
+	}
+
+	check := checkTokenFile(Options{TokenManager: tm})
+	if check.Status != StatusOK {
+		t.Errorf("checkTokenFile() = %+v, want ok", check)
+	}
+
+	if err := os.Chmod(tm.TokenPath(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	check = checkTokenFile(Options{TokenManager: tm})
+	if check.Status != StatusFail {
+		t.Errorf("checkTokenFile() with 0644 = %+v, want fail", check)
+	}
+}
+
+func TestCheckTokenExpiry(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    *auth.Token
+		expected Status
+	}{
+		{"no token", nil, StatusSkip},
+		{"unknown expiry", &auth.Token{AccessToken: "a"}, StatusOK},
+		{"valid", &auth.Token{AccessToken: "a", Expiry: time.Now().Add(72 * time.Hour)}, StatusOK},
+		{"expiring without refresh", &auth.Token{AccessToken: "a", Expiry: time.Now().Add(time.Hour)}, StatusWarn},
+		{"expired with refresh", &auth.Token{AccessToken: "a", RefreshToken: "r", Expiry: time.Now().Add(-time.Hour)}, StatusWarn},
+		{"expired without refresh", &auth.Token{AccessToken: "a", Expiry: time.Now().Add(-time.Hour)}, StatusFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := checkTokenExpiry(Options{}, tt.token, nil)
+			if check.Status != tt.expected {
+				t.Errorf("checkTokenExpiry() = %+v, want %v", check, tt.expected)
+			}
+		})
+	}
+}
+
+func TestJWTExpiry(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Unix()
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, exp)))
+
+	got, ok := jwtExpiry("header." + payload + ".signature")
+	if !ok || got.Unix() != exp {
+		t.Errorf("jwtExpiry() = %v, %v, want %v", got, ok, time.Unix(exp, 0))
+	}
+
+	if _, ok := jwtExpiry("opaque-token"); ok {
+		t.Error("jwtExpiry() should not decode opaque tokens")
+	}
+}
+
+func TestProbeStatus(t *testing.T) {
+	endpoint := whoop.ProbeEndpoint{Name: "sleep", Scope: whoop.ScopeSleep}
+
+	tests := []struct {
+		code     int
+		expected Status
+	}{
+		{http.StatusOK, StatusOK},
+		{http.StatusUnauthorized, StatusFail},
+		{http.StatusForbidden, StatusWarn},
+		{http.StatusTooManyRequests, StatusWarn},
+		{http.StatusInternalServerError, StatusFail},
+	}
+
+	for _, tt := range tests {
+		status, _ := probeStatus(endpoint, &whoop.ProbeResult{StatusCode: tt.code})
+		if status != tt.expected {
+			t.Errorf("probeStatus(%d) = %v, want %v", tt.code, status, tt.expected)
+		}
+	}
+}
+
+func TestRateLimitCheck(t *testing.T) {
+	if check := rateLimitCheck(nil); check.Status != StatusSkip {
+		t.Errorf("rateLimitCheck(nil) = %v, want skip", check.Status)
+	}
+	if check := rateLimitCheck(&whoop.RateLimit{Limit: 100, Remaining: 50}); check.Status != StatusOK {
+		t.Errorf("rateLimitCheck(50/100) = %v, want ok", check.Status)
+	}
+	if check := rateLimitCheck(&whoop.RateLimit{Limit: 100, Remaining: 5}); check.Status != StatusWarn {
+		t.Errorf("rateLimitCheck(5/100) = %v, want warn", check.Status)
+	}
+}
+
+func TestCheckCredentialsNone(t *testing.T) {
+	if check := checkCredentials(Options{}); check.Status != StatusFail {
+		t.Errorf("checkCredentials() without credentials = %v, want fail", check.Status)
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	if check := checkConfig(Options{}); check.Status != StatusOK {
+		t.Errorf("checkConfig() of a valid configuration = %v, want ok", check.Status)
+	}
+	check := checkConfig(Options{ConfigErr: errors.New("units: unknown units")})
+	if check.Status != StatusFail || !strings.Contains(check.Message, "units: unknown units") {
+		t.Errorf("checkConfig() of an invalid configuration = %+v, want fail", check)
+	}
+}
@@ -3,24 +3,58 @@ package whoop
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	BaseURL  = "https://api.prod.whoop.com/developer"
 	AuthURL  = "https://api.prod.whoop.com/oauth/oauth2/auth"
 	TokenURL = "https://api.prod.whoop.com/oauth/oauth2/token"
+	// RevokeURL is the OAuth 2.0 token revocation endpoint (RFC 7009).
+	RevokeURL = "https://api.prod.whoop.com/oauth/oauth2/revoke"
 
 	defaultTimeout = 30 * time.Second
+
+	// tracerName identifies the package's spans. They go to the global
+	// OpenTelemetry tracer provider, which does nothing unless configured.
+	tracerName = "github.com/xokvictor/whoop-mcp/pkg/whoop"
 )
 
+// ErrReauthenticationRequired is returned when the API rejected the access
+// token and obtaining a new one failed, so the user must authorize again.
+var ErrReauthenticationRequired = errors.New("access token rejected and could not be refreshed; re-authorization required")
+
+// ErrRefreshRejected is returned by a TokenProvider when no new access token
+// can be obtained without the user authorizing again, e.g. because the
+// refresh token expired or was revoked (invalid_grant). Other refresh
+// failures, such as network errors or 5xx answers, may succeed on retry.
+var ErrRefreshRejected = errors.New("refresh rejected")
+
 // TokenProvider is an interface for obtaining valid access tokens.
 type TokenProvider interface {
+	// EnsureValidToken returns a usable access token, refreshing it when the
+	// locally known expiry has passed.
 	EnsureValidToken(ctx context.Context) (string, error)
+
+	// ForceRefresh returns an access token other than rejected, refreshing
+	// it even if its expiry has not passed. It is called when the API
+	// answers 401 for a token the provider still considers valid. Errors
+	// wrap ErrRefreshRejected when only a new authorization can help.
+	ForceRefresh(ctx context.Context, rejected string) (string, error)
 }
 
 // Client is the WHOOP API client
@@ -29,6 +63,13 @@ type Client struct {
 	baseURL       string
 	token         string
 	tokenProvider TokenProvider
+	retry         RetryPolicy
+	logger        *slog.Logger
+	observer      Observer
+
+	// envTokenRejected is set once the API rejects the static token and the
+	// provider supplied a working replacement.
+	envTokenRejected atomic.Bool
 }
 
 // NewClient creates a new WHOOP API client.
@@ -69,17 +110,326 @@ func (c *Client) SetTokenProvider(provider TokenProvider) {
 	c.tokenProvider = provider
 }
 
+// SetBaseURL points the client at another API host, such as a proxy or a
+// test server.
+func (c *Client) SetBaseURL(baseURL string) {
+	c.baseURL = strings.TrimSuffix(baseURL, "/")
+}
+
+// SetLogger sets the logger for request, retry and token refresh events.
+// Records are logged with the request's context, so handlers can add the
+// correlation ID from RequestID. Defaults to slog.Default().
+func (c *Client) SetLogger(logger *slog.Logger) {
+	c.logger = logger
+}
+
+func (c *Client) log() *slog.Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	return slog.Default()
+}
+
+// Observer receives every API request attempt, for example to record
+// metrics. It is called synchronously and must not block.
+type Observer interface {
+	ObserveRequest(ctx context.Context, info RequestInfo)
+}
+
+// RequestInfo describes one attempt of an API request.
+type RequestInfo struct {
+	Method string
+	// Endpoint is the request path without the query string and with record
+	// IDs replaced by {id}, e.g. /v2/cycle/{id}/recovery.
+	Endpoint string
+	// Status is the HTTP status, or zero when no response was received.
+	Status   int
+	Duration time.Duration
+	// Retry is zero for the first attempt and counts retries after it.
+	Retry int
+	Err   error
+	// RateLimit is nil when the response carried no rate-limit headers.
+	RateLimit *RateLimit
+}
+
+// SetObserver sets the observer notified of each request attempt.
+func (c *Client) SetObserver(observer Observer) {
+	c.observer = observer
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying a correlation ID, so that log
+// records of the API requests made for one tool call can be matched up.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the correlation ID carried by ctx, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// SetTimeout sets the time limit for each HTTP request, including reading
+// the response body. Zero means no limit.
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.httpClient.Timeout = timeout
+}
+
+// RetryPolicy controls how the client retries GET requests that WHOOP rate
+// limited (429) or that failed upstream (5xx, network errors).
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt. Zero
+	// disables retrying.
+	MaxRetries int
+	// Backoff is the wait before the first retry, doubled for each further
+	// retry. Rate-limited requests wait for Retry-After instead when WHOOP
+	// sends it. Defaults to DefaultRetryBackoff.
+	Backoff time.Duration
+	// MaxWait caps each wait. A 429 whose Retry-After exceeds it is returned
+	// to the caller instead of retried. Defaults to DefaultRetryMaxWait.
+	MaxWait time.Duration
+}
+
+// Defaults for the zero fields of a RetryPolicy.
+const (
+	DefaultRetryBackoff = 500 * time.Millisecond
+	DefaultRetryMaxWait = 30 * time.Second
+)
+
+// SetRetryPolicy sets how failed requests are retried. Clients don't retry
+// by default.
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	if policy.Backoff <= 0 {
+		policy.Backoff = DefaultRetryBackoff
+	}
+	if policy.MaxWait <= 0 {
+		policy.MaxWait = DefaultRetryMaxWait
+	}
+	c.retry = policy
+}
+
+// retryDelay returns how long to wait before retrying a request that failed
+// with err on the given zero-based attempt, and false if it should not be
+// retried.
+func (p RetryPolicy) retryDelay(attempt int, err error) (time.Duration, bool) {
+	if err == nil || attempt >= p.MaxRetries {
+		return 0, false
+	}
+
+	wait := p.Backoff << attempt
+	if wait <= 0 || wait > p.MaxWait {
+		wait = p.MaxWait
+	}
+
+	var rateLimited *ErrRateLimited
+	switch {
+	case errors.As(err, &rateLimited):
+		if rateLimited.RetryAfter > p.MaxWait {
+			return 0, false
+		}
+		if rateLimited.RetryAfter > 0 {
+			wait = rateLimited.RetryAfter
+		}
+		return wait, true
+	case errors.Is(err, ErrUpstream):
+		return wait, true
+	default:
+		return 0, false
+	}
+}
+
+// doRequest sends an API request in a client span that covers every
+// attempt: retries and the replay after a token refresh.
 func (c *Client) doRequest(ctx context.Context, method, path string) ([]byte, error) {
+	endpoint := endpointTemplate(path)
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", method),
+		attribute.String("url.template", endpoint),
+	}
+	if u, err := url.Parse(c.baseURL); err == nil && u.Host != "" {
+		attrs = append(attrs, attribute.String("server.address", u.Hostname()))
+	}
+	ctx, span := otel.Tracer(tracerName).Start(ctx, method+" "+endpoint,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	defer span.End()
+
+	body, err := c.request(ctx, method, path)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return body, err
+}
+
+func (c *Client) request(ctx context.Context, method, path string) ([]byte, error) {
 	token, err := c.getToken(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("getting token: %w", err)
 	}
 
+	body, err := c.sendWithRetry(ctx, method, path, token)
+
+	// A 401 can arrive before the locally recorded expiry (revoked or
+	// rotated token). Refresh once and replay the request.
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.IsUnauthorized() && c.tokenProvider != nil {
+		trace.SpanFromContext(ctx).AddEvent("access token rejected")
+		newToken, refreshErr := c.tokenProvider.ForceRefresh(ctx, token)
+		if refreshErr != nil {
+			c.log().WarnContext(ctx, "WHOOP rejected the access token and refreshing it failed", "error", refreshErr)
+			if errors.Is(refreshErr, ErrRefreshRejected) {
+				return nil, fmt.Errorf("%w: %w", ErrReauthenticationRequired, refreshErr)
+			}
+			// A transient failure keeps its own category, e.g. ErrUpstream
+			return nil, fmt.Errorf("access token rejected and refreshing it failed: %w", refreshErr)
+		}
+		if newToken == "" || newToken == token {
+			return nil, err
+		}
+
+		body, err = c.sendWithRetry(ctx, method, path, newToken)
+		if err == nil && c.token != "" && token == c.token {
+			c.envTokenRejected.Store(true)
+		}
+	}
+
+	return body, err
+}
+
+// sendWithRetry sends the request, retrying GET requests according to the
+// client's retry policy.
+func (c *Client) sendWithRetry(ctx context.Context, method, path, token string) ([]byte, error) {
+	body, err := c.sendLogged(ctx, method, path, token, 0)
+	if method != http.MethodGet {
+		return body, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		wait, retry := c.retry.retryDelay(attempt, err)
+		if !retry {
+			return body, err
+		}
+		c.log().InfoContext(ctx, "retrying WHOOP API request", "method", method, "endpoint", endpointOf(path), "retry", attempt+1, "wait", wait, "error", err)
+		trace.SpanFromContext(ctx).AddEvent("retry", trace.WithAttributes(
+			attribute.Int("retry", attempt+1),
+			attribute.String("wait", wait.String()),
+			attribute.String("error", err.Error()),
+		))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+
+		body, err = c.sendLogged(ctx, method, path, token, attempt+1)
+	}
+}
+
+// sendLogged sends the request, logs its outcome (rate limiting as a
+// warning, everything else at debug level) and reports it to the observer.
+func (c *Client) sendLogged(ctx context.Context, method, path, token string, retry int) ([]byte, error) {
+	start := time.Now()
+	body, resp, err := c.send(ctx, method, path, token)
+	elapsed := time.Since(start)
+
+	var status int
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	span := trace.SpanFromContext(ctx)
+	if status != 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+	}
+	if retry > 0 {
+		span.SetAttributes(attribute.Int("http.request.resend_count", retry))
+	}
+	if c.observer != nil {
+		info := RequestInfo{
+			Method:   method,
+			Endpoint: endpointTemplate(path),
+			Status:   status,
+			Duration: elapsed,
+			Retry:    retry,
+			Err:      err,
+		}
+		if resp != nil {
+			info.RateLimit = parseRateLimit(resp.Header)
+		}
+		c.observer.ObserveRequest(ctx, info)
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("endpoint", endpointOf(path)),
+		slog.Duration("duration", elapsed),
+	}
+	if retry > 0 {
+		attrs = append(attrs, slog.Int("retry", retry))
+	}
+	if status != 0 {
+		attrs = append(attrs, slog.Int("status", status))
+	}
+	var apiErr *APIError
+	if err != nil && !errors.As(err, &apiErr) {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+
+	if apiErr != nil && apiErr.StatusCode == http.StatusTooManyRequests {
+		attrs = append(attrs, slog.Duration("retry_after", apiErr.RetryAfter))
+		c.log().LogAttrs(ctx, slog.LevelWarn, "WHOOP API rate limit reached", attrs...)
+	} else {
+		c.log().LogAttrs(ctx, slog.LevelDebug, "WHOOP API request", attrs...)
+	}
+	return body, err
+}
+
+// endpointOf strips the query string from a request path.
+func endpointOf(path string) string {
+	endpoint, _, _ := strings.Cut(path, "?")
+	return endpoint
+}
+
+// endpointTemplate is endpointOf with numeric and UUID record IDs replaced
+// by {id}, so that requests for different records share one endpoint.
+func endpointTemplate(path string) string {
+	segments := strings.Split(endpointOf(path), "/")
+	for i, segment := range segments {
+		if isRecordID(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// isRecordID reports whether a path segment is a cycle ID (an integer) or
+// a sleep or workout ID (a UUID).
+func isRecordID(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	if strings.Trim(segment, "0123456789") == "" {
+		return true
+	}
+	return len(segment) == 36 && strings.Count(segment, "-") == 4 &&
+		strings.Trim(segment, "0123456789abcdefABCDEF-") == ""
+}
+
+// send performs a single HTTP request with the given access token. The
+// response, whose body has been read and closed, is nil when none was
+// received.
+func (c *Client) send(ctx context.Context, method, path, token string) ([]byte, *http.Response, error) {
 	url := c.baseURL + path
 
 	req, err := http.NewRequestWithContext(ctx, method, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
+		return nil, nil, fmt.Errorf("creating request: %w", err)
 	}
 
 	if token != "" {
@@ -88,30 +438,31 @@ func (c *Client) doRequest(ctx context.Context, method, path string) ([]byte, er
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("executing request: %w", err)
+		if ctx.Err() != nil {
+			return nil, nil, fmt.Errorf("executing request: %w", err)
+		}
+		return nil, nil, fmt.Errorf("%w: executing request: %w", ErrUpstream, err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("reading response: %w", err)
+		return nil, resp, fmt.Errorf("%w: reading response: %w", ErrUpstream, err)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, &APIError{
-			StatusCode: resp.StatusCode,
-			Message:    string(body),
-		}
+		return nil, resp, newAPIError(resp, body)
 	}
 
-	return body, nil
+	return body, resp, nil
 }
 
 // getToken returns the access token to use for requests.
 // Priority: 1) env var token 2) token provider 3) empty
 func (c *Client) getToken(ctx context.Context) (string, error) {
-	// Environment variable token has highest priority
-	if c.token != "" {
+	// Environment variable token has highest priority, unless the API has
+	// already rejected it in favor of the provider's token
+	if c.token != "" && (c.tokenProvider == nil || !c.envTokenRejected.Load()) {
 		return c.token, nil
 	}
 
@@ -141,28 +492,3 @@ func (c *Client) get(ctx context.Context, path string, result interface{}) error
 
 	return nil
 }
-
-// APIError represents an error response from the WHOOP API.
-type APIError struct {
-	StatusCode int
-	Message    string
-}
-
-func (e *APIError) Error() string {
-	return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Message)
-}
-
-// IsUnauthorized returns true if the error is a 401 Unauthorized response.
-func (e *APIError) IsUnauthorized() bool {
-	return e.StatusCode == http.StatusUnauthorized
-}
-
-// IsNotFound returns true if the error is a 404 Not Found response.
-func (e *APIError) IsNotFound() bool {
-	return e.StatusCode == http.StatusNotFound
-}
-
-// IsRateLimited returns true if the error is a 429 Too Many Requests response.
-func (e *APIError) IsRateLimited() bool {
-	return e.StatusCode == http.StatusTooManyRequests
-}
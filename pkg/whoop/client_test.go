@@ -1,11 +1,24 @@
 package whoop
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestNewClient(t *testing.T) {
@@ -194,3 +207,359 @@ func TestClientGet(t *testing.T) {
 		t.Errorf("expected name 'test', got %q", result.Name)
 	}
 }
+
+// fakeTokenProvider is a TokenProvider that hands out tokens from a list on
+// every forced refresh.
+type fakeTokenProvider struct {
+	current    string
+	refreshed  []string
+	refreshErr error
+	forced     int
+}
+
+func (p *fakeTokenProvider) EnsureValidToken(ctx context.Context) (string, error) {
+	return p.current, nil
+}
+
+func (p *fakeTokenProvider) ForceRefresh(ctx context.Context, rejected string) (string, error) {
+	p.forced++
+	if p.refreshErr != nil {
+		return "", p.refreshErr
+	}
+	if len(p.refreshed) > 0 {
+		p.current, p.refreshed = p.refreshed[0], p.refreshed[1:]
+	}
+	return p.current, nil
+}
+
+func TestClientDoRequestUnauthorizedRecovery(t *testing.T) {
+	newServer := func(validToken string, hits *int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*hits++
+			if r.Header.Get("Authorization") != "Bearer "+validToken {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{}`))
+		}))
+	}
+
+	t.Run("refreshes and replays once", func(t *testing.T) {
+		var hits int
+		server := newServer("fresh", &hits)
+		defer server.Close()
+
+		provider := &fakeTokenProvider{current: "stale", refreshed: []string{"fresh"}}
+		client := NewClientWithTokenProvider("", provider)
+		client.baseURL = server.URL
+
+		if _, err := client.doRequest(context.Background(), http.MethodGet, "/test"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if provider.forced != 1 {
+			t.Errorf("ForceRefresh calls = %d, want 1", provider.forced)
+		}
+		if hits != 2 {
+			t.Errorf("requests = %d, want 2", hits)
+		}
+	})
+
+	t.Run("refresh rejected", func(t *testing.T) {
+		var hits int
+		server := newServer("fresh", &hits)
+		defer server.Close()
+
+		provider := &fakeTokenProvider{current: "stale", refreshErr: fmt.Errorf("%w: refresh failed with status 400: invalid_grant", ErrRefreshRejected)}
+		client := NewClientWithTokenProvider("", provider)
+		client.baseURL = server.URL
+
+		_, err := client.doRequest(context.Background(), http.MethodGet, "/test")
+		if !errors.Is(err, ErrReauthenticationRequired) {
+			t.Fatalf("expected ErrReauthenticationRequired, got %v", err)
+		}
+		if hits != 1 {
+			t.Errorf("requests = %d, want 1", hits)
+		}
+	})
+
+	t.Run("refresh unavailable", func(t *testing.T) {
+		var hits int
+		server := newServer("fresh", &hits)
+		defer server.Close()
+
+		provider := &fakeTokenProvider{current: "stale", refreshErr: fmt.Errorf("%w: refresh failed with status 503", ErrUpstream)}
+		client := NewClientWithTokenProvider("", provider)
+		client.baseURL = server.URL
+
+		_, err := client.doRequest(context.Background(), http.MethodGet, "/test")
+		if errors.Is(err, ErrReauthenticationRequired) || !errors.Is(err, ErrUpstream) {
+			t.Fatalf("expected a retryable upstream error, got %v", err)
+		}
+	})
+
+	t.Run("still unauthorized after refresh", func(t *testing.T) {
+		var hits int
+		server := newServer("never", &hits)
+		defer server.Close()
+
+		provider := &fakeTokenProvider{current: "stale", refreshed: []string{"fresh", "fresher"}}
+		client := NewClientWithTokenProvider("", provider)
+		client.baseURL = server.URL
+
+		_, err := client.doRequest(context.Background(), http.MethodGet, "/test")
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || !apiErr.IsUnauthorized() {
+			t.Fatalf("expected 401 APIError, got %v", err)
+		}
+		if provider.forced != 1 {
+			t.Errorf("ForceRefresh calls = %d, want exactly 1", provider.forced)
+		}
+		if hits != 2 {
+			t.Errorf("requests = %d, want 2", hits)
+		}
+	})
+
+	t.Run("rejected env token falls back to provider", func(t *testing.T) {
+		var hits int
+		server := newServer("stored", &hits)
+		defer server.Close()
+
+		provider := &fakeTokenProvider{current: "stored"}
+		client := NewClientWithTokenProvider("env-token", provider)
+		client.baseURL = server.URL
+
+		if _, err := client.doRequest(context.Background(), http.MethodGet, "/test"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := client.doRequest(context.Background(), http.MethodGet, "/test"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if hits != 3 {
+			t.Errorf("requests = %d, want 3 (second call should skip the rejected env token)", hits)
+		}
+	})
+}
+
+func TestClientRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     *RetryPolicy
+		statuses   []int
+		retryAfter string
+		wantHits   int
+		wantErr    bool
+	}{
+		{name: "no retries by default", statuses: []int{503, 200}, wantHits: 1, wantErr: true},
+		{name: "retries upstream errors", policy: &RetryPolicy{MaxRetries: 2, Backoff: time.Millisecond}, statuses: []int{502, 503, 200}, wantHits: 3},
+		{name: "gives up after max retries", policy: &RetryPolicy{MaxRetries: 1, Backoff: time.Millisecond}, statuses: []int{500, 500, 200}, wantHits: 2, wantErr: true},
+		{name: "retries rate limits", policy: &RetryPolicy{MaxRetries: 1, Backoff: time.Millisecond}, statuses: []int{429, 200}, wantHits: 2},
+		{name: "honors Retry-After beyond max wait", policy: &RetryPolicy{MaxRetries: 3, MaxWait: time.Second}, statuses: []int{429, 200}, retryAfter: "60", wantHits: 1, wantErr: true},
+		{name: "does not retry client errors", policy: &RetryPolicy{MaxRetries: 3, Backoff: time.Millisecond}, statuses: []int{404, 200}, wantHits: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status := tt.statuses[hits]
+				hits++
+				if status == http.StatusTooManyRequests && tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(status)
+				w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			client := NewClientWithToken("token")
+			client.SetBaseURL(server.URL)
+			if tt.policy != nil {
+				client.SetRetryPolicy(*tt.policy)
+			}
+
+			_, err := client.doRequest(context.Background(), http.MethodGet, "/test")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if hits != tt.wantHits {
+				t.Errorf("requests = %d, want %d", hits, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestClientLogsRequests(t *testing.T) {
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if hits == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	client := NewClientWithToken("token")
+	client.SetBaseURL(server.URL)
+	client.SetLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	client.SetRetryPolicy(RetryPolicy{MaxRetries: 1, Backoff: time.Millisecond})
+
+	if _, err := client.doRequest(context.Background(), http.MethodGet, "/test?nextToken=abc"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var records []map[string]interface{}
+	decoder := json.NewDecoder(&buf)
+	for decoder.More() {
+		var record map[string]interface{}
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	want := []struct {
+		level, msg string
+		status     float64
+	}{
+		{"WARN", "WHOOP API rate limit reached", 429},
+		{"INFO", "retrying WHOOP API request", 0},
+		{"DEBUG", "WHOOP API request", 200},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d: %s", len(records), len(want), buf.String())
+	}
+	for i, w := range want {
+		r := records[i]
+		if r["level"] != w.level || r["msg"] != w.msg || r["endpoint"] != "/test" {
+			t.Errorf("record %d = %v", i, r)
+		}
+		if w.status != 0 && r["status"] != w.status {
+			t.Errorf("record %d status = %v, want %v", i, r["status"], w.status)
+		}
+	}
+}
+
+type recordingObserver struct {
+	requests []RequestInfo
+}
+
+func (o *recordingObserver) ObserveRequest(_ context.Context, info RequestInfo) {
+	o.requests = append(o.requests, info)
+}
+
+func TestClientObserver(t *testing.T) {
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if hits == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("X-RateLimit-Limit", "100, 100;window=60")
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	observer := &recordingObserver{}
+	client := NewClientWithToken("token")
+	client.SetBaseURL(server.URL)
+	client.SetObserver(observer)
+	client.SetRetryPolicy(RetryPolicy{MaxRetries: 1, Backoff: time.Millisecond})
+
+	if _, err := client.doRequest(context.Background(), http.MethodGet, "/v2/cycle/93845/sleep?limit=1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(observer.requests) != 2 {
+		t.Fatalf("observed %d requests, want 2", len(observer.requests))
+	}
+	first, second := observer.requests[0], observer.requests[1]
+	if first.Endpoint != "/v2/cycle/{id}/sleep" || first.Status != http.StatusBadGateway || first.Retry != 0 || first.Err == nil {
+		t.Errorf("first attempt = %+v", first)
+	}
+	if second.Status != http.StatusOK || second.Retry != 1 || second.Err != nil {
+		t.Errorf("retry = %+v", second)
+	}
+	if second.RateLimit == nil || second.RateLimit.Remaining != 42 {
+		t.Errorf("retry rate limit = %+v, want 42 remaining", second.RateLimit)
+	}
+}
+
+func TestEndpointTemplate(t *testing.T) {
+	tests := map[string]string{
+		"/v2/cycle?limit=25": "/v2/cycle",
+		"/v2/cycle/93845":    "/v2/cycle/{id}",
+		"/v2/activity/sleep/ecfc6a15-4661-442f-a9a4-f160dd7afae8": "/v2/activity/sleep/{id}",
+		"/v2/cycle/93845/recovery":                                "/v2/cycle/{id}/recovery",
+		"/v2/user/profile/basic":                                  "/v2/user/profile/basic",
+	}
+	for path, want := range tests {
+		if got := endpointTemplate(path); got != want {
+			t.Errorf("endpointTemplate(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestClientTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if hits == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/missing") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithToken("token")
+	client.SetBaseURL(server.URL)
+	client.SetRetryPolicy(RetryPolicy{MaxRetries: 1, Backoff: time.Millisecond})
+
+	if _, err := client.doRequest(context.Background(), http.MethodGet, "/v2/cycle/93845?limit=1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.doRequest(context.Background(), http.MethodGet, "/v2/cycle/missing"); err == nil {
+		t.Fatal("expected an error for 404")
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want one per request", len(spans))
+	}
+	retried := spans[0]
+	if retried.Name != "GET /v2/cycle/{id}" || retried.SpanKind != trace.SpanKindClient {
+		t.Errorf("span = %s (%v)", retried.Name, retried.SpanKind)
+	}
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range retried.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	if attrs["http.response.status_code"].AsInt64() != 200 || attrs["http.request.resend_count"].AsInt64() != 1 || attrs["url.template"].AsString() != "/v2/cycle/{id}" {
+		t.Errorf("attributes = %v", retried.Attributes)
+	}
+	if len(retried.Events) != 1 || retried.Events[0].Name != "retry" {
+		t.Errorf("events = %v, want one retry", retried.Events)
+	}
+	if retried.Status.Code != codes.Unset {
+		t.Errorf("status = %v, want unset for a success", retried.Status)
+	}
+
+	if failed := spans[1]; failed.Status.Code != codes.Error {
+		t.Errorf("failed request status = %v, want error", failed.Status)
+	}
+}
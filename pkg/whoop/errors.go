@@ -0,0 +1,255 @@
+package whoop
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error categories. An *APIError unwraps to the one matching its status, so
+// callers can test with errors.Is(err, whoop.ErrNotFound) regardless of how
+// the error was wrapped.
+var (
+	// ErrUnauthorized means WHOOP rejected the access token (401).
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbiddenScope means the token lacks a scope the endpoint needs (403).
+	ErrForbiddenScope = errors.New("forbidden: missing scope")
+	// ErrNotFound means the resource does not exist or is not visible (404).
+	ErrNotFound = errors.New("not found")
+	// ErrValidation means the request was invalid, either rejected locally
+	// before sending or by WHOOP (400, 422).
+	ErrValidation = errors.New("validation failed")
+	// ErrUpstream means WHOOP failed or could not be reached (5xx, network).
+	ErrUpstream = errors.New("upstream error")
+)
+
+// Machine-readable error codes returned by ErrorCode.
+const (
+	CodeUnauthorized             = "unauthorized"
+	CodeReauthenticationRequired = "reauthentication_required"
+	CodeForbiddenScope           = "forbidden_scope"
+	CodeNotFound                 = "not_found"
+	CodeRateLimited              = "rate_limited"
+	CodeValidation               = "validation_error"
+	CodeUpstream                 = "upstream_error"
+	CodeCancelled                = "cancelled"
+	CodeInternal                 = "internal_error"
+)
+
+// ErrRateLimited is returned when WHOOP answers 429. RetryAfter is how long
+// WHOOP asked callers to wait, or zero if it did not say.
+//
+// Match it with errors.As to read RetryAfter, or errors.Is(err,
+// &ErrRateLimited{}) to test the category.
+type ErrRateLimited struct {
+	RetryAfter time.Duration
+}
+
+func (e *ErrRateLimited) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited: retry after %s", e.RetryAfter)
+	}
+	return "rate limited"
+}
+
+// Is reports whether target is an *ErrRateLimited, whatever its RetryAfter.
+func (e *ErrRateLimited) Is(target error) bool {
+	_, ok := target.(*ErrRateLimited)
+	return ok
+}
+
+// APIError represents an error response from the WHOOP API.
+type APIError struct {
+	StatusCode int
+	// Message is the error message from WHOOP's JSON error body, or the raw
+	// body when it is not JSON.
+	Message string
+	// Code is WHOOP's own error code from the body, if any.
+	Code string
+	// RequestID identifies the request in WHOOP's logs, if returned.
+	RequestID string
+	// Method and Endpoint identify the failing request, e.g. GET /v2/cycle.
+	Method   string
+	Endpoint string
+	// RetryAfter is set for 429 responses from the Retry-After header.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Message)
+
+	var details []string
+	if e.Endpoint != "" {
+		details = append(details, strings.TrimSpace(e.Method+" "+e.Endpoint))
+	}
+	if e.RequestID != "" {
+		details = append(details, "request ID "+e.RequestID)
+	}
+	if len(details) > 0 {
+		msg += " [" + strings.Join(details, ", ") + "]"
+	}
+	return msg
+}
+
+// Unwrap returns the error category for the status code, or nil.
+func (e *APIError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusForbidden:
+		return ErrForbiddenScope
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusTooManyRequests:
+		return &ErrRateLimited{RetryAfter: e.RetryAfter}
+	case e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity:
+		return ErrValidation
+	case e.StatusCode >= 500:
+		return ErrUpstream
+	default:
+		return nil
+	}
+}
+
+// IsUnauthorized returns true if the error is a 401 Unauthorized response.
+func (e *APIError) IsUnauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized
+}
+
+// IsNotFound returns true if the error is a 404 Not Found response.
+func (e *APIError) IsNotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// IsRateLimited returns true if the error is a 429 Too Many Requests response.
+func (e *APIError) IsRateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+// ErrorCode returns a stable, machine-readable code for err, suitable for
+// returning to API consumers alongside the human-readable message.
+func ErrorCode(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrReauthenticationRequired), errors.Is(err, ErrRefreshRejected):
+		return CodeReauthenticationRequired
+	case errors.Is(err, ErrUnauthorized):
+		return CodeUnauthorized
+	case errors.Is(err, ErrForbiddenScope):
+		return CodeForbiddenScope
+	case errors.Is(err, ErrNotFound):
+		return CodeNotFound
+	case errors.Is(err, &ErrRateLimited{}):
+		return CodeRateLimited
+	case errors.Is(err, ErrValidation):
+		return CodeValidation
+	case errors.Is(err, ErrUpstream):
+		return CodeUpstream
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return CodeCancelled
+	default:
+		return CodeInternal
+	}
+}
+
+// newAPIError builds an APIError from a non-2xx response and its body.
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    string(body),
+		RequestID:  requestID(resp.Header),
+	}
+	if resp.Request != nil {
+		apiErr.Method = resp.Request.Method
+		apiErr.Endpoint = resp.Request.URL.Path
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		apiErr.RetryAfter = retryAfter(resp.Header, time.Now())
+	}
+
+	var parsed errorBody
+	if err := json.Unmarshal(body, &parsed); err == nil {
+		if msg := parsed.message(); msg != "" {
+			apiErr.Message = msg
+		}
+		apiErr.Code = parsed.code()
+		if apiErr.RequestID == "" {
+			apiErr.RequestID = firstNonEmpty(parsed.RequestID, parsed.RequestIDCamel)
+		}
+	}
+
+	return apiErr
+}
+
+// errorBody covers the JSON error shapes WHOOP returns: OAuth-style
+// error/error_description and API-style message/code.
+type errorBody struct {
+	Message          string          `json:"message"`
+	Error            string          `json:"error"`
+	ErrorDescription string          `json:"error_description"`
+	Code             json.RawMessage `json:"code"`
+	RequestID        string          `json:"request_id"`
+	RequestIDCamel   string          `json:"requestId"`
+}
+
+func (b *errorBody) message() string {
+	switch {
+	case b.Message != "":
+		return b.Message
+	case b.ErrorDescription != "":
+		return b.ErrorDescription
+	default:
+		return b.Error
+	}
+}
+
+// code returns WHOOP's error code, which may be a string or a number.
+func (b *errorBody) code() string {
+	if len(b.Code) > 0 {
+		var s string
+		if err := json.Unmarshal(b.Code, &s); err == nil {
+			return s
+		}
+		return string(b.Code)
+	}
+	if b.Message != "" || b.ErrorDescription != "" {
+		return b.Error
+	}
+	return ""
+}
+
+func requestID(header http.Header) string {
+	return firstNonEmpty(header.Get("X-Request-Id"), header.Get("X-Amzn-Requestid"))
+}
+
+// retryAfter parses Retry-After (seconds or HTTP date), falling back to
+// X-RateLimit-Reset.
+func retryAfter(header http.Header, now time.Time) time.Duration {
+	if v := strings.TrimSpace(header.Get("Retry-After")); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second
+		}
+		if at, err := http.ParseTime(v); err == nil && at.After(now) {
+			return at.Sub(now)
+		}
+	}
+	if reset := leadingInt(header.Get("X-RateLimit-Reset")); reset > 0 {
+		return time.Duration(reset) * time.Second
+	}
+	return 0
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,159 @@
+package whoop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAPIErrorCategories(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		target     error
+		code       string
+	}{
+		{http.StatusUnauthorized, ErrUnauthorized, CodeUnauthorized},
+		{http.StatusForbidden, ErrForbiddenScope, CodeForbiddenScope},
+		{http.StatusNotFound, ErrNotFound, CodeNotFound},
+		{http.StatusTooManyRequests, &ErrRateLimited{}, CodeRateLimited},
+		{http.StatusBadRequest, ErrValidation, CodeValidation},
+		{http.StatusUnprocessableEntity, ErrValidation, CodeValidation},
+		{http.StatusBadGateway, ErrUpstream, CodeUpstream},
+		{http.StatusTeapot, nil, CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.statusCode), func(t *testing.T) {
+			// Wrap the error the way callers do to make sure the
+			// category survives wrapping.
+			err := fmt.Errorf("fetching: %w", &APIError{StatusCode: tt.statusCode, Message: "test"})
+
+			if tt.target != nil && !errors.Is(err, tt.target) {
+				t.Errorf("errors.Is(%d, %v) = false, want true", tt.statusCode, tt.target)
+			}
+			if got := ErrorCode(err); got != tt.code {
+				t.Errorf("ErrorCode() = %v, want %v", got, tt.code)
+			}
+		})
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code string
+	}{
+		{"nil", nil, ""},
+		{"reauthentication", fmt.Errorf("%w: refresh failed", ErrReauthenticationRequired), CodeReauthenticationRequired},
+		{"refresh rejected", fmt.Errorf("refreshing token: %w: invalid_grant", ErrRefreshRejected), CodeReauthenticationRequired},
+		{"validation", fmt.Errorf("%w: invalid cycle ID", ErrValidation), CodeValidation},
+		{"cancelled", fmt.Errorf("executing request: %w", context.Canceled), CodeCancelled},
+		{"other", errors.New("boom"), CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorCode(tt.err); got != tt.code {
+				t.Errorf("ErrorCode() = %v, want %v", got, tt.code)
+			}
+		})
+	}
+}
+
+func TestClientParsesErrorBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-123")
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"message": "Too many requests", "code": "RATE_LIMIT"}`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithToken("test-token")
+	client.baseURL = server.URL
+
+	_, err := client.GetCycles(context.Background(), CycleParams{Limit: 1})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %v", err)
+	}
+	if apiErr.Message != "Too many requests" {
+		t.Errorf("Message = %q, want parsed message", apiErr.Message)
+	}
+	if apiErr.Code != "RATE_LIMIT" {
+		t.Errorf("Code = %q, want RATE_LIMIT", apiErr.Code)
+	}
+	if apiErr.RequestID != "req-123" {
+		t.Errorf("RequestID = %q, want req-123", apiErr.RequestID)
+	}
+	if apiErr.Method != http.MethodGet || apiErr.Endpoint != "/v2/cycle" {
+		t.Errorf("request = %s %s, want GET /v2/cycle", apiErr.Method, apiErr.Endpoint)
+	}
+
+	var rateLimited *ErrRateLimited
+	if !errors.As(err, &rateLimited) {
+		t.Fatalf("expected *ErrRateLimited, got %v", err)
+	}
+	if rateLimited.RetryAfter != 30*time.Second {
+		t.Errorf("RetryAfter = %v, want 30s", rateLimited.RetryAfter)
+	}
+
+	expected := "API error (status 429): Too many requests [GET /v2/cycle, request ID req-123]"
+	if err.Error() != expected {
+		t.Errorf("Error() = %q, want %q", err.Error(), expected)
+	}
+}
+
+func TestNewAPIErrorOAuthBody(t *testing.T) {
+	resp := &http.Response{StatusCode: http.StatusBadRequest, Header: http.Header{}}
+	apiErr := newAPIError(resp, []byte(`{"error": "invalid_request", "error_description": "bad limit", "requestId": "abc"}`))
+
+	if apiErr.Message != "bad limit" || apiErr.Code != "invalid_request" || apiErr.RequestID != "abc" {
+		t.Errorf("newAPIError() = %+v", apiErr)
+	}
+
+	apiErr = newAPIError(resp, []byte("plain text"))
+	if apiErr.Message != "plain text" {
+		t.Errorf("Message = %q, want raw body", apiErr.Message)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		header   http.Header
+		expected time.Duration
+	}{
+		{"seconds", http.Header{"Retry-After": {"120"}}, 2 * time.Minute},
+		{"http date", http.Header{"Retry-After": {now.Add(time.Minute).Format(http.TimeFormat)}}, time.Minute},
+		{"rate limit reset", http.Header{"X-Ratelimit-Reset": {"15"}}, 15 * time.Second},
+		{"none", http.Header{}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryAfter(tt.header, now); got != tt.expected {
+				t.Errorf("retryAfter() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	client := NewClientWithToken("test-token")
+
+	if _, err := client.GetCycleByID(context.Background(), 0); !errors.Is(err, ErrValidation) {
+		t.Errorf("GetCycleByID(0) error = %v, want ErrValidation", err)
+	}
+	if _, err := client.GetSleepByID(context.Background(), "not-a-uuid"); !errors.Is(err, ErrValidation) {
+		t.Errorf("GetSleepByID() error = %v, want ErrValidation", err)
+	}
+}
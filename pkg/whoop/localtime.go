@@ -0,0 +1,217 @@
+package whoop
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WHOOP timestamps are UTC; each record carries the wearer's UTC offset at
+// the time (timezone_offset, e.g. "-05:00"). Local calendar questions such
+// as "which day was this sleep" must use that offset rather than the
+// server's timezone, since it follows the wearer across DST changes and
+// travel.
+
+// ParseTimezoneOffset parses a WHOOP timezone_offset such as "-05:00",
+// "+0530" or "Z" into a fixed-offset location.
+func ParseTimezoneOffset(offset string) (*time.Location, error) {
+	s := strings.TrimSpace(offset)
+	if s == "Z" || s == "z" {
+		return time.UTC, nil
+	}
+	if len(s) < 2 || (s[0] != '+' && s[0] != '-') {
+		return nil, fmt.Errorf("invalid timezone offset %q", offset)
+	}
+
+	hh, mm, found := strings.Cut(s[1:], ":")
+	if !found && len(hh) == 4 {
+		hh, mm = hh[:2], hh[2:]
+	}
+	hours, err := strconv.Atoi(hh)
+	if err != nil || len(hh) > 2 || hours > 14 {
+		return nil, fmt.Errorf("invalid timezone offset %q", offset)
+	}
+	minutes := 0
+	if mm != "" {
+		minutes, err = strconv.Atoi(mm)
+		if err != nil || len(mm) != 2 || minutes > 59 {
+			return nil, fmt.Errorf("invalid timezone offset %q", offset)
+		}
+	}
+
+	seconds := hours*3600 + minutes*60
+	if s[0] == '-' {
+		seconds = -seconds
+	}
+	return time.FixedZone(fmt.Sprintf("UTC%c%02d:%02d", s[0], hours, minutes), seconds), nil
+}
+
+// offsetLocation is ParseTimezoneOffset falling back to UTC for missing or
+// malformed offsets.
+func offsetLocation(offset string) *time.Location {
+	loc, err := ParseTimezoneOffset(offset)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Location returns the cycle's UTC offset as a location, or UTC if unknown.
+func (c Cycle) Location() *time.Location {
+	return offsetLocation(c.TimezoneOffset)
+}
+
+// LocalStart returns Start in the cycle's own offset.
+func (c Cycle) LocalStart() time.Time {
+	return c.Start.In(c.Location())
+}
+
+// LocalEnd returns End in the cycle's own offset, or nil for the current
+// cycle.
+func (c Cycle) LocalEnd() *time.Time {
+	if c.End == nil {
+		return nil
+	}
+	end := c.End.In(c.Location())
+	return &end
+}
+
+// Location returns the sleep's UTC offset as a location, or UTC if unknown.
+func (s Sleep) Location() *time.Location {
+	return offsetLocation(s.TimezoneOffset)
+}
+
+// LocalStart returns Start in the sleep's own offset.
+func (s Sleep) LocalStart() time.Time {
+	return s.Start.In(s.Location())
+}
+
+// LocalEnd returns End in the sleep's own offset. A night's sleep is
+// usually attributed to the day it ends on.
+func (s Sleep) LocalEnd() time.Time {
+	return s.End.In(s.Location())
+}
+
+// Location returns the workout's UTC offset as a location, or UTC if unknown.
+func (w WorkoutV2) Location() *time.Location {
+	return offsetLocation(w.TimezoneOffset)
+}
+
+// LocalStart returns Start in the workout's own offset.
+func (w WorkoutV2) LocalStart() time.Time {
+	return w.Start.In(w.Location())
+}
+
+// LocalEnd returns End in the workout's own offset.
+func (w WorkoutV2) LocalEnd() time.Time {
+	return w.End.In(w.Location())
+}
+
+// Date is a calendar date with no time of day or location.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// DateOf returns the calendar date of t in t's own location.
+func DateOf(t time.Time) Date {
+	y, m, d := t.Date()
+	return Date{Year: y, Month: m, Day: d}
+}
+
+// String formats the date as YYYY-MM-DD.
+func (d Date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// AddDays returns the date n days later (or earlier, for negative n).
+func (d Date) AddDays(n int) Date {
+	return DateOf(d.midnightUTC().AddDate(0, 0, n))
+}
+
+// Weekday returns the day of the week.
+func (d Date) Weekday() time.Weekday {
+	return d.midnightUTC().Weekday()
+}
+
+// Before reports whether d is earlier than other.
+func (d Date) Before(other Date) bool {
+	return d.midnightUTC().Before(other.midnightUTC())
+}
+
+// midnightUTC anchors date arithmetic in UTC, where every day has 24 hours.
+func (d Date) midnightUTC() time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, time.UTC)
+}
+
+// BucketPeriod is the calendar span grouped into one Bucket.
+type BucketPeriod int
+
+const (
+	BucketDay BucketPeriod = iota
+	BucketWeek
+)
+
+// BucketOptions configures BucketByLocalDate.
+type BucketOptions struct {
+	Period BucketPeriod
+	// WeekStart is the first day of a BucketWeek. The zero value is Sunday,
+	// so set it explicitly; Monday gives ISO 8601 weeks.
+	WeekStart time.Weekday
+}
+
+// Bucket is a group of records sharing a local calendar day or week.
+type Bucket[T any] struct {
+	// Key is "2024-03-11" for days and non-ISO weeks (the first day), or
+	// "2024-W11" for ISO weeks.
+	Key string
+	// Start is the first day of the bucket and End the day after its last.
+	Start Date
+	End   Date
+	// Records keep their input order.
+	Records []T
+}
+
+// BucketByLocalDate groups records by the local calendar date of the time
+// returned by localTime, which should already be in the record's own
+// offset, e.g. Cycle.LocalStart or Sleep.LocalEnd. Buckets are sorted by
+// Start; periods with no records are omitted.
+func BucketByLocalDate[T any](records []T, localTime func(T) time.Time, opts BucketOptions) []Bucket[T] {
+	index := make(map[Date]int)
+	var buckets []Bucket[T]
+
+	for _, record := range records {
+		start, end, key := opts.span(DateOf(localTime(record)))
+		i, ok := index[start]
+		if !ok {
+			i = len(buckets)
+			index[start] = i
+			buckets = append(buckets, Bucket[T]{Key: key, Start: start, End: end})
+		}
+		buckets[i].Records = append(buckets[i].Records, record)
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Start.Before(buckets[j].Start)
+	})
+	return buckets
+}
+
+// span returns the bucket containing day.
+func (o BucketOptions) span(day Date) (start, end Date, key string) {
+	if o.Period != BucketWeek {
+		return day, day.AddDays(1), day.String()
+	}
+
+	offset := (int(day.Weekday()) - int(o.WeekStart) + 7) % 7
+	start = day.AddDays(-offset)
+	end = start.AddDays(7)
+	if o.WeekStart == time.Monday {
+		year, week := start.midnightUTC().ISOWeek()
+		return start, end, fmt.Sprintf("%04d-W%02d", year, week)
+	}
+	return start, end, start.String()
+}
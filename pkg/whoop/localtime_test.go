@@ -0,0 +1,151 @@
+package whoop
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimezoneOffset(t *testing.T) {
+	tests := []struct {
+		offset  string
+		seconds int
+		wantErr bool
+	}{
+		{offset: "-05:00", seconds: -5 * 3600},
+		{offset: "+05:30", seconds: 5*3600 + 30*60},
+		{offset: "+0545", seconds: 5*3600 + 45*60},
+		{offset: "+02", seconds: 2 * 3600},
+		{offset: "Z", seconds: 0},
+		{offset: "", wantErr: true},
+		{offset: "05:00", wantErr: true},
+		{offset: "+25:00", wantErr: true},
+		{offset: "+05:75", wantErr: true},
+		{offset: "+ab:cd", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.offset, func(t *testing.T) {
+			loc, err := ParseTimezoneOffset(tt.offset)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			_, got := time.Date(2024, 1, 1, 0, 0, 0, 0, loc).Zone()
+			if got != tt.seconds {
+				t.Errorf("offset = %ds, want %ds", got, tt.seconds)
+			}
+		})
+	}
+}
+
+func TestRecordLocalTimes(t *testing.T) {
+	// 03:30 UTC is still the previous evening in New York
+	start := time.Date(2024, 3, 11, 3, 30, 0, 0, time.UTC)
+
+	cycle := Cycle{Start: start, TimezoneOffset: "-04:00"}
+	if got := DateOf(cycle.LocalStart()).String(); got != "2024-03-10" {
+		t.Errorf("cycle local date = %s, want 2024-03-10", got)
+	}
+	if cycle.LocalEnd() != nil {
+		t.Error("expected nil LocalEnd for a current cycle")
+	}
+
+	sleep := Sleep{Start: start, End: start.Add(8 * time.Hour), TimezoneOffset: "-04:00"}
+	if got := sleep.LocalEnd().Format("2006-01-02T15:04"); got != "2024-03-11T07:30" {
+		t.Errorf("sleep local end = %s, want 2024-03-11T07:30", got)
+	}
+
+	workout := WorkoutV2{Start: start, TimezoneOffset: "garbage"}
+	if workout.LocalStart().Location() != time.UTC {
+		t.Error("expected UTC fallback for an invalid offset")
+	}
+}
+
+func TestBucketByLocalDate(t *testing.T) {
+	utc := func(day, hour int) time.Time {
+		return time.Date(2024, 3, day, hour, 0, 0, 0, time.UTC)
+	}
+	// Sleeps across the US DST change (2024-03-10) and a trip to Tokyo.
+	// Each wakes at 07:00 local time.
+	sleeps := []Sleep{
+		{ID: "a", End: utc(9, 12), TimezoneOffset: "-05:00"},  // Sat 9 Mar, EST
+		{ID: "b", End: utc(10, 11), TimezoneOffset: "-04:00"}, // Sun 10 Mar, EDT
+		{ID: "c", End: utc(10, 22), TimezoneOffset: "+09:00"}, // Mon 11 Mar, JST
+		{ID: "d", End: utc(11, 20), TimezoneOffset: "+09:00"}, // Tue 12 Mar, 05:00 JST
+		{ID: "e", End: utc(11, 21), TimezoneOffset: "+09:00"}, // Tue 12 Mar, nap
+	}
+
+	t.Run("days", func(t *testing.T) {
+		buckets := BucketByLocalDate(sleeps, Sleep.LocalEnd, BucketOptions{Period: BucketDay})
+		want := map[string][]string{
+			"2024-03-09": {"a"},
+			"2024-03-10": {"b"},
+			"2024-03-11": {"c"},
+			"2024-03-12": {"d", "e"},
+		}
+		if len(buckets) != len(want) {
+			t.Fatalf("got %d buckets, want %d", len(buckets), len(want))
+		}
+		for i, b := range buckets {
+			if i > 0 && !buckets[i-1].Start.Before(b.Start) {
+				t.Errorf("buckets not sorted: %s before %s", buckets[i-1].Key, b.Key)
+			}
+			if b.End != b.Start.AddDays(1) {
+				t.Errorf("bucket %s: end %s, want next day", b.Key, b.End)
+			}
+			assertSleepIDs(t, b.Key, b.Records, want[b.Key])
+		}
+	})
+
+	t.Run("iso weeks", func(t *testing.T) {
+		buckets := BucketByLocalDate(sleeps, Sleep.LocalEnd, BucketOptions{Period: BucketWeek, WeekStart: time.Monday})
+		if len(buckets) != 2 {
+			t.Fatalf("got %d buckets, want 2", len(buckets))
+		}
+		if buckets[0].Key != "2024-W10" || buckets[0].Start.String() != "2024-03-04" {
+			t.Errorf("first bucket = %s starting %s, want 2024-W10 starting 2024-03-04", buckets[0].Key, buckets[0].Start)
+		}
+		assertSleepIDs(t, buckets[0].Key, buckets[0].Records, []string{"a", "b"})
+		if buckets[1].Key != "2024-W11" {
+			t.Errorf("second bucket = %s, want 2024-W11", buckets[1].Key)
+		}
+		assertSleepIDs(t, buckets[1].Key, buckets[1].Records, []string{"c", "d", "e"})
+	})
+
+	t.Run("sunday weeks", func(t *testing.T) {
+		buckets := BucketByLocalDate(sleeps, Sleep.LocalEnd, BucketOptions{Period: BucketWeek, WeekStart: time.Sunday})
+		if len(buckets) != 2 {
+			t.Fatalf("got %d buckets, want 2", len(buckets))
+		}
+		if buckets[1].Key != "2024-03-10" || buckets[1].End.String() != "2024-03-17" {
+			t.Errorf("second bucket = %s to %s, want 2024-03-10 to 2024-03-17", buckets[1].Key, buckets[1].End)
+		}
+		assertSleepIDs(t, buckets[1].Key, buckets[1].Records, []string{"b", "c", "d", "e"})
+	})
+
+	t.Run("iso week spanning new year", func(t *testing.T) {
+		cycles := []Cycle{{Start: time.Date(2024, 12, 31, 12, 0, 0, 0, time.UTC), TimezoneOffset: "+00:00"}}
+		buckets := BucketByLocalDate(cycles, Cycle.LocalStart, BucketOptions{Period: BucketWeek, WeekStart: time.Monday})
+		if len(buckets) != 1 || buckets[0].Key != "2025-W01" {
+			t.Errorf("got %+v, want key 2025-W01", buckets)
+		}
+	})
+}
+
+func assertSleepIDs(t *testing.T, key string, records []Sleep, want []string) {
+	t.Helper()
+	if len(records) != len(want) {
+		t.Errorf("bucket %s: got %d records, want %v", key, len(records), want)
+		return
+	}
+	for i, r := range records {
+		if r.ID != want[i] {
+			t.Errorf("bucket %s: record %d = %s, want %s", key, i, r.ID, want[i])
+		}
+	}
+}
@@ -3,6 +3,7 @@ package whoop
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"net/url"
 	"regexp"
 	"strconv"
@@ -16,8 +17,8 @@ const (
 // UUID validation pattern
 var uuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
 
-// isValidUUID checks if a string is a valid UUID
-func isValidUUID(s string) bool {
+// IsValidUUID checks if a string is a valid UUID
+func IsValidUUID(s string) bool {
 	return uuidRegex.MatchString(s)
 }
 
@@ -41,12 +42,22 @@ func (c *Client) GetBodyMeasurements(ctx context.Context) (*UserBodyMeasurement,
 	return &measurements, nil
 }
 
+// RevokeAccess revokes the access token's grant for this application,
+// so the token stops working and the user must authorize again.
+func (c *Client) RevokeAccess(ctx context.Context) error {
+	_, err := c.doRequest(ctx, http.MethodDelete, "/v2/user/access")
+	return err
+}
+
 // Cycle methods
 
 // GetCycles returns the user's physiological cycles.
 func (c *Client) GetCycles(ctx context.Context, params CycleParams) (*PaginatedCycleResponse, error) {
 	path := "/v2/cycle"
-	query := buildQuery(params.Start, params.End, params.Limit, params.NextToken)
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	query := buildQuery(params.startParam(), params.endParam(), params.Limit, params.NextToken)
 	if query != "" {
 		path += "?" + query
 	}
@@ -59,9 +70,9 @@ func (c *Client) GetCycles(ctx context.Context, params CycleParams) (*PaginatedC
 }
 
 // GetCycleByID returns a specific cycle by its ID.
-func (c *Client) GetCycleByID(ctx context.Context, cycleID int) (*Cycle, error) {
+func (c *Client) GetCycleByID(ctx context.Context, cycleID int64) (*Cycle, error) {
 	if cycleID <= 0 {
-		return nil, fmt.Errorf("invalid cycle ID: %d", cycleID)
+		return nil, fmt.Errorf("%w: invalid cycle ID: %d", ErrValidation, cycleID)
 	}
 
 	path := fmt.Sprintf("/v2/cycle/%d", cycleID)
@@ -77,7 +88,10 @@ func (c *Client) GetCycleByID(ctx context.Context, cycleID int) (*Cycle, error)
 // GetSleeps returns the user's sleep records.
 func (c *Client) GetSleeps(ctx context.Context, params SleepParams) (*PaginatedSleepResponse, error) {
 	path := "/v2/activity/sleep"
-	query := buildQuery(params.Start, params.End, params.Limit, params.NextToken)
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	query := buildQuery(params.startParam(), params.endParam(), params.Limit, params.NextToken)
 	if query != "" {
 		path += "?" + query
 	}
@@ -91,8 +105,8 @@ func (c *Client) GetSleeps(ctx context.Context, params SleepParams) (*PaginatedS
 
 // GetSleepByID returns a specific sleep record by its UUID.
 func (c *Client) GetSleepByID(ctx context.Context, sleepID string) (*Sleep, error) {
-	if !isValidUUID(sleepID) {
-		return nil, fmt.Errorf("invalid sleep ID: must be a valid UUID")
+	if !IsValidUUID(sleepID) {
+		return nil, fmt.Errorf("%w: invalid sleep ID: must be a valid UUID", ErrValidation)
 	}
 
 	path := fmt.Sprintf("/v2/activity/sleep/%s", sleepID)
@@ -104,9 +118,9 @@ func (c *Client) GetSleepByID(ctx context.Context, sleepID string) (*Sleep, erro
 }
 
 // GetSleepForCycle returns the sleep record for a specific cycle.
-func (c *Client) GetSleepForCycle(ctx context.Context, cycleID int) (*Sleep, error) {
+func (c *Client) GetSleepForCycle(ctx context.Context, cycleID int64) (*Sleep, error) {
 	if cycleID <= 0 {
-		return nil, fmt.Errorf("invalid cycle ID: %d", cycleID)
+		return nil, fmt.Errorf("%w: invalid cycle ID: %d", ErrValidation, cycleID)
 	}
 
 	path := fmt.Sprintf("/v2/cycle/%d/sleep", cycleID)
@@ -122,7 +136,10 @@ func (c *Client) GetSleepForCycle(ctx context.Context, cycleID int) (*Sleep, err
 // GetRecoveries returns the user's recovery records.
 func (c *Client) GetRecoveries(ctx context.Context, params RecoveryParams) (*RecoveryCollection, error) {
 	path := "/v2/recovery"
-	query := buildQuery(params.Start, params.End, params.Limit, params.NextToken)
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	query := buildQuery(params.startParam(), params.endParam(), params.Limit, params.NextToken)
 	if query != "" {
 		path += "?" + query
 	}
@@ -135,9 +152,9 @@ func (c *Client) GetRecoveries(ctx context.Context, params RecoveryParams) (*Rec
 }
 
 // GetRecoveryForCycle returns the recovery record for a specific cycle.
-func (c *Client) GetRecoveryForCycle(ctx context.Context, cycleID int) (*Recovery, error) {
+func (c *Client) GetRecoveryForCycle(ctx context.Context, cycleID int64) (*Recovery, error) {
 	if cycleID <= 0 {
-		return nil, fmt.Errorf("invalid cycle ID: %d", cycleID)
+		return nil, fmt.Errorf("%w: invalid cycle ID: %d", ErrValidation, cycleID)
 	}
 
 	path := fmt.Sprintf("/v2/cycle/%d/recovery", cycleID)
@@ -153,7 +170,10 @@ func (c *Client) GetRecoveryForCycle(ctx context.Context, cycleID int) (*Recover
 // GetWorkouts returns the user's workout records.
 func (c *Client) GetWorkouts(ctx context.Context, params WorkoutParams) (*WorkoutCollection, error) {
 	path := "/v2/activity/workout"
-	query := buildQuery(params.Start, params.End, params.Limit, params.NextToken)
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	query := buildQuery(params.startParam(), params.endParam(), params.Limit, params.NextToken)
 	if query != "" {
 		path += "?" + query
 	}
@@ -167,8 +187,8 @@ func (c *Client) GetWorkouts(ctx context.Context, params WorkoutParams) (*Workou
 
 // GetWorkoutByID returns a specific workout by its UUID.
 func (c *Client) GetWorkoutByID(ctx context.Context, workoutID string) (*WorkoutV2, error) {
-	if !isValidUUID(workoutID) {
-		return nil, fmt.Errorf("invalid workout ID: must be a valid UUID")
+	if !IsValidUUID(workoutID) {
+		return nil, fmt.Errorf("%w: invalid workout ID: must be a valid UUID", ErrValidation)
 	}
 
 	path := fmt.Sprintf("/v2/activity/workout/%s", workoutID)
@@ -182,9 +202,9 @@ func (c *Client) GetWorkoutByID(ctx context.Context, workoutID string) (*Workout
 // Activity mapping
 
 // GetActivityMapping returns the V2 UUID for a V1 Activity ID.
-func (c *Client) GetActivityMapping(ctx context.Context, activityV1ID int) (*ActivityIdMappingResponse, error) {
+func (c *Client) GetActivityMapping(ctx context.Context, activityV1ID int64) (*ActivityIdMappingResponse, error) {
 	if activityV1ID <= 0 {
-		return nil, fmt.Errorf("invalid activity V1 ID: %d", activityV1ID)
+		return nil, fmt.Errorf("%w: invalid activity V1 ID: %d", ErrValidation, activityV1ID)
 	}
 
 	path := fmt.Sprintf("/v1/activity-mapping/%d", activityV1ID)
@@ -3,10 +3,12 @@ package whoop
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestIsValidUUID(t *testing.T) {
@@ -28,8 +30,8 @@ func TestIsValidUUID(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := isValidUUID(tt.input); got != tt.expected {
-				t.Errorf("isValidUUID(%q) = %v, want %v", tt.input, got, tt.expected)
+			if got := IsValidUUID(tt.input); got != tt.expected {
+				t.Errorf("IsValidUUID(%q) = %v, want %v", tt.input, got, tt.expected)
 			}
 		})
 	}
@@ -266,6 +268,50 @@ func TestGetCycles(t *testing.T) {
 	}
 }
 
+func TestGetCyclesTimeRange(t *testing.T) {
+	t.Run("formats bounds as UTC", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if got := r.URL.Query().Get("start"); got != "2024-01-01T05:00:00.000Z" {
+				t.Errorf("expected start=2024-01-01T05:00:00.000Z, got %s", got)
+			}
+			if got := r.URL.Query().Get("end"); got != "" {
+				t.Errorf("expected no end, got %s", got)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(PaginatedCycleResponse{})
+		}))
+		defer server.Close()
+
+		client := NewClientWithToken("test-token")
+		client.baseURL = server.URL
+
+		est := time.FixedZone("EST", -5*60*60)
+		params := CycleParams{TimeRange: TimeRange{Start: time.Date(2024, 1, 1, 0, 0, 0, 0, est)}}
+		if _, err := client.GetCycles(context.Background(), params); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("rejects inverted range without calling the API", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Error("unexpected request")
+		}))
+		defer server.Close()
+
+		client := NewClientWithToken("test-token")
+		client.baseURL = server.URL
+
+		params := CycleParams{TimeRange: TimeRange{
+			Start: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			End:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		}}
+		_, err := client.GetCycles(context.Background(), params)
+		if !errors.Is(err, ErrValidation) {
+			t.Errorf("expected ErrValidation, got %v", err)
+		}
+	})
+}
+
 func TestGetActivityMapping(t *testing.T) {
 	t.Run("valid activity ID", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
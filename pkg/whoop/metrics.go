@@ -0,0 +1,199 @@
+package whoop
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Conversion factors.
+const (
+	KilojoulesPerKilocalorie = 4.184
+	MetersPerMile            = 1609.344
+	MetersPerFoot            = 0.3048
+	KilogramsPerPound        = 0.45359237
+)
+
+// Units selects the measurement system for derived values.
+type Units string
+
+const (
+	UnitsMetric   Units = "metric"
+	UnitsImperial Units = "imperial"
+)
+
+// ParseUnits parses "metric" or "imperial". An empty string means metric.
+func ParseUnits(s string) (Units, error) {
+	switch Units(strings.ToLower(strings.TrimSpace(s))) {
+	case "", UnitsMetric:
+		return UnitsMetric, nil
+	case UnitsImperial:
+		return UnitsImperial, nil
+	default:
+		return "", fmt.Errorf("%w: units must be metric or imperial, got %q", ErrValidation, s)
+	}
+}
+
+// millis converts a WHOOP *_milli field to a duration.
+func millis(ms int64) time.Duration {
+	return time.Duration(ms) * time.Millisecond
+}
+
+// percent returns part as a percentage of whole, or 0 when whole is 0.
+func percent(part, whole int64) float64 {
+	if whole <= 0 {
+		return 0
+	}
+	return float64(part) / float64(whole) * 100
+}
+
+// TotalSleepTime is the time spent asleep: light, slow wave and REM sleep.
+func (s SleepStageSummary) TotalSleepTime() time.Duration {
+	return millis(s.asleepMilli())
+}
+
+// TimeInBed is the total time in bed, asleep or not.
+func (s SleepStageSummary) TimeInBed() time.Duration {
+	return millis(s.TotalInBedTimeMilli)
+}
+
+// AwakeTime is the time spent awake while in bed.
+func (s SleepStageSummary) AwakeTime() time.Duration {
+	return millis(s.TotalAwakeTimeMilli)
+}
+
+// LightSleepPercent is light sleep as a percentage of total sleep time.
+func (s SleepStageSummary) LightSleepPercent() float64 {
+	return percent(s.TotalLightSleepTimeMilli, s.asleepMilli())
+}
+
+// SlowWaveSleepPercent is slow wave (deep) sleep as a percentage of total
+// sleep time.
+func (s SleepStageSummary) SlowWaveSleepPercent() float64 {
+	return percent(s.TotalSlowWaveSleepTimeMilli, s.asleepMilli())
+}
+
+// RemSleepPercent is REM sleep as a percentage of total sleep time.
+func (s SleepStageSummary) RemSleepPercent() float64 {
+	return percent(s.TotalRemSleepTimeMilli, s.asleepMilli())
+}
+
+// AwakePercent is awake time as a percentage of time in bed.
+func (s SleepStageSummary) AwakePercent() float64 {
+	return percent(s.TotalAwakeTimeMilli, s.TotalInBedTimeMilli)
+}
+
+func (s SleepStageSummary) asleepMilli() int64 {
+	return s.TotalLightSleepTimeMilli + s.TotalSlowWaveSleepTimeMilli + s.TotalRemSleepTimeMilli
+}
+
+// Need is the total sleep WHOOP recommended for the night.
+func (n SleepNeeded) Need() time.Duration {
+	return millis(n.BaselineMilli + n.NeedFromSleepDebtMilli + n.NeedFromRecentStrainMilli + n.NeedFromRecentNapMilli)
+}
+
+// Kilocalories converts the cycle's energy expenditure to kcal (dietary
+// calories).
+func (c CycleScore) Kilocalories() float64 {
+	return c.Kilojoule / KilojoulesPerKilocalorie
+}
+
+// Kilocalories converts the workout's energy expenditure to kcal (dietary
+// calories).
+func (w WorkoutScore) Kilocalories() float64 {
+	return w.Kilojoule / KilojoulesPerKilocalorie
+}
+
+// Distance returns the distance in kilometers or miles, and false when the
+// workout has no distance.
+func (w WorkoutScore) Distance(units Units) (float64, bool) {
+	if w.DistanceMeter == nil {
+		return 0, false
+	}
+	if units == UnitsImperial {
+		return *w.DistanceMeter / MetersPerMile, true
+	}
+	return *w.DistanceMeter / 1000, true
+}
+
+// AltitudeGain returns the altitude gain in meters or feet, and false when
+// the workout has none.
+func (w WorkoutScore) AltitudeGain(units Units) (float64, bool) {
+	if w.AltitudeGainMeter == nil {
+		return 0, false
+	}
+	if units == UnitsImperial {
+		return *w.AltitudeGainMeter / MetersPerFoot, true
+	}
+	return *w.AltitudeGainMeter, true
+}
+
+// Speed returns the average speed over d in km/h or mph, and false when
+// there is no distance or duration.
+func (w WorkoutScore) Speed(d time.Duration, units Units) (float64, bool) {
+	distance, ok := w.Distance(units)
+	if !ok || d <= 0 || distance <= 0 {
+		return 0, false
+	}
+	return distance / d.Hours(), true
+}
+
+// Pace returns the average time per kilometer or mile over d, and false
+// when there is no distance or duration.
+func (w WorkoutScore) Pace(d time.Duration, units Units) (time.Duration, bool) {
+	distance, ok := w.Distance(units)
+	if !ok || d <= 0 || distance <= 0 {
+		return 0, false
+	}
+	return time.Duration(float64(d) / distance).Round(time.Second), true
+}
+
+// Duration returns the workout's length.
+func (w WorkoutV2) Duration() time.Duration {
+	return w.End.Sub(w.Start)
+}
+
+// Duration returns the sleep's length from start to end.
+func (s Sleep) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Duration returns the cycle's length, and false for the current cycle.
+func (c Cycle) Duration() (time.Duration, bool) {
+	if c.End == nil {
+		return 0, false
+	}
+	return c.End.Sub(c.Start), true
+}
+
+// SkinTemp returns the skin temperature in °C or °F, and false when it was
+// not measured.
+func (r RecoveryScore) SkinTemp(units Units) (float64, bool) {
+	if r.SkinTempCelsius == nil {
+		return 0, false
+	}
+	if units == UnitsImperial {
+		return *r.SkinTempCelsius*9/5 + 32, true
+	}
+	return *r.SkinTempCelsius, true
+}
+
+// BMI returns the body mass index, or 0 when height is unknown.
+func (m UserBodyMeasurement) BMI() float64 {
+	if m.HeightMeter <= 0 {
+		return 0
+	}
+	return m.WeightKilogram / (m.HeightMeter * m.HeightMeter)
+}
+
+// WeightPounds returns the weight in pounds.
+func (m UserBodyMeasurement) WeightPounds() float64 {
+	return m.WeightKilogram / KilogramsPerPound
+}
+
+// HeightFeetInches returns the height as whole feet and remaining inches.
+func (m UserBodyMeasurement) HeightFeetInches() (feet int, inches float64) {
+	totalInches := m.HeightMeter / MetersPerFoot * 12
+	feet = int(totalInches / 12)
+	return feet, totalInches - float64(feet)*12
+}
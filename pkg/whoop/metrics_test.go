@@ -0,0 +1,111 @@
+package whoop
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 0.01
+}
+
+func TestSleepStageSummaryMetrics(t *testing.T) {
+	hour := int64(time.Hour / time.Millisecond)
+	s := SleepStageSummary{
+		TotalInBedTimeMilli:         8 * hour,
+		TotalAwakeTimeMilli:         hour,
+		TotalLightSleepTimeMilli:    4 * hour,
+		TotalSlowWaveSleepTimeMilli: hour + hour/2,
+		TotalRemSleepTimeMilli:      hour + hour/2,
+	}
+
+	if got := s.TotalSleepTime(); got != 7*time.Hour {
+		t.Errorf("TotalSleepTime() = %v, want 7h", got)
+	}
+	if got := s.TimeInBed(); got != 8*time.Hour {
+		t.Errorf("TimeInBed() = %v, want 8h", got)
+	}
+	if got := s.RemSleepPercent(); !approx(got, 21.43) {
+		t.Errorf("RemSleepPercent() = %v, want 21.43", got)
+	}
+	if got := s.LightSleepPercent() + s.SlowWaveSleepPercent() + s.RemSleepPercent(); !approx(got, 100) {
+		t.Errorf("stage percentages sum to %v, want 100", got)
+	}
+	if got := s.AwakePercent(); !approx(got, 12.5) {
+		t.Errorf("AwakePercent() = %v, want 12.5", got)
+	}
+	if got := (SleepStageSummary{}).RemSleepPercent(); got != 0 {
+		t.Errorf("empty RemSleepPercent() = %v, want 0", got)
+	}
+}
+
+func TestWorkoutScoreMetrics(t *testing.T) {
+	distance := 5000.0
+	w := WorkoutScore{Kilojoule: 1046, DistanceMeter: &distance}
+	d := 25 * time.Minute
+
+	if got := w.Kilocalories(); !approx(got, 250) {
+		t.Errorf("Kilocalories() = %v, want 250", got)
+	}
+	if got, _ := w.Distance(UnitsImperial); !approx(got, 3.107) {
+		t.Errorf("Distance(imperial) = %v, want 3.107", got)
+	}
+	if got, _ := w.Speed(d, UnitsMetric); !approx(got, 12) {
+		t.Errorf("Speed(metric) = %v, want 12", got)
+	}
+	if got, _ := w.Pace(d, UnitsMetric); got != 5*time.Minute {
+		t.Errorf("Pace(metric) = %v, want 5m", got)
+	}
+	if got, _ := w.Pace(d, UnitsImperial); got != 8*time.Minute+3*time.Second {
+		t.Errorf("Pace(imperial) = %v, want 8m3s", got)
+	}
+
+	noDistance := WorkoutScore{}
+	if _, ok := noDistance.Pace(d, UnitsMetric); ok {
+		t.Error("expected no pace without distance")
+	}
+	if _, ok := w.Speed(0, UnitsMetric); ok {
+		t.Error("expected no speed without duration")
+	}
+}
+
+func TestBodyMeasurementMetrics(t *testing.T) {
+	m := UserBodyMeasurement{HeightMeter: 1.8, WeightKilogram: 81}
+
+	if got := m.BMI(); !approx(got, 25) {
+		t.Errorf("BMI() = %v, want 25", got)
+	}
+	if got := m.WeightPounds(); !approx(got, 178.57) {
+		t.Errorf("WeightPounds() = %v, want 178.57", got)
+	}
+	feet, inches := m.HeightFeetInches()
+	if feet != 5 || !approx(inches, 10.87) {
+		t.Errorf("HeightFeetInches() = %d ft %.2f in, want 5 ft 10.87 in", feet, inches)
+	}
+	if got := (UserBodyMeasurement{WeightKilogram: 80}).BMI(); got != 0 {
+		t.Errorf("BMI() without height = %v, want 0", got)
+	}
+}
+
+func TestSkinTemp(t *testing.T) {
+	celsius := 33.5
+	r := RecoveryScore{SkinTempCelsius: &celsius}
+	if got, _ := r.SkinTemp(UnitsImperial); !approx(got, 92.3) {
+		t.Errorf("SkinTemp(imperial) = %v, want 92.3", got)
+	}
+	if _, ok := (RecoveryScore{}).SkinTemp(UnitsMetric); ok {
+		t.Error("expected no skin temperature when unmeasured")
+	}
+}
+
+func TestParseUnits(t *testing.T) {
+	for in, want := range map[string]Units{"": UnitsMetric, "metric": UnitsMetric, " Imperial ": UnitsImperial} {
+		if got, err := ParseUnits(in); err != nil || got != want {
+			t.Errorf("ParseUnits(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseUnits("stone"); err == nil {
+		t.Error("expected error for unknown units")
+	}
+}
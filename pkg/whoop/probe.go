@@ -0,0 +1,106 @@
+package whoop
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProbeEndpoint is a cheap request that exercises one API area.
+type ProbeEndpoint struct {
+	Name  string
+	Path  string
+	Scope string
+}
+
+// ProbeEndpoints lists one lightweight request per data scope. Collection
+// endpoints ask for a single record.
+var ProbeEndpoints = []ProbeEndpoint{
+	{Name: "profile", Path: "/v2/user/profile/basic", Scope: ScopeProfile},
+	{Name: "body_measurement", Path: "/v2/user/measurement/body", Scope: ScopeBodyMeasurement},
+	{Name: "cycles", Path: "/v2/cycle?limit=1", Scope: ScopeCycles},
+	{Name: "sleep", Path: "/v2/activity/sleep?limit=1", Scope: ScopeSleep},
+	{Name: "recovery", Path: "/v2/recovery?limit=1", Scope: ScopeRecovery},
+	{Name: "workout", Path: "/v2/activity/workout?limit=1", Scope: ScopeWorkout},
+}
+
+// RateLimit is the request quota WHOOP reported on a response.
+type RateLimit struct {
+	Limit     int           `json:"limit"`
+	Remaining int           `json:"remaining"`
+	Reset     time.Duration `json:"-"`
+}
+
+// ProbeResult describes a single request made by Probe.
+type ProbeResult struct {
+	StatusCode int
+	Latency    time.Duration
+	// RateLimit is nil when the response carried no rate-limit headers.
+	RateLimit *RateLimit
+}
+
+// Probe sends a GET request to path and reports the status, latency and
+// rate-limit headers. Unlike the data methods it does not retry on 401, and
+// non-2xx statuses are returned in the result rather than as an error.
+func (c *Client) Probe(ctx context.Context, path string) (*ProbeResult, error) {
+	token, err := c.getToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting token: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return &ProbeResult{
+		StatusCode: resp.StatusCode,
+		Latency:    time.Since(start),
+		RateLimit:  parseRateLimit(resp.Header),
+	}, nil
+}
+
+// parseRateLimit reads the X-RateLimit-* headers. WHOOP may send several
+// policies in X-RateLimit-Limit (e.g. "100, 100;window=60"); the first
+// number is the one Remaining counts down.
+func parseRateLimit(header http.Header) *RateLimit {
+	remaining, err := strconv.Atoi(strings.TrimSpace(header.Get("X-RateLimit-Remaining")))
+	if err != nil {
+		return nil
+	}
+
+	limit := leadingInt(header.Get("X-RateLimit-Limit"))
+	reset := leadingInt(header.Get("X-RateLimit-Reset"))
+
+	return &RateLimit{
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     time.Duration(reset) * time.Second,
+	}
+}
+
+// leadingInt returns the integer at the start of s, or 0.
+func leadingInt(s string) int {
+	s = strings.TrimSpace(s)
+	end := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if end >= 0 {
+		s = s[:end]
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
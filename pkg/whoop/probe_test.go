@@ -0,0 +1,52 @@
+package whoop
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("missing bearer token, got %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Query().Get("limit") != "1" {
+			t.Errorf("limit = %q, want 1", r.URL.Query().Get("limit"))
+		}
+		w.Header().Set("X-RateLimit-Limit", "100, 100;window=60, 10000;window=86400")
+		w.Header().Set("X-RateLimit-Remaining", "97")
+		w.Header().Set("X-RateLimit-Reset", "42")
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client := NewClientWithToken("test-token")
+	client.baseURL = server.URL
+
+	result, err := client.Probe(context.Background(), "/v2/cycle?limit=1")
+	if err != nil {
+		t.Fatalf("Probe() error = %v", err)
+	}
+	if result.StatusCode != http.StatusForbidden {
+		t.Errorf("StatusCode = %d, want 403", result.StatusCode)
+	}
+	if result.Latency <= 0 {
+		t.Error("Latency should be measured")
+	}
+	if result.RateLimit == nil {
+		t.Fatal("RateLimit should be parsed")
+	}
+	expected := RateLimit{Limit: 100, Remaining: 97, Reset: 42 * time.Second}
+	if *result.RateLimit != expected {
+		t.Errorf("RateLimit = %+v, want %+v", *result.RateLimit, expected)
+	}
+}
+
+func TestParseRateLimitMissing(t *testing.T) {
+	if rl := parseRateLimit(http.Header{}); rl != nil {
+		t.Errorf("parseRateLimit() = %+v, want nil", rl)
+	}
+}
@@ -0,0 +1,35 @@
+package whoop
+
+// OAuth scopes understood by the WHOOP API.
+const (
+	ScopeProfile         = "read:profile"
+	ScopeBodyMeasurement = "read:body_measurement"
+	ScopeCycles          = "read:cycles"
+	ScopeRecovery        = "read:recovery"
+	ScopeSleep           = "read:sleep"
+	ScopeWorkout         = "read:workout"
+
+	// ScopeOffline requests a refresh token alongside the access token.
+	ScopeOffline = "offline"
+)
+
+// ReadScopes lists every data scope, in the order they are usually requested.
+var ReadScopes = []string{
+	ScopeProfile,
+	ScopeBodyMeasurement,
+	ScopeCycles,
+	ScopeRecovery,
+	ScopeSleep,
+	ScopeWorkout,
+}
+
+// ScopeDescriptions describes what each scope grants access to.
+var ScopeDescriptions = map[string]string{
+	ScopeRecovery:        "Read Recovery data (HRV, resting HR, recovery score)",
+	ScopeCycles:          "Read physiological cycles (strain, day boundaries)",
+	ScopeWorkout:         "Read workout data (activities, heart rate zones)",
+	ScopeSleep:           "Read sleep data (stages, efficiency, duration)",
+	ScopeProfile:         "Read user profile (name, email)",
+	ScopeBodyMeasurement: "Read body measurements (height, weight, max HR)",
+	ScopeOffline:         "Issue a refresh token so access can be renewed without signing in again",
+}
@@ -0,0 +1,132 @@
+package whoop
+
+// ScoreState is WHOOP's scoring status for a record. Score is only present
+// when the state is ScoreStateScored.
+type ScoreState string
+
+const (
+	ScoreStateScored       ScoreState = "SCORED"
+	ScoreStatePendingScore ScoreState = "PENDING_SCORE"
+	ScoreStateUnscorable   ScoreState = "UNSCORABLE"
+)
+
+// RecordStatus summarizes whether a record's score can be relied on.
+type RecordStatus string
+
+const (
+	// StatusScored means the record has a final score.
+	StatusScored RecordStatus = "scored"
+	// StatusInProgress means the cycle has not ended; its score covers the
+	// day so far and will still change.
+	StatusInProgress RecordStatus = "in_progress"
+	// StatusPendingScore means WHOOP has not calculated the score yet.
+	StatusPendingScore RecordStatus = "pending_score"
+	// StatusUnscorable means WHOOP could not score the record and never will.
+	StatusUnscorable RecordStatus = "unscorable"
+)
+
+// scoreStatus maps a score state to a status. A SCORED record without a
+// score is treated as pending.
+func scoreStatus(state ScoreState, hasScore bool) RecordStatus {
+	switch {
+	case state == ScoreStateScored && hasScore:
+		return StatusScored
+	case state == ScoreStateUnscorable:
+		return StatusUnscorable
+	default:
+		return StatusPendingScore
+	}
+}
+
+// IsScored reports whether the cycle has a score. The current cycle may be
+// scored while still in progress.
+func (c Cycle) IsScored() bool {
+	return c.ScoreState == ScoreStateScored && c.Score != nil
+}
+
+// IsInProgress reports whether this is the current, unfinished cycle.
+func (c Cycle) IsInProgress() bool {
+	return c.End == nil
+}
+
+// Status returns the cycle's status, reporting the current cycle as in
+// progress whatever its score state.
+func (c Cycle) Status() RecordStatus {
+	if c.IsInProgress() {
+		return StatusInProgress
+	}
+	return scoreStatus(c.ScoreState, c.Score != nil)
+}
+
+// IsScored reports whether the sleep has a score.
+func (s Sleep) IsScored() bool {
+	return s.ScoreState == ScoreStateScored && s.Score != nil
+}
+
+// IsInProgress reports whether the sleep is still waiting for its score.
+func (s Sleep) IsInProgress() bool {
+	return s.Status() == StatusPendingScore
+}
+
+// Status returns the sleep's status.
+func (s Sleep) Status() RecordStatus {
+	return scoreStatus(s.ScoreState, s.Score != nil)
+}
+
+// IsScored reports whether the recovery has a score.
+func (r Recovery) IsScored() bool {
+	return r.ScoreState == ScoreStateScored && r.Score != nil
+}
+
+// IsInProgress reports whether the recovery is still waiting for its score.
+func (r Recovery) IsInProgress() bool {
+	return r.Status() == StatusPendingScore
+}
+
+// Status returns the recovery's status.
+func (r Recovery) Status() RecordStatus {
+	return scoreStatus(r.ScoreState, r.Score != nil)
+}
+
+// IsScored reports whether the workout has a score.
+func (w WorkoutV2) IsScored() bool {
+	return w.ScoreState == ScoreStateScored && w.Score != nil
+}
+
+// IsInProgress reports whether the workout is still waiting for its score.
+func (w WorkoutV2) IsInProgress() bool {
+	return w.Status() == StatusPendingScore
+}
+
+// Status returns the workout's status.
+func (w WorkoutV2) Status() RecordStatus {
+	return scoreStatus(w.ScoreState, w.Score != nil)
+}
+
+// StatusNote explains a non-final status to a reader who might otherwise
+// treat missing values as zero. It is empty for StatusScored.
+func StatusNote(status RecordStatus) string {
+	switch status {
+	case StatusInProgress:
+		return "Current cycle, still in progress: the score covers the day so far and will change."
+	case StatusPendingScore:
+		return "Score not calculated yet: missing values are unknown, not zero."
+	case StatusUnscorable:
+		return "WHOOP could not score this record: missing values are unknown, not zero."
+	default:
+		return ""
+	}
+}
+
+// ScoredOnly returns the records with a final score, skipping in-progress
+// cycles and pending or unscorable records. Aggregations should use it so
+// missing scores are not counted as zero.
+func ScoredOnly[T interface{ Status() RecordStatus }](records []T) []T {
+	scored := make([]T, 0, len(records))
+	for _, r := range records {
+		if r.Status() == StatusScored {
+			scored = append(scored, r)
+		}
+	}
+	return scored
+}
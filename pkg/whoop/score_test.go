@@ -0,0 +1,85 @@
+package whoop
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordStatus(t *testing.T) {
+	end := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		record interface {
+			Status() RecordStatus
+			IsScored() bool
+			IsInProgress() bool
+		}
+		status     RecordStatus
+		scored     bool
+		inProgress bool
+	}{
+		{
+			name:   "scored cycle",
+			record: Cycle{End: &end, ScoreState: ScoreStateScored, Score: &CycleScore{Strain: 10}},
+			status: StatusScored,
+			scored: true,
+		},
+		{
+			name:       "current cycle",
+			record:     Cycle{ScoreState: ScoreStateScored, Score: &CycleScore{Strain: 4}},
+			status:     StatusInProgress,
+			scored:     true,
+			inProgress: true,
+		},
+		{
+			name:       "pending sleep",
+			record:     Sleep{ScoreState: ScoreStatePendingScore},
+			status:     StatusPendingScore,
+			inProgress: true,
+		},
+		{
+			name:   "unscorable recovery",
+			record: Recovery{ScoreState: ScoreStateUnscorable},
+			status: StatusUnscorable,
+		},
+		{
+			name:       "scored state without score",
+			record:     WorkoutV2{ScoreState: ScoreStateScored},
+			status:     StatusPendingScore,
+			inProgress: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.record.Status(); got != tt.status {
+				t.Errorf("Status() = %s, want %s", got, tt.status)
+			}
+			if got := tt.record.IsScored(); got != tt.scored {
+				t.Errorf("IsScored() = %v, want %v", got, tt.scored)
+			}
+			if got := tt.record.IsInProgress(); got != tt.inProgress {
+				t.Errorf("IsInProgress() = %v, want %v", got, tt.inProgress)
+			}
+			if (StatusNote(tt.status) == "") != (tt.status == StatusScored) {
+				t.Errorf("StatusNote(%s) = %q", tt.status, StatusNote(tt.status))
+			}
+		})
+	}
+}
+
+func TestScoredOnly(t *testing.T) {
+	end := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	cycles := []Cycle{
+		{ID: 1, End: &end, ScoreState: ScoreStateScored, Score: &CycleScore{}},
+		{ID: 2, ScoreState: ScoreStateScored, Score: &CycleScore{}},
+		{ID: 3, End: &end, ScoreState: ScoreStateUnscorable},
+		{ID: 4, End: &end, ScoreState: ScoreStateScored, Score: &CycleScore{}},
+	}
+
+	got := ScoredOnly(cycles)
+	if len(got) != 2 || got[0].ID != 1 || got[1].ID != 4 {
+		t.Errorf("ScoredOnly() = %+v, want cycles 1 and 4", got)
+	}
+}
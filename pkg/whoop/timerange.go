@@ -0,0 +1,231 @@
+package whoop
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeRange bounds a collection query. A zero Start or End leaves that side
+// open, which WHOOP treats as "from the beginning" and "until now".
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// ParseTimeRange parses start and end expressions into a TimeRange. Each
+// expression may be:
+//
+//   - an RFC 3339 timestamp, e.g. 2024-01-01T06:00:00Z
+//   - a date or local date-time without offset, e.g. 2024-01-01 or
+//     2024-01-01T06:00, interpreted in loc
+//   - "now", or a duration before now: 36h, 7d, 2w
+//   - a named period: today, yesterday, this_week, last_week, this_month,
+//     last_month (spaces and hyphens are accepted in place of underscores)
+//
+// Dates and named periods cover whole days: as a start they mean the first
+// instant of the period and as an end the first instant after it, so
+// start=2024-01-01 end=2024-01-31 includes all of January 31. When end is
+// empty and start is a named period, the range ends with that period; a
+// date as start leaves the end open, so start=2024-01-01 means "since
+// January 1".
+// Weeks start on Monday. A nil loc means time.Local.
+//
+// The returned range has already been validated.
+func ParseTimeRange(start, end string, loc *time.Location, now time.Time) (TimeRange, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	now = now.In(loc)
+
+	var r TimeRange
+	var startPeriod *period
+	if start = strings.TrimSpace(start); start != "" {
+		t, p, err := parseTimeExpr(start, loc, now)
+		if err != nil {
+			return TimeRange{}, fmt.Errorf("%w: start: %v", ErrValidation, err)
+		}
+		r.Start = t
+		if p != nil {
+			r.Start = p.start
+			if !p.date {
+				startPeriod = p
+			}
+		}
+	}
+
+	if end = strings.TrimSpace(end); end != "" {
+		t, p, err := parseTimeExpr(end, loc, now)
+		if err != nil {
+			return TimeRange{}, fmt.Errorf("%w: end: %v", ErrValidation, err)
+		}
+		r.End = t
+		if p != nil {
+			r.End = p.end
+		}
+	} else if startPeriod != nil {
+		r.End = startPeriod.end
+	}
+
+	if err := r.Validate(); err != nil {
+		return TimeRange{}, err
+	}
+	return r, nil
+}
+
+// CheckTimeExpr returns nil if expr is a start or end accepted by
+// ParseTimeRange, or an error listing the accepted forms.
+func CheckTimeExpr(expr string) error {
+	_, _, err := parseTimeExpr(strings.TrimSpace(expr), time.UTC, time.Now())
+	return err
+}
+
+// Validate checks that Start is before End when both are set.
+func (r TimeRange) Validate() error {
+	if !r.Start.IsZero() && !r.End.IsZero() && !r.Start.Before(r.End) {
+		return fmt.Errorf("%w: start (%s) must be before end (%s)",
+			ErrValidation, r.Start.Format(time.RFC3339), r.End.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// IsZero reports whether neither bound is set.
+func (r TimeRange) IsZero() bool {
+	return r.Start.IsZero() && r.End.IsZero()
+}
+
+// Contains reports whether t falls within the range. Open bounds match
+// everything on that side.
+func (r TimeRange) Contains(t time.Time) bool {
+	if !r.Start.IsZero() && t.Before(r.Start) {
+		return false
+	}
+	if !r.End.IsZero() && !t.Before(r.End) {
+		return false
+	}
+	return true
+}
+
+// startParam and endParam format the bounds for WHOOP's query string.
+func (r TimeRange) startParam() string {
+	return formatQueryTime(r.Start)
+}
+
+func (r TimeRange) endParam() string {
+	return formatQueryTime(r.End)
+}
+
+func formatQueryTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// period is a whole-day span named by a date or relative keyword.
+type period struct {
+	start, end time.Time
+	// date is set for a plain date, which is only widened to its whole
+	// day as an end.
+	date bool
+}
+
+// localLayouts are accepted without an offset and read in the configured
+// location.
+var localLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// parseTimeExpr parses one expression. It returns either an instant or,
+// for dates and named periods, the period it names.
+func parseTimeExpr(expr string, loc *time.Location, now time.Time) (time.Time, *period, error) {
+	for _, layout := range []string{time.RFC3339Nano, time.RFC3339} {
+		if t, err := time.Parse(layout, expr); err == nil {
+			return t, nil, nil
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", expr, loc); err == nil {
+		return time.Time{}, &period{start: t, end: t.AddDate(0, 0, 1), date: true}, nil
+	}
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, expr, loc); err == nil {
+			return t, nil, nil
+		}
+	}
+
+	key := strings.ToLower(expr)
+	key = strings.NewReplacer(" ", "_", "-", "_").Replace(key)
+
+	today := startOfDay(now)
+	switch key {
+	case "now":
+		return now, nil, nil
+	case "today":
+		return time.Time{}, &period{start: today, end: today.AddDate(0, 0, 1)}, nil
+	case "yesterday":
+		return time.Time{}, &period{start: today.AddDate(0, 0, -1), end: today}, nil
+	case "this_week":
+		week := startOfWeek(today)
+		return time.Time{}, &period{start: week, end: week.AddDate(0, 0, 7)}, nil
+	case "last_week":
+		week := startOfWeek(today)
+		return time.Time{}, &period{start: week.AddDate(0, 0, -7), end: week}, nil
+	case "this_month":
+		month := startOfMonth(today)
+		return time.Time{}, &period{start: month, end: month.AddDate(0, 1, 0)}, nil
+	case "last_month":
+		month := startOfMonth(today)
+		return time.Time{}, &period{start: month.AddDate(0, -1, 0), end: month}, nil
+	}
+
+	if ago, ok := parseAgo(key); ok {
+		return ago(now), nil, nil
+	}
+
+	return time.Time{}, nil, fmt.Errorf("unrecognized time %q (use RFC 3339, YYYY-MM-DD, 7d, yesterday, this_week, last_month, ...)", expr)
+}
+
+// parseAgo parses "<n>h", "<n>d" or "<n>w" as a point that long before now.
+// Days and weeks are calendar days, so they keep the wall-clock time across
+// DST changes.
+func parseAgo(key string) (func(time.Time) time.Time, bool) {
+	key = strings.TrimSuffix(key, "_ago")
+	if len(key) < 2 {
+		return nil, false
+	}
+	n, err := strconv.Atoi(key[:len(key)-1])
+	if err != nil || n < 0 {
+		return nil, false
+	}
+
+	switch key[len(key)-1] {
+	case 'h':
+		return func(now time.Time) time.Time { return now.Add(-time.Duration(n) * time.Hour) }, true
+	case 'd':
+		return func(now time.Time) time.Time { return now.AddDate(0, 0, -n) }, true
+	case 'w':
+		return func(now time.Time) time.Time { return now.AddDate(0, 0, -7*n) }, true
+	default:
+		return nil, false
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// startOfWeek returns the Monday on or before day.
+func startOfWeek(day time.Time) time.Time {
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+func startOfMonth(t time.Time) time.Time {
+	y, m, _ := t.Date()
+	return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+}
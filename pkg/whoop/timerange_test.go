@@ -0,0 +1,179 @@
+package whoop
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseTimeRange(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	// Wednesday 2024-03-13 15:30 in Berlin
+	now := time.Date(2024, 3, 13, 15, 30, 0, 0, berlin)
+	at := func(y int, m time.Month, d, h, min int) time.Time {
+		return time.Date(y, m, d, h, min, 0, 0, berlin)
+	}
+
+	tests := []struct {
+		name      string
+		start     string
+		end       string
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:      "rfc3339",
+			start:     "2024-01-01T06:00:00Z",
+			end:       "2024-01-02T06:00:00+01:00",
+			wantStart: time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, 1, 2, 5, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "dates cover whole days in location",
+			start:     "2024-01-01",
+			end:       "2024-01-31",
+			wantStart: at(2024, 1, 1, 0, 0),
+			wantEnd:   at(2024, 2, 1, 0, 0),
+		},
+		{
+			name:      "local date-time",
+			start:     "2024-01-01T06:30",
+			wantStart: at(2024, 1, 1, 6, 30),
+		},
+		{
+			name:      "days ago",
+			start:     "7d",
+			wantStart: at(2024, 3, 6, 15, 30),
+		},
+		{
+			name:      "hours and weeks ago",
+			start:     "2w",
+			end:       "36h",
+			wantStart: at(2024, 2, 28, 15, 30),
+			wantEnd:   at(2024, 3, 12, 3, 30),
+		},
+		{
+			name:      "yesterday alone",
+			start:     "yesterday",
+			wantStart: at(2024, 3, 12, 0, 0),
+			wantEnd:   at(2024, 3, 13, 0, 0),
+		},
+		{
+			name:      "this week starts monday",
+			start:     "this_week",
+			wantStart: at(2024, 3, 11, 0, 0),
+			wantEnd:   at(2024, 3, 18, 0, 0),
+		},
+		{
+			name:      "last week with spaces",
+			start:     "Last Week",
+			wantStart: at(2024, 3, 4, 0, 0),
+			wantEnd:   at(2024, 3, 11, 0, 0),
+		},
+		{
+			name:      "last month to now",
+			start:     "last_month",
+			end:       "now",
+			wantStart: at(2024, 2, 1, 0, 0),
+			wantEnd:   now,
+		},
+		{
+			name:      "period as end",
+			start:     "2024-01-01",
+			end:       "last_month",
+			wantStart: at(2024, 1, 1, 0, 0),
+			wantEnd:   at(2024, 3, 1, 0, 0),
+		},
+		{
+			name:      "across DST change",
+			start:     "2024-03-31",
+			end:       "2024-03-31",
+			wantStart: at(2024, 3, 31, 0, 0),
+			wantEnd:   at(2024, 4, 1, 0, 0),
+		},
+		{
+			name:      "date start without end stays open",
+			start:     "2024-01-01",
+			wantStart: at(2024, 1, 1, 0, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTimeRange(tt.start, tt.end, berlin, now)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Start.Equal(tt.wantStart) {
+				t.Errorf("start = %v, want %v", got.Start, tt.wantStart)
+			}
+			if !got.End.Equal(tt.wantEnd) {
+				t.Errorf("end = %v, want %v", got.End, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestParseTimeRangeErrors(t *testing.T) {
+	now := time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		start string
+		end   string
+	}{
+		{name: "unparseable start", start: "last week-ish"},
+		{name: "unparseable end", end: "soon"},
+		{name: "bad unit", start: "7y"},
+		{name: "start after end", start: "2024-02-01", end: "2024-01-01T00:00:00Z"},
+		{name: "start equals end", start: "2024-01-01T00:00:00Z", end: "2024-01-01T00:00:00Z"},
+		{name: "start in future of end", start: "today", end: "yesterday"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTimeRange(tt.start, tt.end, time.UTC, now)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !errors.Is(err, ErrValidation) {
+				t.Errorf("expected ErrValidation, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckTimeExpr(t *testing.T) {
+	for _, expr := range []string{"2024-01-01", "2024-01-01T06:00:00Z", "7d", "last_week", " Today "} {
+		if err := CheckTimeExpr(expr); err != nil {
+			t.Errorf("CheckTimeExpr(%q) = %v", expr, err)
+		}
+	}
+	for _, expr := range []string{"soon", "7y", "2024-13-01"} {
+		if err := CheckTimeExpr(expr); err == nil {
+			t.Errorf("CheckTimeExpr(%q) should fail", expr)
+		}
+	}
+}
+
+func TestTimeRangeContains(t *testing.T) {
+	r := TimeRange{
+		Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+	if !r.Contains(r.Start) {
+		t.Error("expected start to be included")
+	}
+	if r.Contains(r.End) {
+		t.Error("expected end to be excluded")
+	}
+	if !(TimeRange{}).Contains(r.End) {
+		t.Error("expected open range to contain everything")
+	}
+}
@@ -25,7 +25,7 @@ type Cycle struct {
 	Start          time.Time   `json:"start"`
 	End            *time.Time  `json:"end,omitempty"`
 	TimezoneOffset string      `json:"timezone_offset"`
-	ScoreState     string      `json:"score_state"`
+	ScoreState     ScoreState  `json:"score_state"`
 	Score          *CycleScore `json:"score,omitempty"`
 }
 
@@ -53,7 +53,7 @@ type Sleep struct {
 	End            time.Time   `json:"end"`
 	TimezoneOffset string      `json:"timezone_offset"`
 	Nap            bool        `json:"nap"`
-	ScoreState     string      `json:"score_state"`
+	ScoreState     ScoreState  `json:"score_state"`
 	Score          *SleepScore `json:"score,omitempty"`
 }
 
@@ -96,7 +96,7 @@ type Recovery struct {
 	UserID     int64          `json:"user_id"`
 	CreatedAt  time.Time      `json:"created_at"`
 	UpdatedAt  time.Time      `json:"updated_at"`
-	ScoreState string         `json:"score_state"`
+	ScoreState ScoreState     `json:"score_state"`
 	Score      *RecoveryScore `json:"score,omitempty"`
 }
 
@@ -126,7 +126,7 @@ type WorkoutV2 struct {
 	TimezoneOffset string        `json:"timezone_offset"`
 	SportName      string        `json:"sport_name"`
 	SportID        *int          `json:"sport_id,omitempty"`
-	ScoreState     string        `json:"score_state"`
+	ScoreState     ScoreState    `json:"score_state"`
 	Score          *WorkoutScore `json:"score,omitempty"`
 }
 
@@ -161,31 +161,28 @@ type ActivityIdMappingResponse struct {
 	V2ActivityID string `json:"v2_activity_id"`
 }
 
-// Query parameters
+// Query parameters. The embedded TimeRange is validated before the request
+// is sent.
 type CycleParams struct {
-	Start     string
-	End       string
+	TimeRange
 	Limit     int
 	NextToken string
 }
 
 type SleepParams struct {
-	Start     string
-	End       string
+	TimeRange
 	Limit     int
 	NextToken string
 }
 
 type RecoveryParams struct {
-	Start     string
-	End       string
+	TimeRange
 	Limit     int
 	NextToken string
 }
 
 type WorkoutParams struct {
-	Start     string
-	End       string
+	TimeRange
 	Limit     int
 	NextToken string
 }
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/xokvictor/whoop-mcp/pkg/auth"
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+const profileArgDescription = "WHOOP account profile to use (defaults to the active profile). See whoop_list_profiles."
+
+// profileSession holds the per-account state: token storage and API client.
+type profileSession struct {
+	name         string
+	tokenManager *auth.TokenManager
+	client       *whoop.Client
+	// external is the access token from outside the token file
+	// (WHOOP_ACCESS_TOKEN, a secret file or the credential helper), if any.
+	external *auth.ExternalToken
+	// stopRefresher stops the session's background refresher, if any.
+	stopRefresher context.CancelFunc
+}
+
+// profileRegistry lazily creates one session per profile and tracks which
+// profile is active for tool calls that don't name one explicitly.
+type profileRegistry struct {
+	mu           sync.Mutex
+	clientID     string
+	clientSecret string
+	external     *auth.ExternalToken
+	envProfile   string
+	active       string
+	sessions     map[string]*profileSession
+
+	// refreshCtx and refresher are set when background refresh is enabled.
+	refreshCtx context.Context
+	refresher  *auth.RefresherConfig
+
+	// configureClient, if set, is applied to every session's client.
+	configureClient func(*whoop.Client)
+	// tokenObserver, if set, is attached to every session's token manager.
+	tokenObserver auth.TokenObserver
+}
+
+// newProfileRegistry creates a registry with the given profile active.
+// The external access token, if any, is bound to the startup profile only.
+func newProfileRegistry(clientID, clientSecret string, external *auth.ExternalToken, active string) (*profileRegistry, error) {
+	if active == "" {
+		active = auth.DefaultProfile
+	}
+	if err := auth.ValidateProfileName(active); err != nil {
+		return nil, err
+	}
+
+	return &profileRegistry{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		external:     external,
+		envProfile:   active,
+		active:       active,
+		sessions:     make(map[string]*profileSession),
+	}, nil
+}
+
+// EnableBackgroundRefresh starts a background refresher for every existing
+// and future profile session. The refreshers stop when ctx is cancelled.
+func (r *profileRegistry) EnableBackgroundRefresh(ctx context.Context, config auth.RefresherConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.refreshCtx = ctx
+	r.refresher = &config
+	for _, session := range r.sessions {
+		r.startRefresher(session)
+	}
+}
+
+// ConfigureClients applies configure to the WHOOP client of every existing
+// and future profile session.
+func (r *profileRegistry) ConfigureClients(configure func(*whoop.Client)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.configureClient = configure
+	for _, session := range r.sessions {
+		configure(session.client)
+	}
+}
+
+// ObserveTokens attaches observer to the token manager of every future
+// profile session. It must be called before the first session is created.
+func (r *profileRegistry) ObserveTokens(observer auth.TokenObserver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokenObserver = observer
+}
+
+// startRefresher starts the background refresher for a session when
+// background refresh is enabled. Callers must hold r.mu.
+func (r *profileRegistry) startRefresher(session *profileSession) {
+	if r.refresher == nil || session.tokenManager == nil || session.stopRefresher != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(r.refreshCtx)
+	session.stopRefresher = cancel
+	session.tokenManager.StartRefresher(ctx, *r.refresher)
+}
+
+// Active returns the name of the active profile.
+func (r *profileRegistry) Active() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.active
+}
+
+// Switch makes the named profile active.
+func (r *profileRegistry) Switch(name string) (*profileSession, error) {
+	session, err := r.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.active = session.name
+	r.mu.Unlock()
+
+	return session, nil
+}
+
+// Get returns the session for the named profile, creating it on first use.
+// An empty name selects the active profile.
+func (r *profileRegistry) Get(name string) (*profileSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if name == "" {
+		name = r.active
+	}
+	if err := auth.ValidateProfileName(name); err != nil {
+		return nil, err
+	}
+
+	if session, ok := r.sessions[name]; ok {
+		return session, nil
+	}
+
+	session := &profileSession{name: name}
+
+	if r.clientID != "" && r.clientSecret != "" {
+		tokenManager, err := auth.NewProfileTokenManager(r.clientID, r.clientSecret, name)
+		if err != nil {
+			slog.Warn("failed to initialize token manager", "profile", name, "error", err)
+		} else {
+			if r.tokenObserver != nil {
+				tokenManager.SetObserver(r.tokenObserver)
+			}
+			session.tokenManager = tokenManager
+		}
+	}
+
+	if name == r.envProfile {
+		session.external = r.external
+	}
+
+	switch {
+	case session.external != nil && session.external.Refreshable():
+		// Secret files and the credential helper can supply a new token
+		// themselves, so they replace the token file for this profile.
+		session.client = whoop.NewClientWithTokenProvider("", session.external)
+	case session.external != nil:
+		token, _ := session.external.Current()
+		if session.tokenManager != nil {
+			session.client = whoop.NewClientWithTokenProvider(token, session.tokenManager)
+		} else {
+			session.client = whoop.NewClientWithToken(token)
+		}
+	case session.tokenManager != nil:
+		session.client = whoop.NewClientWithTokenProvider("", session.tokenManager)
+	default:
+		session.client = whoop.NewClientWithToken("")
+	}
+
+	if r.configureClient != nil {
+		r.configureClient(session.client)
+	}
+	r.startRefresher(session)
+
+	r.sessions[name] = session
+	return session, nil
+}
+
+// Forget drops the cached session for a profile, stopping its background
+// refresher and discarding its client. The next Get starts from scratch.
+func (r *profileRegistry) Forget(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[name]
+	if !ok {
+		return
+	}
+	if session.stopRefresher != nil {
+		session.stopRefresher()
+	}
+	delete(r.sessions, name)
+}
+
+// Resolve returns the session selected by the optional "profile" tool
+// argument. The argument is bound like any other, so a value that is not a
+// string is rejected rather than falling back to the active profile.
+func (r *profileRegistry) Resolve(args map[string]interface{}) (*profileSession, error) {
+	var arg profileArg
+	if err := bindArgs(map[string]interface{}{"profile": args["profile"]}, &arg); err != nil {
+		return nil, err
+	}
+	session, err := r.Get(arg.Profile)
+	if err != nil {
+		return nil, newArgumentError("profile: %v", err)
+	}
+	return session, nil
+}
+
+// List returns every known profile: those on disk plus any created this session.
+func (r *profileRegistry) List() ([]string, error) {
+	profiles, err := auth.ListProfiles()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(profiles))
+	for _, name := range profiles {
+		seen[name] = true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, name := range []string{r.active, r.envProfile} {
+		if !seen[name] {
+			seen[name] = true
+			profiles = append(profiles, name)
+		}
+	}
+
+	return profiles, nil
+}
+
+// Logout revokes and removes the profile's stored token and forgets its
+// session. When the profile uses an external access token, that token is
+// revoked too, but its source stays configured until the server is.
+func (r *profileRegistry) Logout(ctx context.Context, session *profileSession) (*auth.LogoutResult, error) {
+	defer r.Forget(session.name)
+
+	result := &auth.LogoutResult{Profile: session.name}
+
+	if session.tokenManager != nil {
+		var err error
+		result, err = session.tokenManager.Logout(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if session.external != nil {
+		source := session.external.Source()
+		token, _ := session.external.Current()
+		r.mu.Lock()
+		configure := r.configureClient
+		r.mu.Unlock()
+
+		// A client of its own revokes exactly this token, whatever the
+		// session's client falls back to, with the configured policy.
+		externalClient := whoop.NewClientWithToken(token)
+		if configure != nil {
+			configure(externalClient)
+		}
+		if err := externalClient.RevokeAccess(ctx); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("revoking access token from %s: %v", source, err))
+		} else {
+			result.AccessRevoked = true
+		}
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%s is still configured for the server; remove it from your MCP configuration.", source))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xokvictor/whoop-mcp/pkg/auth"
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+func TestProfileRegistryGet(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	registry, err := newProfileRegistry("id", "secret", auth.StaticToken(auth.EnvAccessToken, "env-token"), "")
+	if err != nil {
+		t.Fatalf("newProfileRegistry() error = %v", err)
+	}
+
+	if registry.Active() != "default" {
+		t.Errorf("Active() = %v, want default", registry.Active())
+	}
+
+	session, err := registry.Get("")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if session.name != "default" {
+		t.Errorf("session name = %v, want default", session.name)
+	}
+	if session.external == nil {
+		t.Error("startup profile should use the environment token")
+	}
+	if session.tokenManager == nil {
+		t.Error("tokenManager should be set when client credentials are configured")
+	}
+
+	again, _ := registry.Get("default")
+	if again != session {
+		t.Error("Get() should return the same session for the same profile")
+	}
+
+	other, err := registry.Get("alice")
+	if err != nil {
+		t.Fatalf("Get(alice) error = %v", err)
+	}
+	if other.external != nil {
+		t.Error("environment token should not leak into other profiles")
+	}
+	if other.client == session.client {
+		t.Error("each profile should have its own client")
+	}
+
+	if _, err := registry.Get("../evil"); err == nil {
+		t.Error("Get() should reject invalid profile names")
+	}
+}
+
+func TestProfileRegistrySwitch(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	registry, err := newProfileRegistry("", "", nil, "work")
+	if err != nil {
+		t.Fatalf("newProfileRegistry() error = %v", err)
+	}
+	if registry.Active() != "work" {
+		t.Errorf("Active() = %v, want work", registry.Active())
+	}
+
+	if _, err := registry.Switch("home"); err != nil {
+		t.Fatalf("Switch() error = %v", err)
+	}
+	if registry.Active() != "home" {
+		t.Errorf("Active() = %v, want home", registry.Active())
+	}
+
+	session, err := registry.Resolve(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if session.name != "home" {
+		t.Errorf("Resolve() without profile = %v, want active profile home", session.name)
+	}
+
+	session, err = registry.Resolve(map[string]interface{}{"profile": "work"})
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if session.name != "work" {
+		t.Errorf("Resolve() = %v, want work", session.name)
+	}
+
+	var argErr *argumentError
+	if _, err := registry.Resolve(map[string]interface{}{"profile": 7}); !errors.As(err, &argErr) {
+		t.Errorf("Resolve() with a non-string profile error = %v, want an argument error", err)
+	}
+
+	if _, err := registry.Switch("bad name"); err == nil {
+		t.Error("Switch() should reject invalid profile names")
+	}
+	if registry.Active() != "home" {
+		t.Error("failed Switch() should not change the active profile")
+	}
+}
+
+func TestProfileRegistryList(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if err := os.MkdirAll(filepath.Join(home, ".whoop", "profiles", "alice"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	registry, err := newProfileRegistry("", "", nil, "bob")
+	if err != nil {
+		t.Fatalf("newProfileRegistry() error = %v", err)
+	}
+
+	profiles, err := registry.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	expected := []string{"default", "alice", "bob"}
+	if len(profiles) != len(expected) {
+		t.Fatalf("List() = %v, want %v", profiles, expected)
+	}
+	for i := range expected {
+		if profiles[i] != expected[i] {
+			t.Errorf("List()[%d] = %v, want %v", i, profiles[i], expected[i])
+		}
+	}
+}
+
+func TestProfileRegistryBackgroundRefresh(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	registry, err := newProfileRegistry("id", "secret", nil, "")
+	if err != nil {
+		t.Fatalf("newProfileRegistry() error = %v", err)
+	}
+
+	existing, err := registry.Get("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	registry.EnableBackgroundRefresh(ctx, auth.RefresherConfig{})
+
+	created, err := registry.Get("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, session := range []*profileSession{existing, created} {
+		if !session.tokenManager.RefreshStatus().BackgroundActive {
+			t.Errorf("profile %s: background refresher should be running", session.name)
+		}
+	}
+}
+
+func TestProfileRegistryForget(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	registry, err := newProfileRegistry("id", "secret", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	registry.EnableBackgroundRefresh(ctx, auth.RefresherConfig{})
+
+	first, err := registry.Get("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry.Forget("alice")
+
+	second, err := registry.Get("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second || first.client == second.client {
+		t.Error("Get() after Forget() should create a fresh session and client")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for first.tokenManager.RefreshStatus().BackgroundActive {
+		if time.Now().After(deadline) {
+			t.Fatal("forgotten session's background refresher should stop")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestProfileRegistryLogoutExternalToken(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var authorization string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete || r.URL.Path != "/v2/user/access" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		authorization = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	registry, err := newProfileRegistry("", "", auth.StaticToken(auth.EnvAccessToken, "env-token"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry.ConfigureClients(func(client *whoop.Client) { client.SetBaseURL(srv.URL) })
+
+	session, err := registry.Get("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := registry.Logout(context.Background(), session)
+	if err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+	if !result.AccessRevoked {
+		t.Errorf("Logout() = %+v, want the access token revoked", result)
+	}
+	if authorization != "Bearer env-token" {
+		t.Errorf("revoke Authorization = %q, want the external token through the configured client", authorization)
+	}
+}
@@ -0,0 +1,402 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+// maxCollectPages caps how many pages of each record kind a prompt or a day
+// resource fetches.
+const maxCollectPages = 4
+
+// illnessRecentDays is how many of the latest days illness_check compares
+// against the baseline before them.
+const illnessRecentDays = 3
+
+const promptProfileDescription = "Profile to read data from (default: the active profile)."
+
+// promptBuilder fetches the data for a prompt and returns the instructions
+// to send along with it.
+type promptBuilder func(ctx context.Context, session *profileSession, present presenter, args map[string]string) (instructions string, data interface{}, err error)
+
+// registerPrompts adds coaching prompts. Each one fetches the relevant
+// WHOOP data up front and embeds it in the prompt, so answers are grounded
+// in the user's records rather than in what the assistant chooses to look up.
+// Records whose tools are disabled are left out.
+func registerPrompts(s *server.MCPServer, profiles *profileRegistry, opts toolOptions) {
+	allKinds := opts.enabledKinds(latestKinds...)
+	sleepKinds := opts.enabledKinds("sleep", "recovery")
+
+	addPrompt(s,
+		mcp.NewPrompt("morning_briefing",
+			mcp.WithPromptDescription("Summarize last night's sleep and this morning's recovery against the past week, and suggest how hard to go today."),
+			mcp.WithArgument("profile", mcp.ArgumentDescription(promptProfileDescription)),
+			mcp.WithArgument("units", mcp.ArgumentDescription(unitsDescription)),
+		),
+		allKinds,
+		coachingPrompt(profiles, opts, func(ctx context.Context, session *profileSession, present presenter, args map[string]string) (string, interface{}, error) {
+			data, err := fetchPeriod(ctx, session, present, lastDays(8, time.Now()), opts.location, allKinds...)
+			if err != nil {
+				return "", nil, err
+			}
+			return `Give me a short morning briefing from my WHOOP data below.
+
+1. Last night's sleep: total sleep against sleep needed, performance and efficiency, and anything unusual in the stages.
+2. This morning's recovery: score, HRV and resting heart rate, compared with my averages over the past week.
+3. Yesterday's strain and workouts.
+4. A recommendation for today's strain, in one or two sentences.
+
+The newest records come first. Keep it under 200 words.`, data, nil
+		}),
+	)
+
+	addPrompt(s,
+		mcp.NewPrompt("weekly_review",
+			mcp.WithPromptDescription("Review a week of recovery, sleep, strain and workouts, with trends and takeaways."),
+			mcp.WithArgument("period", mcp.ArgumentDescription("Period to review, e.g. 7d, this_week or last_week (default: 7d).")),
+			mcp.WithArgument("profile", mcp.ArgumentDescription(promptProfileDescription)),
+			mcp.WithArgument("units", mcp.ArgumentDescription(unitsDescription)),
+		),
+		allKinds,
+		coachingPrompt(profiles, opts, func(ctx context.Context, session *profileSession, present presenter, args map[string]string) (string, interface{}, error) {
+			period := args["period"]
+			if period == "" {
+				period = "7d"
+			}
+			window, err := whoop.ParseTimeRange(period, "", opts.location, time.Now())
+			if err != nil {
+				return "", nil, err
+			}
+			data, err := fetchPeriod(ctx, session, present, window, opts.location, allKinds...)
+			if err != nil {
+				return "", nil, err
+			}
+			return `Review my week using the WHOOP data below.
+
+1. Recovery: average and range, the best and worst days, and the HRV and resting heart rate trend.
+2. Sleep: average total sleep against need, consistency, and nights that fell short.
+3. Strain and workouts: daily strain, training load and how it matched my recovery.
+4. Two or three concrete takeaways for next week.
+
+Use tables where they help. Only count scored records in averages.`, data, nil
+		}),
+	)
+
+	addPrompt(s,
+		mcp.NewPrompt("training_plan_adjustment",
+			mcp.WithPromptDescription("Suggest adjustments to the coming week of training toward a goal, based on the last four weeks of strain, recovery and sleep."),
+			mcp.WithArgument("goal", mcp.RequiredArgument(), mcp.ArgumentDescription("Training goal, e.g. \"run a sub-50 10k in 8 weeks\".")),
+			mcp.WithArgument("profile", mcp.ArgumentDescription(promptProfileDescription)),
+			mcp.WithArgument("units", mcp.ArgumentDescription(unitsDescription)),
+		),
+		allKinds,
+		coachingPrompt(profiles, opts, func(ctx context.Context, session *profileSession, present presenter, args map[string]string) (string, interface{}, error) {
+			goal := strings.TrimSpace(args["goal"])
+			if goal == "" {
+				return "", nil, newArgumentError("goal is required")
+			}
+			data, err := fetchPeriod(ctx, session, present, lastDays(28, time.Now()), opts.location, allKinds...)
+			if err != nil {
+				return "", nil, err
+			}
+			return fmt.Sprintf(`My training goal: %s
+
+Using the last four weeks of WHOOP data below, suggest how to adjust my training for the coming week.
+
+1. Summarize my recent training load: workouts per week, sports, strain and heart rate zones.
+2. Assess how well I am recovering from it, from recovery scores, HRV, resting heart rate and sleep.
+3. Propose a day-by-day plan for the next 7 days with a target strain for each day, and explain what changed and why.
+4. Name the signs in my data that should make me back off.`, goal), data, nil
+		}),
+	)
+
+	addPrompt(s,
+		mcp.NewPrompt("sleep_coaching",
+			mcp.WithPromptDescription("Analyze recent sleep and suggest changes to duration, timing and consistency."),
+			mcp.WithArgument("nights", mcp.ArgumentDescription("Number of nights to analyze, 1 to 60 (default: 14).")),
+			mcp.WithArgument("profile", mcp.ArgumentDescription(promptProfileDescription)),
+			mcp.WithArgument("units", mcp.ArgumentDescription(unitsDescription)),
+		),
+		sleepKinds,
+		coachingPrompt(profiles, opts, func(ctx context.Context, session *profileSession, present presenter, args map[string]string) (string, interface{}, error) {
+			nights := 14
+			if arg := strings.TrimSpace(args["nights"]); arg != "" {
+				n, err := strconv.Atoi(arg)
+				if err != nil || n < 1 || n > 60 {
+					return "", nil, newArgumentError("nights must be an integer from 1 to 60, got %q", arg)
+				}
+				nights = n
+			}
+			data, err := fetchPeriod(ctx, session, present, lastDays(nights, time.Now()), opts.location, sleepKinds...)
+			if err != nil {
+				return "", nil, err
+			}
+			return fmt.Sprintf(`Act as my sleep coach, using my last %d nights of WHOOP sleep and recovery data below.
+
+1. How much I sleep against what I need, and how much sleep debt I carry.
+2. Consistency of bedtimes and wake times, using each record's local time.
+3. Sleep quality: efficiency, disturbances, and slow wave and REM sleep.
+4. How my sleep relates to next-day recovery.
+5. Up to three specific, practical changes, most impactful first.
+
+Naps are marked with nap: true; treat them separately from main sleeps.`, nights), data, nil
+		}),
+	)
+
+	addPrompt(s,
+		mcp.NewPrompt("illness_check",
+			mcp.WithPromptDescription("Check the last few days for early signs of illness or overreaching: HRV, resting heart rate, respiratory rate, skin temperature and SpO2 against the user's baseline."),
+			mcp.WithArgument("profile", mcp.ArgumentDescription(promptProfileDescription)),
+			mcp.WithArgument("units", mcp.ArgumentDescription(unitsDescription)),
+		),
+		sleepKinds,
+		coachingPrompt(profiles, opts, func(ctx context.Context, session *profileSession, present presenter, args map[string]string) (string, interface{}, error) {
+			now := time.Now()
+			period, err := fetchPeriod(ctx, session, present, lastDays(28, now), opts.location, sleepKinds...)
+			if err != nil {
+				return "", nil, err
+			}
+			data := struct {
+				*periodView
+				Comparison []metricComparison `json:"baseline_comparison"`
+			}{period, compareToBaseline(period, now.AddDate(0, 0, -illnessRecentDays))}
+			return fmt.Sprintf(`Check my WHOOP data below for early signs of illness or overreaching.
+
+baseline_comparison compares my last %d days with the weeks before. Look for the usual pattern: HRV down, resting heart rate up, respiratory rate up, skin temperature up or SpO2 down, especially several at once.
+
+1. State whether my recent metrics are within my normal range, and which are not.
+2. Rate the overall signal as none, mild or strong, and explain why.
+3. Suggest how to adjust training and rest for the next few days.
+
+This is not a diagnosis. If the signal is strong or I mention symptoms, recommend seeing a doctor.`, illnessRecentDays), data, nil
+		}),
+	)
+}
+
+// coachingPrompt adapts a promptBuilder into a prompt handler: it resolves
+// the profile and units arguments and embeds the data as JSON after the
+// instructions.
+func coachingPrompt(profiles *profileRegistry, opts toolOptions, build promptBuilder) server.PromptHandlerFunc {
+	return func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		args := request.Params.Arguments
+
+		session, err := profiles.Get(args["profile"])
+		if err != nil {
+			return nil, resourceError(newArgumentError("profile: %v", err))
+		}
+		present, err := opts.presenter(args["units"])
+		if err != nil {
+			return nil, resourceError(err)
+		}
+
+		instructions, data, err := build(ctx, session, present, args)
+		if err != nil {
+			return nil, resourceError(err)
+		}
+		jsonData, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal prompt data: %w", err)
+		}
+
+		text := fmt.Sprintf("%s\n\nMy WHOOP data (profile %q, fetched %s, units: %s). %s\n\n```json\n%s\n```",
+			instructions, session.name, time.Now().In(opts.location).Format(time.RFC3339), present.units, recordStatusDescription, jsonData)
+		return mcp.NewGetPromptResult(fmt.Sprintf("WHOOP %s for profile %q", request.Params.Name, session.name), []mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+		}), nil
+	}
+}
+
+// addPrompt registers a prompt that embeds records of kinds, unless the
+// tools of all of them are disabled.
+func addPrompt(s *server.MCPServer, prompt mcp.Prompt, kinds []string, handler server.PromptHandlerFunc) {
+	if len(kinds) == 0 {
+		return
+	}
+	s.AddPrompt(prompt, handler)
+}
+
+func lastDays(n int, now time.Time) whoop.TimeRange {
+	return whoop.TimeRange{Start: now.AddDate(0, 0, -n), End: now}
+}
+
+// periodView is the data embedded in a prompt. Records are newest first.
+type periodView struct {
+	Start      string         `json:"start"`
+	End        string         `json:"end"`
+	Cycles     []cycleView    `json:"cycles,omitempty"`
+	Sleeps     []sleepView    `json:"sleeps,omitempty"`
+	Recoveries []recoveryView `json:"recoveries,omitempty"`
+	Workouts   []workoutView  `json:"workouts,omitempty"`
+	Errors     sectionErrors  `json:"errors,omitempty"`
+}
+
+// fetchPeriod loads the records of the given kinds in window. A kind that
+// fails is reported in Errors; only when all of them fail is the error
+// returned.
+func fetchPeriod(ctx context.Context, session *profileSession, present presenter, window whoop.TimeRange, loc *time.Location, kinds ...string) (*periodView, error) {
+	out := &periodView{
+		Start:  window.Start.In(loc).Format(time.RFC3339),
+		End:    window.End.In(loc).Format(time.RFC3339),
+		Errors: sectionErrors{},
+	}
+
+	for _, kind := range kinds {
+		switch kind {
+		case "cycle":
+			cycles, err := collectPages(func(next string) ([]whoop.Cycle, *string, error) {
+				resp, err := session.client.GetCycles(ctx, whoop.CycleParams{TimeRange: window, Limit: whoop.MaxLimit, NextToken: next})
+				if err != nil {
+					return nil, nil, err
+				}
+				return resp.Records, resp.NextToken, nil
+			})
+			if err != nil {
+				out.Errors.add("cycles", err)
+			}
+			out.Cycles = labelPage(cycles, nil, false, present.cycle).Records
+		case "sleep":
+			sleeps, err := collectPages(func(next string) ([]whoop.Sleep, *string, error) {
+				resp, err := session.client.GetSleeps(ctx, whoop.SleepParams{TimeRange: window, Limit: whoop.MaxLimit, NextToken: next})
+				if err != nil {
+					return nil, nil, err
+				}
+				return resp.Records, resp.NextToken, nil
+			})
+			if err != nil {
+				out.Errors.add("sleeps", err)
+			}
+			out.Sleeps = labelPage(sleeps, nil, false, present.sleep).Records
+		case "recovery":
+			recoveries, err := collectPages(func(next string) ([]whoop.Recovery, *string, error) {
+				resp, err := session.client.GetRecoveries(ctx, whoop.RecoveryParams{TimeRange: window, Limit: whoop.MaxLimit, NextToken: next})
+				if err != nil {
+					return nil, nil, err
+				}
+				return resp.Records, resp.NextToken, nil
+			})
+			if err != nil {
+				out.Errors.add("recoveries", err)
+			}
+			out.Recoveries = labelPage(recoveries, nil, false, present.recovery).Records
+		case "workout":
+			workouts, err := collectPages(func(next string) ([]whoop.WorkoutV2, *string, error) {
+				resp, err := session.client.GetWorkouts(ctx, whoop.WorkoutParams{TimeRange: window, Limit: whoop.MaxLimit, NextToken: next})
+				if err != nil {
+					return nil, nil, err
+				}
+				return resp.Records, resp.NextToken, nil
+			})
+			if err != nil {
+				out.Errors.add("workouts", err)
+			}
+			out.Workouts = labelPage(workouts, nil, false, present.workout).Records
+		}
+	}
+
+	if len(out.Errors) == len(kinds) {
+		for _, msg := range out.Errors {
+			return nil, fmt.Errorf("no WHOOP data could be read: %s", msg)
+		}
+	}
+	return out, nil
+}
+
+// collectPages follows next tokens for up to maxCollectPages pages. On error
+// it returns the records read so far.
+func collectPages[T any](fetch func(nextToken string) ([]T, *string, error)) ([]T, error) {
+	var all []T
+	next := ""
+	for page := 0; page < maxCollectPages; page++ {
+		records, nextToken, err := fetch(next)
+		if err != nil {
+			return all, err
+		}
+		all = append(all, records...)
+		if nextToken == nil || *nextToken == "" {
+			break
+		}
+		next = *nextToken
+	}
+	return all, nil
+}
+
+// metricComparison is the mean of a metric over the recent days against
+// the baseline before them.
+type metricComparison struct {
+	Metric          string   `json:"metric"`
+	Recent          *float64 `json:"recent"`
+	Baseline        *float64 `json:"baseline"`
+	ChangePercent   *float64 `json:"change_percent,omitempty"`
+	RecentSamples   int      `json:"recent_samples"`
+	BaselineSamples int      `json:"baseline_samples"`
+}
+
+// compareToBaseline compares scored recoveries and main sleeps since
+// recentSince with those before it.
+func compareToBaseline(period *periodView, recentSince time.Time) []metricComparison {
+	type samples struct{ recent, baseline []float64 }
+	metrics := []string{"hrv_rmssd_milli", "resting_heart_rate", "respiratory_rate", "skin_temp_celsius", "spo2_percentage"}
+	values := make(map[string]*samples, len(metrics))
+	for _, m := range metrics {
+		values[m] = &samples{}
+	}
+	add := func(metric string, at time.Time, v float64) {
+		if at.Before(recentSince) {
+			values[metric].baseline = append(values[metric].baseline, v)
+		} else {
+			values[metric].recent = append(values[metric].recent, v)
+		}
+	}
+
+	for _, r := range period.Recoveries {
+		if !r.IsScored() {
+			continue
+		}
+		add("hrv_rmssd_milli", r.CreatedAt, r.Score.HrvRmssdMilli)
+		add("resting_heart_rate", r.CreatedAt, r.Score.RestingHeartRate)
+		if r.Score.SkinTempCelsius != nil {
+			add("skin_temp_celsius", r.CreatedAt, *r.Score.SkinTempCelsius)
+		}
+		if r.Score.Spo2Percentage != nil {
+			add("spo2_percentage", r.CreatedAt, *r.Score.Spo2Percentage)
+		}
+	}
+	for _, s := range period.Sleeps {
+		if s.Nap || !s.IsScored() || s.Score.RespiratoryRate == nil {
+			continue
+		}
+		add("respiratory_rate", s.End, *s.Score.RespiratoryRate)
+	}
+
+	comparisons := make([]metricComparison, 0, len(metrics))
+	for _, m := range metrics {
+		v := values[m]
+		c := metricComparison{Metric: m, Recent: mean(v.recent), Baseline: mean(v.baseline), RecentSamples: len(v.recent), BaselineSamples: len(v.baseline)}
+		if c.Recent != nil && c.Baseline != nil && *c.Baseline != 0 {
+			c.ChangePercent = roundPtr((*c.Recent-*c.Baseline) / *c.Baseline * 100, 1)
+		}
+		comparisons = append(comparisons, c)
+	}
+	return comparisons
+}
+
+// mean returns the rounded mean of values, or nil if there are none.
+func mean(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return roundPtr(sum/float64(len(values)), 2)
+}
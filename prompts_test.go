@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+func TestCompareToBaseline(t *testing.T) {
+	since := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	recovery := func(day int, hrv, rhr float64) recoveryView {
+		r := whoop.Recovery{
+			CreatedAt:  time.Date(2024, 3, day, 8, 0, 0, 0, time.UTC),
+			ScoreState: whoop.ScoreStateScored,
+			Score:      &whoop.RecoveryScore{HrvRmssdMilli: hrv, RestingHeartRate: rhr},
+		}
+		return recoveryView{Recovery: r}
+	}
+	rate := 16.0
+	period := &periodView{
+		Recoveries: []recoveryView{
+			recovery(11, 40, 60),
+			recovery(10, 40, 60),
+			recovery(8, 50, 50),
+			recovery(7, 50, 50),
+			{Recovery: whoop.Recovery{CreatedAt: since.AddDate(0, 0, -5), ScoreState: whoop.ScoreStatePendingScore}},
+		},
+		Sleeps: []sleepView{
+			{Sleep: whoop.Sleep{End: since.Add(time.Hour), ScoreState: whoop.ScoreStateScored, Score: &whoop.SleepScore{RespiratoryRate: &rate}}},
+			{Sleep: whoop.Sleep{End: since.Add(2 * time.Hour), Nap: true, ScoreState: whoop.ScoreStateScored, Score: &whoop.SleepScore{RespiratoryRate: &rate}}},
+		},
+	}
+
+	got := make(map[string]metricComparison)
+	for _, c := range compareToBaseline(period, since) {
+		got[c.Metric] = c
+	}
+
+	hrv := got["hrv_rmssd_milli"]
+	if *hrv.Recent != 40 || *hrv.Baseline != 50 || *hrv.ChangePercent != -20 || hrv.RecentSamples != 2 || hrv.BaselineSamples != 2 {
+		t.Errorf("hrv = %+v", hrv)
+	}
+	if rhr := got["resting_heart_rate"]; *rhr.ChangePercent != 20 {
+		t.Errorf("resting heart rate change = %v, want 20", *rhr.ChangePercent)
+	}
+	resp := got["respiratory_rate"]
+	if resp.RecentSamples != 1 || resp.Baseline != nil || resp.ChangePercent != nil {
+		t.Errorf("respiratory rate = %+v, want one recent sample without a baseline", resp)
+	}
+	if temp := got["skin_temp_celsius"]; temp.Recent != nil || temp.Baseline != nil {
+		t.Errorf("skin temp = %+v, want no samples", temp)
+	}
+}
+
+func TestCollectPages(t *testing.T) {
+	calls := 0
+	records, err := collectPages(func(next string) ([]int, *string, error) {
+		calls++
+		token := "more"
+		return []int{calls}, &token, nil
+	})
+	if err != nil || len(records) != maxCollectPages || calls != maxCollectPages {
+		t.Errorf("got %v, %v after %d calls; want %d pages", records, err, calls, maxCollectPages)
+	}
+}
+
+func getPrompt(t *testing.T, s *server.MCPServer, name string, args map[string]string) mcp.JSONRPCMessage {
+	t.Helper()
+	request, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "prompts/get",
+		"params":  map[string]interface{}{"name": name, "arguments": args},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.HandleMessage(context.Background(), request)
+}
+
+func TestPrompts(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v2/activity/sleep":
+			json.NewEncoder(w).Encode(whoop.PaginatedSleepResponse{Records: []whoop.Sleep{{ID: "night-1", ScoreState: whoop.ScoreStatePendingScore}}})
+		case "/v2/recovery":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			json.NewEncoder(w).Encode(whoop.PaginatedCycleResponse{})
+		}
+	}))
+	defer srv.Close()
+
+	s := server.NewMCPServer("test", "0.0.0", server.WithPromptCapabilities(false))
+	registerPrompts(s, newResourceTestRegistry(t, srv), toolOptions{location: time.UTC, units: whoop.UnitsMetric})
+
+	response, ok := getPrompt(t, s, "sleep_coaching", map[string]string{"nights": "7"}).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("expected a response, got %+v", response)
+	}
+	result, ok := response.Result.(*mcp.GetPromptResult)
+	if !ok || len(result.Messages) != 1 {
+		t.Fatalf("unexpected result %+v", response.Result)
+	}
+	text := result.Messages[0].Content.(mcp.TextContent).Text
+	for _, want := range []string{"last 7 nights", `"id": "night-1"`, `"status": "pending_score"`, `"recoveries": "`} {
+		if !strings.Contains(text, want) {
+			t.Errorf("prompt text missing %q:\n%s", want, text)
+		}
+	}
+	if len(paths) != 2 {
+		t.Errorf("fetched %v, want sleeps and recoveries only", paths)
+	}
+
+	for name, args := range map[string]map[string]string{
+		"training_plan_adjustment": nil,
+		"sleep_coaching":           {"nights": "0"},
+		"weekly_review":            {"period": "next_week"},
+		"morning_briefing":         {"units": "furlongs"},
+	} {
+		if _, ok := getPrompt(t, s, name, args).(mcp.JSONRPCError); !ok {
+			t.Errorf("%s %v: expected an error", name, args)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+const recordStatusDescription = "Every record has a status: scored, in_progress (the current cycle, whose score is partial), pending_score or unscorable. Records that are not scored have no score; treat their metrics as unknown, not zero."
+
+const scoredOnlyDescription = "Only return records with a final score, skipping the in-progress cycle and records that are pending or unscorable (default: false). A page may then hold fewer than limit records."
+
+const unitsDescription = "Units for the derived values (hours, percentages, kcal, distance, speed): metric or imperial. Defaults to the server setting."
+
+// toolOptions are server-wide defaults for the data tools.
+type toolOptions struct {
+	// location resolves dates and relative ranges in tool arguments.
+	location *time.Location
+	// units is the default for the units argument.
+	units whoop.Units
+	// privacy selects what privacy mode keeps out of responses.
+	privacy privacyOptions
+	// enabled reports whether a tool is registered. Resources and prompts
+	// leave out the data that disabled tools would serve; nil enables
+	// every tool.
+	enabled func(name string) bool
+}
+
+// toolEnabled reports whether the tool is registered.
+func (o toolOptions) toolEnabled(name string) bool {
+	return o.enabled == nil || o.enabled(name)
+}
+
+// enabledKinds returns the record kinds, out of kinds, whose list tool is
+// registered.
+func (o toolOptions) enabledKinds(kinds ...string) []string {
+	var enabled []string
+	for _, kind := range kinds {
+		if o.toolEnabled(kindTools[kind]) {
+			enabled = append(enabled, kind)
+		}
+	}
+	return enabled
+}
+
+// privacyOptions select what privacy mode keeps out of tool, resource and
+// prompt output.
+type privacyOptions struct {
+	// redactPII replaces the user's ID, email and name.
+	redactPII bool
+	// hideBody withholds body measurements.
+	hideBody bool
+}
+
+// privacyNote returns a description suffix for tools whose output privacy
+// mode redacts, or "" when it is off.
+func privacyNote(privacy privacyOptions) string {
+	if !privacy.redactPII {
+		return ""
+	}
+	return " NOTE: privacy mode is on, so the user ID, email and name are redacted."
+}
+
+// presenter renders WHOOP records for tool output: each record is labeled
+// with its score status and gets a "derived" object with values converted
+// to the caller's units. In privacy mode it also redacts personal data.
+type presenter struct {
+	units   whoop.Units
+	privacy privacyOptions
+}
+
+// presenter returns a presenter for the units argument, falling back to the
+// server default when it is empty.
+func (o toolOptions) presenter(unitsArg string) (presenter, error) {
+	units := o.units
+	if unitsArg != "" {
+		parsed, err := whoop.ParseUnits(unitsArg)
+		if err != nil {
+			return presenter{}, err
+		}
+		units = parsed
+	}
+	return presenter{units: units, privacy: o.privacy}, nil
+}
+
+// scoreLabel is added to every record in tool output so a missing score is
+// not mistaken for a zero.
+type scoreLabel struct {
+	Status whoop.RecordStatus `json:"status"`
+	Note   string             `json:"status_note,omitempty"`
+}
+
+func labelFor(status whoop.RecordStatus) scoreLabel {
+	return scoreLabel{Status: status, Note: whoop.StatusNote(status)}
+}
+
+type cycleView struct {
+	whoop.Cycle
+	scoreLabel
+	Derived *cycleDerived `json:"derived,omitempty"`
+}
+
+type cycleDerived struct {
+	DurationHours *float64 `json:"duration_hours,omitempty"`
+	CaloriesKcal  float64  `json:"calories_kcal"`
+}
+
+type sleepView struct {
+	whoop.Sleep
+	scoreLabel
+	Derived *sleepDerived `json:"derived,omitempty"`
+}
+
+type sleepDerived struct {
+	TotalSleepHours      float64 `json:"total_sleep_hours"`
+	TimeInBedHours       float64 `json:"time_in_bed_hours"`
+	AwakeHours           float64 `json:"awake_hours"`
+	SleepNeededHours     float64 `json:"sleep_needed_hours"`
+	LightSleepPercent    float64 `json:"light_sleep_percent"`
+	SlowWaveSleepPercent float64 `json:"slow_wave_sleep_percent"`
+	RemSleepPercent      float64 `json:"rem_sleep_percent"`
+	AwakePercent         float64 `json:"awake_percent"`
+}
+
+type recoveryView struct {
+	whoop.Recovery
+	scoreLabel
+	Derived *recoveryDerived `json:"derived,omitempty"`
+}
+
+type recoveryDerived struct {
+	SkinTempCelsius    *float64 `json:"skin_temp_celsius,omitempty"`
+	SkinTempFahrenheit *float64 `json:"skin_temp_fahrenheit,omitempty"`
+}
+
+type workoutView struct {
+	whoop.WorkoutV2
+	scoreLabel
+	Derived *workoutDerived `json:"derived,omitempty"`
+}
+
+type workoutDerived struct {
+	DurationMinutes float64  `json:"duration_minutes"`
+	CaloriesKcal    float64  `json:"calories_kcal"`
+	DistanceKm      *float64 `json:"distance_km,omitempty"`
+	DistanceMiles   *float64 `json:"distance_miles,omitempty"`
+	SpeedKph        *float64 `json:"speed_kph,omitempty"`
+	SpeedMph        *float64 `json:"speed_mph,omitempty"`
+	PacePerKm       string   `json:"pace_per_km,omitempty"`
+	PacePerMile     string   `json:"pace_per_mile,omitempty"`
+	AltitudeGainM   *float64 `json:"altitude_gain_m,omitempty"`
+	AltitudeGainFt  *float64 `json:"altitude_gain_ft,omitempty"`
+}
+
+type bodyMeasurementView struct {
+	whoop.UserBodyMeasurement
+	Derived bodyMeasurementDerived `json:"derived"`
+}
+
+type bodyMeasurementDerived struct {
+	BMI          float64  `json:"bmi"`
+	HeightFeet   string   `json:"height_feet_inches,omitempty"`
+	WeightPounds *float64 `json:"weight_pounds,omitempty"`
+}
+
+// recordPage is a labeled page of records. Skipped counts the records
+// dropped by scored_only.
+type recordPage[T any] struct {
+	Records   []T     `json:"records"`
+	NextToken *string `json:"next_token,omitempty"`
+	Skipped   int     `json:"skipped_unscored,omitempty"`
+}
+
+// profile returns the user's profile with the ID, email and name redacted
+// in privacy mode.
+func (p presenter) profile(u whoop.UserBasicProfile) whoop.UserBasicProfile {
+	if p.privacy.redactPII {
+		u.UserID = 0
+		u.Email = redactedValue
+		u.FirstName = redactedValue
+		u.LastName = redactedValue
+	}
+	return u
+}
+
+// userID returns the record owner's ID, or 0 in privacy mode.
+func (p presenter) userID(id int64) int64 {
+	if p.privacy.redactPII {
+		return 0
+	}
+	return id
+}
+
+func (p presenter) cycle(c whoop.Cycle) cycleView {
+	c.UserID = p.userID(c.UserID)
+	view := cycleView{Cycle: c, scoreLabel: labelFor(c.Status())}
+	if c.Score != nil {
+		view.Derived = &cycleDerived{CaloriesKcal: round(c.Score.Kilocalories(), 0)}
+		if d, ok := c.Duration(); ok {
+			view.Derived.DurationHours = roundPtr(d.Hours(), 2)
+		}
+	}
+	return view
+}
+
+func (p presenter) sleep(s whoop.Sleep) sleepView {
+	s.UserID = p.userID(s.UserID)
+	view := sleepView{Sleep: s, scoreLabel: labelFor(s.Status())}
+	if s.Score != nil {
+		stages := s.Score.StageSummary
+		view.Derived = &sleepDerived{
+			TotalSleepHours:      round(stages.TotalSleepTime().Hours(), 2),
+			TimeInBedHours:       round(stages.TimeInBed().Hours(), 2),
+			AwakeHours:           round(stages.AwakeTime().Hours(), 2),
+			SleepNeededHours:     round(s.Score.SleepNeeded.Need().Hours(), 2),
+			LightSleepPercent:    round(stages.LightSleepPercent(), 1),
+			SlowWaveSleepPercent: round(stages.SlowWaveSleepPercent(), 1),
+			RemSleepPercent:      round(stages.RemSleepPercent(), 1),
+			AwakePercent:         round(stages.AwakePercent(), 1),
+		}
+	}
+	return view
+}
+
+func (p presenter) recovery(r whoop.Recovery) recoveryView {
+	r.UserID = p.userID(r.UserID)
+	view := recoveryView{Recovery: r, scoreLabel: labelFor(r.Status())}
+	if r.Score != nil {
+		if temp, ok := r.Score.SkinTemp(p.units); ok {
+			view.Derived = &recoveryDerived{}
+			if p.units == whoop.UnitsImperial {
+				view.Derived.SkinTempFahrenheit = roundPtr(temp, 1)
+			} else {
+				view.Derived.SkinTempCelsius = roundPtr(temp, 1)
+			}
+		}
+	}
+	return view
+}
+
+func (p presenter) workout(w whoop.WorkoutV2) workoutView {
+	w.UserID = p.userID(w.UserID)
+	view := workoutView{WorkoutV2: w, scoreLabel: labelFor(w.Status())}
+	if w.Score == nil {
+		return view
+	}
+
+	d := w.Duration()
+	derived := &workoutDerived{
+		DurationMinutes: round(d.Minutes(), 1),
+		CaloriesKcal:    round(w.Score.Kilocalories(), 0),
+	}
+	imperial := p.units == whoop.UnitsImperial
+	if distance, ok := w.Score.Distance(p.units); ok {
+		if imperial {
+			derived.DistanceMiles = roundPtr(distance, 2)
+		} else {
+			derived.DistanceKm = roundPtr(distance, 2)
+		}
+	}
+	if speed, ok := w.Score.Speed(d, p.units); ok {
+		if imperial {
+			derived.SpeedMph = roundPtr(speed, 1)
+		} else {
+			derived.SpeedKph = roundPtr(speed, 1)
+		}
+	}
+	if pace, ok := w.Score.Pace(d, p.units); ok {
+		if imperial {
+			derived.PacePerMile = formatPace(pace)
+		} else {
+			derived.PacePerKm = formatPace(pace)
+		}
+	}
+	if gain, ok := w.Score.AltitudeGain(p.units); ok {
+		if imperial {
+			derived.AltitudeGainFt = roundPtr(gain, 0)
+		} else {
+			derived.AltitudeGainM = roundPtr(gain, 0)
+		}
+	}
+	view.Derived = derived
+	return view
+}
+
+func (p presenter) bodyMeasurement(m whoop.UserBodyMeasurement) bodyMeasurementView {
+	view := bodyMeasurementView{UserBodyMeasurement: m}
+	view.Derived.BMI = round(m.BMI(), 1)
+	if p.units == whoop.UnitsImperial {
+		feet, inches := m.HeightFeetInches()
+		view.Derived.HeightFeet = fmt.Sprintf("%d ft %.1f in", feet, inches)
+		view.Derived.WeightPounds = roundPtr(m.WeightPounds(), 1)
+	}
+	return view
+}
+
+// labelPage renders records with view and, with scoredOnly, drops those
+// without a final score.
+func labelPage[R interface{ Status() whoop.RecordStatus }, V any](records []R, nextToken *string, scoredOnly bool, view func(R) V) recordPage[V] {
+	page := recordPage[V]{Records: make([]V, 0, len(records)), NextToken: nextToken}
+	if scoredOnly {
+		scored := whoop.ScoredOnly(records)
+		page.Skipped = len(records) - len(scored)
+		records = scored
+	}
+	for _, r := range records {
+		page.Records = append(page.Records, view(r))
+	}
+	return page
+}
+
+func round(v float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(v*scale) / scale
+}
+
+func roundPtr(v float64, places int) *float64 {
+	r := round(v, places)
+	return &r
+}
+
+// formatPace formats a pace as m:ss.
+func formatPace(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+func TestLabelPage(t *testing.T) {
+	records := []whoop.Recovery{
+		{CycleID: 1, ScoreState: whoop.ScoreStateScored, Score: &whoop.RecoveryScore{RecoveryScore: 80}},
+		{CycleID: 2, ScoreState: whoop.ScoreStatePendingScore},
+		{CycleID: 3, ScoreState: whoop.ScoreStateUnscorable},
+	}
+	next := "token"
+
+	t.Run("labels every record", func(t *testing.T) {
+		page := labelPage(records, &next, false, presenter{units: whoop.UnitsMetric}.recovery)
+
+		data, err := json.Marshal(page)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		var decoded struct {
+			Records []struct {
+				CycleID    int64  `json:"cycle_id"`
+				ScoreState string `json:"score_state"`
+				Status     string `json:"status"`
+				StatusNote string `json:"status_note"`
+			} `json:"records"`
+			NextToken string `json:"next_token"`
+			Skipped   *int   `json:"skipped_unscored"`
+		}
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+
+		if len(decoded.Records) != 3 || decoded.NextToken != "token" || decoded.Skipped != nil {
+			t.Fatalf("unexpected page: %s", data)
+		}
+		want := []string{"scored", "pending_score", "unscorable"}
+		for i, r := range decoded.Records {
+			if r.Status != want[i] {
+				t.Errorf("record %d: status = %q, want %q", r.CycleID, r.Status, want[i])
+			}
+			if r.ScoreState == "" {
+				t.Errorf("record %d: score_state missing from flattened output", r.CycleID)
+			}
+			if (r.StatusNote == "") != (r.Status == "scored") {
+				t.Errorf("record %d: status_note = %q", r.CycleID, r.StatusNote)
+			}
+		}
+	})
+
+	t.Run("scored only", func(t *testing.T) {
+		page := labelPage(records, nil, true, presenter{units: whoop.UnitsMetric}.recovery)
+		if len(page.Records) != 1 || page.Records[0].CycleID != 1 {
+			t.Errorf("expected only cycle 1, got %+v", page.Records)
+		}
+		if page.Skipped != 2 {
+			t.Errorf("skipped = %d, want 2", page.Skipped)
+		}
+	})
+}
+
+func TestPresenterWorkoutUnits(t *testing.T) {
+	distance := 10000.0
+	gain := 100.0
+	start := time.Date(2024, 1, 1, 7, 0, 0, 0, time.UTC)
+	workout := whoop.WorkoutV2{
+		Start:      start,
+		End:        start.Add(50 * time.Minute),
+		ScoreState: whoop.ScoreStateScored,
+		Score: &whoop.WorkoutScore{
+			Kilojoule:         2092,
+			DistanceMeter:     &distance,
+			AltitudeGainMeter: &gain,
+		},
+	}
+
+	metric := presenter{units: whoop.UnitsMetric}.workout(workout).Derived
+	if metric.CaloriesKcal != 500 || metric.DurationMinutes != 50 {
+		t.Errorf("metric: got %+v", metric)
+	}
+	if metric.DistanceKm == nil || *metric.DistanceKm != 10 || metric.PacePerKm != "5:00" || *metric.SpeedKph != 12 {
+		t.Errorf("metric distance: got %+v", metric)
+	}
+	if metric.DistanceMiles != nil || metric.PacePerMile != "" {
+		t.Errorf("metric output has imperial values: %+v", metric)
+	}
+
+	imperial := presenter{units: whoop.UnitsImperial}.workout(workout).Derived
+	if imperial.DistanceMiles == nil || *imperial.DistanceMiles != 6.21 || imperial.PacePerMile != "8:03" {
+		t.Errorf("imperial distance: got %+v", imperial)
+	}
+	if imperial.AltitudeGainFt == nil || *imperial.AltitudeGainFt != 328 || imperial.DistanceKm != nil {
+		t.Errorf("imperial altitude: got %+v", imperial)
+	}
+
+	pending := presenter{units: whoop.UnitsMetric}.workout(whoop.WorkoutV2{ScoreState: whoop.ScoreStatePendingScore})
+	if pending.Derived != nil {
+		t.Errorf("expected no derived values without a score, got %+v", pending.Derived)
+	}
+}
+
+func TestToolOptionsPresenter(t *testing.T) {
+	opts := toolOptions{units: whoop.UnitsImperial}
+
+	p, err := opts.presenter("")
+	if err != nil || p.units != whoop.UnitsImperial {
+		t.Errorf("default: got %v, %v", p.units, err)
+	}
+	p, err = opts.presenter("Metric")
+	if err != nil || p.units != whoop.UnitsMetric {
+		t.Errorf("override: got %v, %v", p.units, err)
+	}
+	if _, err := opts.presenter("furlongs"); !errors.Is(err, whoop.ErrValidation) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+}
+
+func TestPresenterPrivacy(t *testing.T) {
+	profile := whoop.UserBasicProfile{UserID: 10129, Email: "jane@example.com", FirstName: "Jane", LastName: "Smith"}
+	cycle := whoop.Cycle{ID: 1, UserID: 10129}
+
+	open := presenter{units: whoop.UnitsMetric}
+	if got := open.profile(profile); got != profile {
+		t.Errorf("profile without privacy mode = %+v, want unchanged", got)
+	}
+	if got := open.cycle(cycle); got.UserID != 10129 {
+		t.Errorf("cycle user_id = %d, want unchanged", got.UserID)
+	}
+
+	private := presenter{units: whoop.UnitsMetric, privacy: privacyOptions{redactPII: true}}
+	got := private.profile(profile)
+	if got.UserID != 0 || got.Email != redactedValue || got.FirstName != redactedValue || got.LastName != redactedValue {
+		t.Errorf("profile in privacy mode = %+v, want redacted", got)
+	}
+	if private.cycle(cycle).UserID != 0 || private.sleep(whoop.Sleep{UserID: 10129}).UserID != 0 ||
+		private.recovery(whoop.Recovery{UserID: 10129}).UserID != 0 || private.workout(whoop.WorkoutV2{UserID: 10129}).UserID != 0 {
+		t.Error("records in privacy mode should have user_id redacted")
+	}
+}
@@ -0,0 +1,558 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+// recentLimit is how many records of each kind whoop://recent lists.
+const recentLimit = 5
+
+// resourceHandler is assignable to both server.ResourceHandlerFunc and
+// server.ResourceTemplateHandlerFunc.
+type resourceHandler = func(ctx context.Context, request mcp.ReadResourceRequest) ([]interface{}, error)
+
+// resourceFetcher loads the data for a record resource. id is the URI's
+// last path segment, empty for fixed resources.
+type resourceFetcher func(ctx context.Context, session *profileSession, present presenter, id string) (interface{}, error)
+
+// registerRecordResources exposes WHOOP records as MCP resources. Every
+// URI accepts ?profile=<name> to read from a profile other than the active
+// one. Resources serve the same data as the tools, so data whose tools are
+// disabled is left out.
+//
+// resources/list only returns fixed resources, so recent records are
+// enumerated by whoop://recent, which links to their URIs.
+func registerRecordResources(s *server.MCPServer, profiles *profileRegistry, opts toolOptions) {
+	if opts.toolEnabled("get_user_profile") || opts.toolEnabled("get_body_measurements") {
+		addFixedResource(s,
+			mcp.NewResource("whoop://profile", "WHOOP Profile",
+				mcp.WithResourceDescription("The user's basic profile and body measurements."),
+				mcp.WithMIMEType("application/json"),
+			),
+			recordResource(profiles, opts, nil, opts.fetchProfileResource),
+		)
+	}
+
+	kinds := opts.enabledKinds(latestKinds...)
+	if len(kinds) > 0 {
+		addFixedResource(s,
+			mcp.NewResource("whoop://recent", "Recent WHOOP Records",
+				mcp.WithResourceDescription(fmt.Sprintf("The %d most recent cycles, sleeps, recoveries and workouts, with their status and resource URIs.", recentLimit)),
+				mcp.WithMIMEType("application/json"),
+			),
+			recordResource(profiles, opts, nil, opts.fetchRecentResource),
+		)
+	}
+
+	if len(kinds) > 0 {
+		addFixedResource(s,
+			mcp.NewResource("whoop://today", "WHOOP Today",
+				mcp.WithResourceDescription("Everything recorded today, as whoop://day/today. Subscribe to be notified when new data is scored."),
+				mcp.WithMIMEType("application/json"),
+			),
+			recordResource(profiles, opts, nil, func(ctx context.Context, session *profileSession, present presenter, _ string) (interface{}, error) {
+				day, err := parseDay("today", opts.location, time.Now())
+				if err != nil {
+					return nil, err
+				}
+				return fetchDay(ctx, session, present, day, opts.location, kinds...), nil
+			}),
+		)
+	}
+
+	for _, kind := range kinds {
+		addFixedResource(s,
+			mcp.NewResource("whoop://latest/"+kind, "Latest WHOOP "+kind,
+				mcp.WithResourceDescription(fmt.Sprintf("The most recent %s record. Subscribe to be notified when a new one is recorded or scored.", kind)),
+				mcp.WithMIMEType("application/json"),
+			),
+			recordResource(profiles, opts, toolScopes[kindTools[kind]], fetchLatest),
+		)
+	}
+
+	if opts.toolEnabled("get_cycle_by_id") {
+		s.AddResourceTemplate(
+			mcp.NewResourceTemplate("whoop://cycle/{id}", "WHOOP Cycle",
+				mcp.WithTemplateDescription("A physiological cycle by numeric ID."),
+				mcp.WithTemplateMIMEType("application/json"),
+			),
+			recordResource(profiles, opts, toolScopes["get_cycle_by_id"], func(ctx context.Context, session *profileSession, present presenter, id string) (interface{}, error) {
+				cycleID, err := strconv.ParseInt(id, 10, 64)
+				if err != nil || cycleID <= 0 {
+					return nil, newArgumentError("cycle ID must be a positive integer, got %q", id)
+				}
+				cycle, err := session.client.GetCycleByID(ctx, cycleID)
+				if err != nil {
+					return nil, err
+				}
+				return present.cycle(*cycle), nil
+			}),
+		)
+	}
+
+	if opts.toolEnabled("get_sleep_by_id") {
+		s.AddResourceTemplate(
+			mcp.NewResourceTemplate("whoop://sleep/{uuid}", "WHOOP Sleep",
+				mcp.WithTemplateDescription("A sleep record by UUID."),
+				mcp.WithTemplateMIMEType("application/json"),
+			),
+			recordResource(profiles, opts, toolScopes["get_sleep_by_id"], func(ctx context.Context, session *profileSession, present presenter, id string) (interface{}, error) {
+				sleep, err := session.client.GetSleepByID(ctx, id)
+				if err != nil {
+					return nil, err
+				}
+				return present.sleep(*sleep), nil
+			}),
+		)
+	}
+
+	if opts.toolEnabled("get_recovery_for_cycle") {
+		s.AddResourceTemplate(
+			mcp.NewResourceTemplate("whoop://recovery/{cycle_id}", "WHOOP Recovery",
+				mcp.WithTemplateDescription("The recovery for a cycle, by numeric cycle ID."),
+				mcp.WithTemplateMIMEType("application/json"),
+			),
+			recordResource(profiles, opts, toolScopes["get_recovery_for_cycle"], func(ctx context.Context, session *profileSession, present presenter, id string) (interface{}, error) {
+				cycleID, err := strconv.ParseInt(id, 10, 64)
+				if err != nil || cycleID <= 0 {
+					return nil, newArgumentError("cycle ID must be a positive integer, got %q", id)
+				}
+				recovery, err := session.client.GetRecoveryForCycle(ctx, cycleID)
+				if err != nil {
+					return nil, err
+				}
+				return present.recovery(*recovery), nil
+			}),
+		)
+	}
+
+	if opts.toolEnabled("get_workout_by_id") {
+		s.AddResourceTemplate(
+			mcp.NewResourceTemplate("whoop://workout/{uuid}", "WHOOP Workout",
+				mcp.WithTemplateDescription("A workout by UUID."),
+				mcp.WithTemplateMIMEType("application/json"),
+			),
+			recordResource(profiles, opts, toolScopes["get_workout_by_id"], func(ctx context.Context, session *profileSession, present presenter, id string) (interface{}, error) {
+				workout, err := session.client.GetWorkoutByID(ctx, id)
+				if err != nil {
+					return nil, err
+				}
+				return present.workout(*workout), nil
+			}),
+		)
+	}
+
+	if len(kinds) > 0 {
+		s.AddResourceTemplate(
+			mcp.NewResourceTemplate("whoop://day/{date}", "WHOOP Day",
+				mcp.WithTemplateDescription("Everything recorded on one local calendar day: the cycle and workouts that started on it, the sleeps that ended on it, and their recoveries. date is YYYY-MM-DD, today or yesterday."),
+				mcp.WithTemplateMIMEType("application/json"),
+			),
+			recordResource(profiles, opts, nil, func(ctx context.Context, session *profileSession, present presenter, id string) (interface{}, error) {
+				day, err := parseDay(id, opts.location, time.Now())
+				if err != nil {
+					return nil, err
+				}
+				return fetchDay(ctx, session, present, day, opts.location, kinds...), nil
+			}),
+		)
+	}
+}
+
+// addFixedResource registers a resource together with a template for its
+// ?profile= form: the server looks fixed resources up by their exact URI,
+// so a query string would otherwise match nothing.
+func addFixedResource(s *server.MCPServer, resource mcp.Resource, handler resourceHandler) {
+	s.AddResource(resource, handler)
+	s.AddResourceTemplate(
+		mcp.NewResourceTemplate(resource.URI+"{?profile}", resource.Name,
+			mcp.WithTemplateDescription(resource.Description),
+			mcp.WithTemplateMIMEType(resource.MIMEType),
+		),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]interface{}, error) {
+			// The template also matches any other suffix, e.g. whoop://profiles
+			if base, _, _ := strings.Cut(request.Params.URI, "?"); base != resource.URI {
+				return nil, resourceError(newArgumentError("unknown resource URI %q", request.Params.URI))
+			}
+			return handler(ctx, request)
+		},
+	)
+}
+
+// latestKinds are the record kinds with a whoop://latest/<kind> resource.
+var latestKinds = []string{"cycle", "sleep", "recovery", "workout"}
+
+// kindTools names the list tool serving each record kind. A kind whose tool
+// is disabled is left out of resources, prompts and notifications.
+var kindTools = map[string]string{
+	"cycle":    "get_cycles",
+	"sleep":    "get_sleeps",
+	"recovery": "get_recoveries",
+	"workout":  "get_workouts",
+}
+
+// fetchLatest loads the newest record of a kind, named by the last segment
+// of its whoop://latest/<kind> URI.
+func fetchLatest(ctx context.Context, session *profileSession, present presenter, kind string) (interface{}, error) {
+	switch kind {
+	case "cycle":
+		resp, err := session.client.GetCycles(ctx, whoop.CycleParams{Limit: 1})
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Records) == 0 {
+			return nil, fmt.Errorf("%w: no cycles recorded yet", whoop.ErrNotFound)
+		}
+		return present.cycle(resp.Records[0]), nil
+	case "sleep":
+		resp, err := session.client.GetSleeps(ctx, whoop.SleepParams{Limit: 1})
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Records) == 0 {
+			return nil, fmt.Errorf("%w: no sleeps recorded yet", whoop.ErrNotFound)
+		}
+		return present.sleep(resp.Records[0]), nil
+	case "recovery":
+		resp, err := session.client.GetRecoveries(ctx, whoop.RecoveryParams{Limit: 1})
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Records) == 0 {
+			return nil, fmt.Errorf("%w: no recoveries recorded yet", whoop.ErrNotFound)
+		}
+		return present.recovery(resp.Records[0]), nil
+	case "workout":
+		resp, err := session.client.GetWorkouts(ctx, whoop.WorkoutParams{Limit: 1})
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Records) == 0 {
+			return nil, fmt.Errorf("%w: no workouts recorded yet", whoop.ErrNotFound)
+		}
+		return present.workout(resp.Records[0]), nil
+	default:
+		return nil, newArgumentError("unknown record kind %q", kind)
+	}
+}
+
+// recordResource adapts a fetcher into a resource handler: it resolves the
+// profile from the URI, checks scopes and renders the data as JSON.
+func recordResource(profiles *profileRegistry, opts toolOptions, scopes []string, fetch resourceFetcher) resourceHandler {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]interface{}, error) {
+		id, profile, err := parseResourceURI(request.Params.URI)
+		if err != nil {
+			return nil, resourceError(err)
+		}
+
+		session, err := profiles.Get(profile)
+		if err != nil {
+			return nil, resourceError(newArgumentError("profile: %v", err))
+		}
+		if missing := missingScopes(session.GrantedScopes(), scopes); len(missing) > 0 {
+			return nil, fmt.Errorf("%s (error code: %s)", missingScopeMessage(session.name, session.GrantedScopes(), missing), whoop.CodeForbiddenScope)
+		}
+
+		data, err := fetch(ctx, session, presenter{units: opts.units, privacy: opts.privacy}, id)
+		if err != nil {
+			return nil, resourceError(err)
+		}
+		return jsonResourceContents(request.Params.URI, data)
+	}
+}
+
+// parseResourceURI returns the last path segment of a whoop:// URI, e.g.
+// the ID in whoop://cycle/123, and the profile query parameter.
+func parseResourceURI(uri string) (id, profile string, err error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "whoop" {
+		return "", "", newArgumentError("invalid resource URI %q", uri)
+	}
+	return strings.TrimPrefix(u.Path, "/"), u.Query().Get("profile"), nil
+}
+
+// resourceError renders err the way tool errors are rendered. Resource
+// reads can only fail with a JSON-RPC error, so the code goes in the message.
+func resourceError(err error) error {
+	return fmt.Errorf("%s (error code: %s)", formatError(err), errorCode(err))
+}
+
+func jsonResourceContents(uri string, data interface{}) ([]interface{}, error) {
+	jsonData, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal resource: %w", err)
+	}
+	return []interface{}{
+		mcp.TextResourceContents{
+			ResourceContents: mcp.ResourceContents{
+				URI:      uri,
+				MIMEType: "application/json",
+			},
+			Text: string(jsonData),
+		},
+	}, nil
+}
+
+// sectionErrors collects per-section failures for resources that combine
+// several endpoints, so that one missing scope does not hide the rest.
+type sectionErrors map[string]string
+
+func (e sectionErrors) add(section string, err error) {
+	e[section] = fmt.Sprintf("%s (error code: %s)", formatError(err), errorCode(err))
+}
+
+// fetchProfileResource loads the sections of whoop://profile whose tools
+// are enabled.
+func (o toolOptions) fetchProfileResource(ctx context.Context, session *profileSession, present presenter, _ string) (interface{}, error) {
+	out := struct {
+		Profile          *whoop.UserBasicProfile `json:"profile,omitempty"`
+		BodyMeasurements *bodyMeasurementView    `json:"body_measurements,omitempty"`
+		Errors           sectionErrors           `json:"errors,omitempty"`
+	}{Errors: sectionErrors{}}
+
+	var firstErr error
+	if o.toolEnabled("get_user_profile") {
+		if profile, err := session.client.GetUserProfile(ctx); err != nil {
+			out.Errors.add("profile", err)
+			firstErr = err
+		} else {
+			redacted := present.profile(*profile)
+			out.Profile = &redacted
+		}
+	}
+
+	// Privacy mode may withhold body measurements
+	if o.toolEnabled("get_body_measurements") && !present.privacy.hideBody {
+		if body, err := session.client.GetBodyMeasurements(ctx); err != nil {
+			out.Errors.add("body_measurements", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		} else {
+			view := present.bodyMeasurement(*body)
+			out.BodyMeasurements = &view
+		}
+	}
+
+	if out.Profile == nil && out.BodyMeasurements == nil && firstErr != nil {
+		return nil, firstErr
+	}
+	return out, nil
+}
+
+// recentRecord links to a record resource.
+type recentRecord struct {
+	URI    string             `json:"uri"`
+	Start  *time.Time         `json:"start,omitempty"`
+	End    *time.Time         `json:"end,omitempty"`
+	Status whoop.RecordStatus `json:"status"`
+	Label  string             `json:"label,omitempty"`
+}
+
+// fetchRecentResource lists the recent records of the kinds whose tools are
+// enabled.
+func (o toolOptions) fetchRecentResource(ctx context.Context, session *profileSession, _ presenter, _ string) (interface{}, error) {
+	kinds := o.enabledKinds(latestKinds...)
+	out := struct {
+		Profile    string         `json:"profile"`
+		Cycles     []recentRecord `json:"cycles"`
+		Sleeps     []recentRecord `json:"sleeps"`
+		Recoveries []recentRecord `json:"recoveries"`
+		Workouts   []recentRecord `json:"workouts"`
+		Errors     sectionErrors  `json:"errors,omitempty"`
+	}{Profile: session.name, Errors: sectionErrors{}}
+
+	if slices.Contains(kinds, "cycle") {
+		if resp, err := session.client.GetCycles(ctx, whoop.CycleParams{Limit: recentLimit}); err != nil {
+			out.Errors.add("cycles", err)
+		} else {
+			for _, c := range resp.Records {
+				out.Cycles = append(out.Cycles, recentRecord{
+					URI: fmt.Sprintf("whoop://cycle/%d", c.ID), Start: &c.Start, End: c.End, Status: c.Status(),
+				})
+			}
+		}
+	}
+
+	if slices.Contains(kinds, "sleep") {
+		if resp, err := session.client.GetSleeps(ctx, whoop.SleepParams{Limit: recentLimit}); err != nil {
+			out.Errors.add("sleeps", err)
+		} else {
+			for _, sl := range resp.Records {
+				label := "sleep"
+				if sl.Nap {
+					label = "nap"
+				}
+				out.Sleeps = append(out.Sleeps, recentRecord{
+					URI: "whoop://sleep/" + sl.ID, Start: &sl.Start, End: &sl.End, Status: sl.Status(), Label: label,
+				})
+			}
+		}
+	}
+
+	if slices.Contains(kinds, "recovery") {
+		if resp, err := session.client.GetRecoveries(ctx, whoop.RecoveryParams{Limit: recentLimit}); err != nil {
+			out.Errors.add("recoveries", err)
+		} else {
+			for _, r := range resp.Records {
+				out.Recoveries = append(out.Recoveries, recentRecord{
+					URI: fmt.Sprintf("whoop://recovery/%d", r.CycleID), Start: &r.CreatedAt, Status: r.Status(),
+				})
+			}
+		}
+	}
+
+	if slices.Contains(kinds, "workout") {
+		if resp, err := session.client.GetWorkouts(ctx, whoop.WorkoutParams{Limit: recentLimit}); err != nil {
+			out.Errors.add("workouts", err)
+		} else {
+			for _, w := range resp.Records {
+				out.Workouts = append(out.Workouts, recentRecord{
+					URI: "whoop://workout/" + w.ID, Start: &w.Start, End: &w.End, Status: w.Status(), Label: w.SportName,
+				})
+			}
+		}
+	}
+
+	if len(kinds) > 0 && len(out.Errors) == len(kinds) {
+		for _, msg := range out.Errors {
+			return nil, fmt.Errorf("no WHOOP data could be read: %s", msg)
+		}
+	}
+	return out, nil
+}
+
+// parseDay accepts YYYY-MM-DD, today or yesterday.
+func parseDay(s string, loc *time.Location, now time.Time) (whoop.Date, error) {
+	switch strings.ToLower(s) {
+	case "today":
+		return whoop.DateOf(now.In(loc)), nil
+	case "yesterday":
+		return whoop.DateOf(now.In(loc)).AddDays(-1), nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return whoop.Date{}, newArgumentError("date must be YYYY-MM-DD, today or yesterday, got %q", s)
+	}
+	return whoop.DateOf(t), nil
+}
+
+// dayView is everything recorded on one local calendar day. Sections of
+// kinds that were not fetched are nil and left out; fetched ones are at
+// least empty.
+type dayView struct {
+	Date       string         `json:"date"`
+	Cycles     []cycleView    `json:"cycles,omitzero"`
+	Sleeps     []sleepView    `json:"sleeps,omitzero"`
+	Recoveries []recoveryView `json:"recoveries,omitzero"`
+	Workouts   []workoutView  `json:"workouts,omitzero"`
+	Errors     sectionErrors  `json:"errors,omitempty"`
+}
+
+// fetchDay loads the records of the given kinds on a day, following next
+// tokens through the window. Days are matched in each record's own timezone
+// offset, so the query window is widened by a day on each side to cover
+// offsets that differ from loc.
+func fetchDay(ctx context.Context, session *profileSession, present presenter, day whoop.Date, loc *time.Location, kinds ...string) *dayView {
+	start := time.Date(day.Year, day.Month, day.Day, 0, 0, 0, 0, loc)
+	window := whoop.TimeRange{Start: start.AddDate(0, 0, -1), End: start.AddDate(0, 0, 2)}
+
+	out := &dayView{Date: day.String(), Errors: sectionErrors{}}
+
+	cycleIDs := make(map[int64]bool)
+	if slices.Contains(kinds, "cycle") {
+		out.Cycles = []cycleView{}
+		cycles, err := collectPages(func(next string) ([]whoop.Cycle, *string, error) {
+			resp, err := session.client.GetCycles(ctx, whoop.CycleParams{TimeRange: window, Limit: whoop.MaxLimit, NextToken: next})
+			if err != nil {
+				return nil, nil, err
+			}
+			return resp.Records, resp.NextToken, nil
+		})
+		if err != nil {
+			out.Errors.add("cycles", err)
+		}
+		for _, c := range cycles {
+			if whoop.DateOf(c.LocalStart()) == day {
+				out.Cycles = append(out.Cycles, present.cycle(c))
+				cycleIDs[c.ID] = true
+			}
+		}
+	}
+
+	if slices.Contains(kinds, "sleep") {
+		out.Sleeps = []sleepView{}
+		sleeps, err := collectPages(func(next string) ([]whoop.Sleep, *string, error) {
+			resp, err := session.client.GetSleeps(ctx, whoop.SleepParams{TimeRange: window, Limit: whoop.MaxLimit, NextToken: next})
+			if err != nil {
+				return nil, nil, err
+			}
+			return resp.Records, resp.NextToken, nil
+		})
+		if err != nil {
+			out.Errors.add("sleeps", err)
+		}
+		for _, s := range sleeps {
+			if whoop.DateOf(s.LocalEnd()) == day {
+				out.Sleeps = append(out.Sleeps, present.sleep(s))
+			}
+		}
+	}
+
+	if slices.Contains(kinds, "recovery") {
+		out.Recoveries = []recoveryView{}
+		recoveries, err := collectPages(func(next string) ([]whoop.Recovery, *string, error) {
+			resp, err := session.client.GetRecoveries(ctx, whoop.RecoveryParams{TimeRange: window, Limit: whoop.MaxLimit, NextToken: next})
+			if err != nil {
+				return nil, nil, err
+			}
+			return resp.Records, resp.NextToken, nil
+		})
+		if err != nil {
+			out.Errors.add("recoveries", err)
+		}
+		for _, r := range recoveries {
+			// Recoveries belong to a cycle and carry no offset of their own
+			matched := cycleIDs[r.CycleID]
+			if _, failed := out.Errors["cycles"]; failed || !slices.Contains(kinds, "cycle") {
+				matched = whoop.DateOf(r.CreatedAt.In(loc)) == day
+			}
+			if matched {
+				out.Recoveries = append(out.Recoveries, present.recovery(r))
+			}
+		}
+	}
+
+	if slices.Contains(kinds, "workout") {
+		out.Workouts = []workoutView{}
+		workouts, err := collectPages(func(next string) ([]whoop.WorkoutV2, *string, error) {
+			resp, err := session.client.GetWorkouts(ctx, whoop.WorkoutParams{TimeRange: window, Limit: whoop.MaxLimit, NextToken: next})
+			if err != nil {
+				return nil, nil, err
+			}
+			return resp.Records, resp.NextToken, nil
+		})
+		if err != nil {
+			out.Errors.add("workouts", err)
+		}
+		for _, w := range workouts {
+			if whoop.DateOf(w.LocalStart()) == day {
+				out.Workouts = append(out.Workouts, present.workout(w))
+			}
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/xokvictor/whoop-mcp/pkg/auth"
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+func TestParseResourceURI(t *testing.T) {
+	tests := []struct {
+		uri     string
+		id      string
+		profile string
+		wantErr bool
+	}{
+		{uri: "whoop://cycle/123", id: "123"},
+		{uri: "whoop://sleep/abc-def?profile=work", id: "abc-def", profile: "work"},
+		{uri: "whoop://recent"},
+		{uri: "oauth://config", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			id, profile, err := parseResourceURI(tt.uri)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if id != tt.id || profile != tt.profile {
+				t.Errorf("got (%q, %q), want (%q, %q)", id, profile, tt.id, tt.profile)
+			}
+		})
+	}
+}
+
+func TestParseDay(t *testing.T) {
+	loc := time.FixedZone("UTC-5", -5*60*60)
+	// 02:00 UTC is still the previous evening at UTC-5
+	now := time.Date(2024, 3, 11, 2, 0, 0, 0, time.UTC)
+
+	for in, want := range map[string]string{"today": "2024-03-10", "Yesterday": "2024-03-09", "2024-01-31": "2024-01-31"} {
+		got, err := parseDay(in, loc, now)
+		if err != nil || got.String() != want {
+			t.Errorf("parseDay(%q) = %v, %v; want %s", in, got, err, want)
+		}
+	}
+	if _, err := parseDay("last_week", loc, now); err == nil {
+		t.Error("expected error for a non-day expression")
+	}
+}
+
+// newResourceTestRegistry returns a registry whose active profile talks to
+// server.
+func newResourceTestRegistry(t *testing.T, server *httptest.Server) *profileRegistry {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	registry, err := newProfileRegistry("", "", auth.StaticToken(auth.EnvAccessToken, "test-token"), "")
+	if err != nil {
+		t.Fatalf("newProfileRegistry() error = %v", err)
+	}
+	session, err := registry.Get("")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	session.client.SetBaseURL(server.URL)
+	return registry
+}
+
+func readResource(handler resourceHandler, uri string) (string, error) {
+	var request mcp.ReadResourceRequest
+	request.Params.URI = uri
+	contents, err := handler(context.Background(), request)
+	if err != nil {
+		return "", err
+	}
+	return contents[0].(mcp.TextResourceContents).Text, nil
+}
+
+func TestRecordResource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/cycle/42":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(whoop.Cycle{ID: 42, ScoreState: whoop.ScoreStatePendingScore})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	registry := newResourceTestRegistry(t, server)
+	handler := recordResource(registry, toolOptions{units: whoop.UnitsMetric}, nil, func(ctx context.Context, session *profileSession, present presenter, id string) (interface{}, error) {
+		if id == "0" {
+			return nil, newArgumentError("bad id")
+		}
+		cycle, err := session.client.GetCycleByID(ctx, 42)
+		if err != nil {
+			return nil, err
+		}
+		return present.cycle(*cycle), nil
+	})
+
+	text, err := readResource(handler, "whoop://cycle/42")
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !strings.Contains(text, `"id": 42`) || !strings.Contains(text, `"status": "in_progress"`) {
+		t.Errorf("unexpected contents: %s", text)
+	}
+
+	_, err = readResource(handler, "whoop://cycle/0")
+	if err == nil || !strings.Contains(err.Error(), "error code: validation_error") {
+		t.Errorf("expected coded validation error, got %v", err)
+	}
+
+	_, err = readResource(handler, "whoop://cycle/42?profile=../etc")
+	if err == nil {
+		t.Error("expected error for an invalid profile")
+	}
+}
+
+func TestFixedResourceProfileQuery(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(whoop.PaginatedCycleResponse{Records: []whoop.Cycle{{ID: 7}}})
+	}))
+	defer srv.Close()
+
+	s := server.NewMCPServer("test", "0.0.0", server.WithResourceCapabilities(false, false))
+	registerRecordResources(s, newResourceTestRegistry(t, srv), toolOptions{location: time.UTC, units: whoop.UnitsMetric})
+
+	read := func(uri string) mcp.JSONRPCMessage {
+		request, err := json.Marshal(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"method":  "resources/read",
+			"params":  map[string]interface{}{"uri": uri},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.HandleMessage(context.Background(), request)
+	}
+
+	for _, uri := range []string{"whoop://latest/cycle", "whoop://latest/cycle?profile=" + auth.DefaultProfile} {
+		response, ok := read(uri).(mcp.JSONRPCResponse)
+		if !ok {
+			t.Errorf("%s: expected a response, got %+v", uri, read(uri))
+			continue
+		}
+		contents := response.Result.(mcp.ReadResourceResult).Contents
+		if text := contents[0].(mcp.TextResourceContents).Text; !strings.Contains(text, `"id": 7`) {
+			t.Errorf("%s: unexpected contents: %s", uri, text)
+		}
+	}
+
+	if _, ok := read("whoop://latest/cycles").(mcp.JSONRPCError); !ok {
+		t.Error("expected an error for a URI that only shares the prefix")
+	}
+	if _, ok := read("whoop://latest/cycle?profile=../etc").(mcp.JSONRPCError); !ok {
+		t.Error("expected an error for an invalid profile")
+	}
+}
+
+func TestFetchDay(t *testing.T) {
+	day := whoop.Date{Year: 2024, Month: time.March, Day: 10}
+	at := func(d, h int) time.Time { return time.Date(2024, 3, d, h, 0, 0, 0, time.UTC) }
+	end := at(11, 12)
+
+	var gotStart string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v2/cycle":
+			gotStart = r.URL.Query().Get("start")
+			json.NewEncoder(w).Encode(whoop.PaginatedCycleResponse{Records: []whoop.Cycle{
+				{ID: 1, Start: at(9, 12), End: &end, TimezoneOffset: "-05:00"},
+				{ID: 2, Start: at(10, 12), End: &end, TimezoneOffset: "-05:00"},
+			}})
+		case "/v2/activity/sleep":
+			// The late nap is on the second page
+			if r.URL.Query().Get("nextToken") == "" {
+				next := "page-2"
+				json.NewEncoder(w).Encode(whoop.PaginatedSleepResponse{NextToken: &next, Records: []whoop.Sleep{
+					// Ends 07:00 on the 10th at UTC-5
+					{ID: "night", Start: at(10, 4), End: at(10, 12), TimezoneOffset: "-05:00"},
+				}})
+				return
+			}
+			json.NewEncoder(w).Encode(whoop.PaginatedSleepResponse{Records: []whoop.Sleep{
+				// Ends 23:00 on the 10th at UTC-5, after midnight UTC
+				{ID: "late-nap", Start: at(11, 3), End: at(11, 4), TimezoneOffset: "-05:00", Nap: true},
+				{ID: "other", Start: at(11, 4), End: at(11, 12), TimezoneOffset: "-05:00"},
+			}})
+		case "/v2/recovery":
+			json.NewEncoder(w).Encode(whoop.RecoveryCollection{Records: []whoop.Recovery{
+				{CycleID: 1}, {CycleID: 2},
+			}})
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	registry := newResourceTestRegistry(t, server)
+	session, _ := registry.Get("")
+	view := fetchDay(context.Background(), session, presenter{units: whoop.UnitsMetric}, day, time.UTC, latestKinds...)
+
+	if gotStart != "2024-03-09T00:00:00.000Z" {
+		t.Errorf("query start = %s, want the day before", gotStart)
+	}
+	if len(view.Cycles) != 1 || view.Cycles[0].ID != 2 {
+		t.Errorf("cycles = %+v, want only cycle 2", view.Cycles)
+	}
+	if len(view.Sleeps) != 2 || view.Sleeps[0].ID != "night" || view.Sleeps[1].ID != "late-nap" {
+		t.Errorf("sleeps = %+v, want night and late-nap", view.Sleeps)
+	}
+	if len(view.Recoveries) != 1 || view.Recoveries[0].CycleID != 2 {
+		t.Errorf("recoveries = %+v, want the recovery of cycle 2", view.Recoveries)
+	}
+	if len(view.Workouts) != 0 || !strings.Contains(view.Errors["workouts"], "forbidden_scope") {
+		t.Errorf("expected a workouts section error, got %v", view.Errors)
+	}
+}
+
+func TestFetchProfileResourcePrivacy(t *testing.T) {
+	var bodyRequested bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v2/user/profile/basic":
+			json.NewEncoder(w).Encode(whoop.UserBasicProfile{UserID: 10129, Email: "jane@example.com", FirstName: "Jane", LastName: "Smith"})
+		case "/v2/user/measurement/body":
+			bodyRequested = true
+			json.NewEncoder(w).Encode(whoop.UserBodyMeasurement{HeightMeter: 1.8, WeightKilogram: 80})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	registry := newResourceTestRegistry(t, server)
+	opts := toolOptions{units: whoop.UnitsMetric, privacy: privacyOptions{redactPII: true, hideBody: true}}
+	text, err := readResource(recordResource(registry, opts, nil, opts.fetchProfileResource), "whoop://profile")
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	for _, leaked := range []string{"jane@example.com", "Jane", "Smith", "10129", "body_measurements"} {
+		if strings.Contains(text, leaked) {
+			t.Errorf("profile resource leaks %q in privacy mode: %s", leaked, text)
+		}
+	}
+	if bodyRequested {
+		t.Error("body measurements should not be fetched when privacy mode hides them")
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+// structuredContentKey is the result metadata key under which tool handlers
+// pass their structured result to the transport. mcp-go v0.10 has no
+// structuredContent field, so the transport moves it out of _meta.
+const structuredContentKey = "whoop-mcp/structuredContent"
+
+// toolAnnotations are the MCP hints that tell clients how a tool behaves.
+type toolAnnotations struct {
+	ReadOnlyHint    bool  `json:"readOnlyHint"`
+	DestructiveHint *bool `json:"destructiveHint,omitempty"`
+	IdempotentHint  bool  `json:"idempotentHint"`
+	OpenWorldHint   bool  `json:"openWorldHint"`
+}
+
+// toolInfo is what tools/list publishes for a tool beyond mcp-go's fields.
+type toolInfo struct {
+	annotations toolAnnotations
+	// outputSchema describes structuredContent; nil when the tool returns
+	// only text.
+	outputSchema map[string]interface{}
+}
+
+var (
+	// readOnlyAPI tools only read WHOOP data.
+	readOnlyAPI = toolAnnotations{ReadOnlyHint: true, IdempotentHint: true, OpenWorldHint: true}
+	// readOnlyLocal tools only read local state.
+	readOnlyLocal = toolAnnotations{ReadOnlyHint: true, IdempotentHint: true}
+)
+
+// toolMetadata lists the annotations and output schemas of every tool.
+var toolMetadata = map[string]toolInfo{
+	"get_user_profile":       {readOnlyAPI, outputSchema(whoop.UserBasicProfile{})},
+	"get_body_measurements":  {readOnlyAPI, outputSchema(bodyMeasurementView{})},
+	"get_cycles":             {readOnlyAPI, outputSchema(recordPage[cycleView]{})},
+	"get_cycle_by_id":        {readOnlyAPI, outputSchema(cycleView{})},
+	"get_sleeps":             {readOnlyAPI, outputSchema(recordPage[sleepView]{})},
+	"get_sleep_by_id":        {readOnlyAPI, outputSchema(sleepView{})},
+	"get_sleep_for_cycle":    {readOnlyAPI, outputSchema(sleepView{})},
+	"get_recoveries":         {readOnlyAPI, outputSchema(recordPage[recoveryView]{})},
+	"get_recovery_for_cycle": {readOnlyAPI, outputSchema(recoveryView{})},
+	"get_workouts":           {readOnlyAPI, outputSchema(recordPage[workoutView]{})},
+	"get_workout_by_id":      {readOnlyAPI, outputSchema(workoutView{})},
+	"get_activity_mapping":   {readOnlyAPI, outputSchema(whoop.ActivityIdMappingResponse{})},
+	"whoop_doctor":           {annotations: readOnlyAPI},
+	"whoop_auth_status":      {annotations: readOnlyLocal},
+	"whoop_list_profiles":    {annotations: readOnlyLocal},
+	// Signing in replaces the profile's stored token.
+	"whoop_authorize": {annotations: toolAnnotations{DestructiveHint: boolPtr(false), OpenWorldHint: true}},
+	// Logging out revokes the token on WHOOP and deletes it locally.
+	"whoop_logout":         {annotations: toolAnnotations{DestructiveHint: boolPtr(true), IdempotentHint: true, OpenWorldHint: true}},
+	"whoop_switch_profile": {annotations: toolAnnotations{DestructiveHint: boolPtr(false), IdempotentHint: true}},
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+// outputSchema returns the JSON schema of v's JSON encoding.
+func outputSchema(v interface{}) map[string]interface{} {
+	return jsonSchema(reflect.TypeOf(v))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// jsonSchema derives a JSON schema from a Go type following encoding/json
+// rules: embedded structs are flattened, omitempty fields are optional, and
+// pointers, slices and maps may be null.
+func jsonSchema(t reflect.Type) map[string]interface{} {
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return nullable(jsonSchema(t.Elem()))
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		var required []string
+		addStructFields(t, properties, &required)
+		schema := map[string]interface{}{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	case reflect.Slice, reflect.Array:
+		return nullable(map[string]interface{}{"type": "array", "items": jsonSchema(t.Elem())})
+	case reflect.Map:
+		return nullable(map[string]interface{}{"type": "object", "additionalProperties": jsonSchema(t.Elem())})
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func addStructFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				addStructFields(embedded, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = jsonSchema(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// nullable allows null in addition to the schema's type.
+func nullable(schema map[string]interface{}) map[string]interface{} {
+	if typ, ok := schema["type"].(string); ok {
+		schema["type"] = []string{typ, "null"}
+	}
+	return schema
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/xokvictor/whoop-mcp/pkg/auth"
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+func TestJSONSchema(t *testing.T) {
+	type inner struct {
+		Label string `json:"label"`
+	}
+	type sample struct {
+		inner
+		ID       int64             `json:"id"`
+		At       time.Time         `json:"at"`
+		End      *time.Time        `json:"end,omitempty"`
+		Tags     []string          `json:"tags"`
+		Score    float64           `json:"score,omitempty"`
+		State    whoop.ScoreState  `json:"state"`
+		Extra    map[string]string `json:"extra,omitempty"`
+		Ignored  string            `json:"-"`
+		internal string
+	}
+
+	schema := outputSchema(sample{})
+	got, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"properties":{"at":{"format":"date-time","type":"string"},"end":{"format":"date-time","type":["string","null"]},"extra":{"additionalProperties":{"type":"string"},"type":["object","null"]},"id":{"type":"integer"},"label":{"type":"string"},"score":{"type":"number"},"state":{"type":"string"},"tags":{"items":{"type":"string"},"type":["array","null"]}},"required":["label","id","at","tags","state"],"type":"object"}`
+	if string(got) != want {
+		t.Errorf("schema =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestOutputSchemaMatchesViews(t *testing.T) {
+	props := toolMetadata["get_cycles"].outputSchema["properties"].(map[string]interface{})
+	records := props["records"].(map[string]interface{})["items"].(map[string]interface{})["properties"].(map[string]interface{})
+	for _, field := range []string{"id", "start", "score_state", "status", "status_note", "derived"} {
+		if _, ok := records[field]; !ok {
+			t.Errorf("cycle record schema is missing %q", field)
+		}
+	}
+}
+
+// serveLines runs the transport over the given requests and returns the
+// decoded responses.
+func serveLines(t *testing.T, s *server.MCPServer, requests ...string) []map[string]interface{} {
+	t.Helper()
+	var out bytes.Buffer
+	transport := newStdioTransport(s, newSubscriptions(), nil, nil, &out)
+	if err := transport.Serve(context.Background(), strings.NewReader(strings.Join(requests, "\n")+"\n")); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	var responses []map[string]interface{}
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var response map[string]interface{}
+		if err := dec.Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		responses = append(responses, response)
+	}
+	return responses
+}
+
+func TestToolsListAnnotations(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	profiles, err := newProfileRegistry("id", "secret", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := &auth.Credentials{ClientID: "id", ClientSecret: "secret"}
+
+	s := server.NewMCPServer("test", "0.0.0")
+	registerTools(s, profiles, toolOptions{location: time.UTC, units: whoop.UnitsMetric})
+	registerAuthTools(s, profiles, creds)
+	registerProfileTools(s, profiles)
+	registerDoctorTool(s, profiles, creds, defaultConfig())
+
+	responses := serveLines(t, s, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	result := responses[0]["result"].(map[string]interface{})
+	tools := result["tools"].([]interface{})
+	if len(tools) != len(toolMetadata) {
+		t.Errorf("server has %d tools, toolMetadata has %d", len(tools), len(toolMetadata))
+	}
+
+	for _, raw := range tools {
+		tool := raw.(map[string]interface{})
+		name := tool["name"].(string)
+		if _, ok := toolMetadata[name]; !ok {
+			t.Errorf("tool %s has no metadata", name)
+			continue
+		}
+		annotations := tool["annotations"].(map[string]interface{})
+		readOnly := annotations["readOnlyHint"].(bool)
+		if strings.HasPrefix(name, "get_") && (!readOnly || tool["outputSchema"] == nil) {
+			t.Errorf("%s should be read-only with an output schema: %v", name, tool)
+		}
+		if name == "whoop_authorize" && readOnly {
+			t.Error("whoop_authorize must not be read-only")
+		}
+		if tool["inputSchema"] == nil {
+			t.Errorf("%s lost its input schema", name)
+		}
+	}
+}
+
+func TestStructuredContent(t *testing.T) {
+	end := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(whoop.PaginatedCycleResponse{Records: []whoop.Cycle{{ID: 9, End: &end, ScoreState: whoop.ScoreStateUnscorable}}})
+	}))
+	defer srv.Close()
+
+	s := server.NewMCPServer("test", "0.0.0")
+	registerTools(s, newResourceTestRegistry(t, srv), toolOptions{location: time.UTC, units: whoop.UnitsMetric})
+
+	responses := serveLines(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_cycles","arguments":{"format":"markdown"}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"get_cycles","arguments":{"format":"xml"}}}`,
+	)
+
+	result := responses[0]["result"].(map[string]interface{})
+	if _, ok := result["_meta"]; ok {
+		t.Errorf("structured content should be moved out of _meta: %v", result["_meta"])
+	}
+	structured, ok := result["structuredContent"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("missing structuredContent: %v", result)
+	}
+	records := structured["records"].([]interface{})
+	record := records[0].(map[string]interface{})
+	if record["id"] != float64(9) || record["status"] != "unscorable" {
+		t.Errorf("unexpected structured record %v", record)
+	}
+	text := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	if !strings.HasPrefix(text, "| id |") {
+		t.Errorf("text content should keep the requested format, got %q", text)
+	}
+
+	errResult := responses[1]["result"].(map[string]interface{})
+	if errResult["isError"] != true || errResult["structuredContent"] != nil {
+		t.Errorf("errors should have no structured content: %v", errResult)
+	}
+	if code := errResult["_meta"].(map[string]interface{})["error"].(map[string]interface{})["code"]; code != whoop.CodeValidation {
+		t.Errorf("error details lost: %v", errResult["_meta"])
+	}
+}
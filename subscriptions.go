@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+// pollLimit is how many of the newest records of each kind the poller
+// compares between polls.
+const pollLimit = 10
+
+// subscriptions is the set of resource URIs the client subscribed to.
+type subscriptions struct {
+	mu   sync.Mutex
+	uris map[string]bool
+}
+
+func newSubscriptions() *subscriptions {
+	return &subscriptions{uris: make(map[string]bool)}
+}
+
+func (s *subscriptions) Add(uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.uris[uri] = true
+}
+
+func (s *subscriptions) Remove(uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.uris, uri)
+}
+
+func (s *subscriptions) Has(uri string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.uris[uri]
+}
+
+func (s *subscriptions) Empty() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.uris) == 0
+}
+
+// recordChange describes a record that appeared or changed between polls.
+type recordChange struct {
+	Kind      string `json:"kind"`
+	RecordURI string `json:"record_uri"`
+	// Change is "created", "scored" or "updated".
+	Change string             `json:"change"`
+	Status whoop.RecordStatus `json:"status"`
+}
+
+// recordState is what the poller remembers about a record.
+type recordState struct {
+	status    whoop.RecordStatus
+	updatedAt time.Time
+}
+
+// snapshot maps record URIs to their state for one kind.
+type snapshot map[string]recordState
+
+// diffSnapshots returns the changes from prev to next, sorted by record
+// URI. Records that dropped out of the window are not changes.
+func diffSnapshots(kind string, prev, next snapshot) []recordChange {
+	var changes []recordChange
+	for uri, state := range next {
+		old, existed := prev[uri]
+		change := ""
+		switch {
+		case !existed:
+			change = "created"
+		case state.status == whoop.StatusScored && old.status != whoop.StatusScored:
+			change = "scored"
+		case state.status != old.status:
+			change = "updated"
+		case state.status == whoop.StatusScored && !state.updatedAt.Equal(old.updatedAt):
+			// Rescored. Unscored records, notably the current cycle, are
+			// updated continuously and not reported until scored.
+			change = "updated"
+		default:
+			continue
+		}
+		changes = append(changes, recordChange{Kind: kind, RecordURI: uri, Change: change, Status: state.status})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].RecordURI < changes[j].RecordURI })
+	return changes
+}
+
+// notifyFunc sends a notification to the client.
+type notifyFunc func(method string, params map[string]interface{})
+
+// poller detects new and newly scored WHOOP records for the active profile
+// and sends notifications/resources/updated for subscribed resources. It
+// only calls the API while the client has subscriptions.
+type poller struct {
+	profiles *profileRegistry
+	subs     *subscriptions
+	notify   notifyFunc
+	interval time.Duration
+	// kinds are the record kinds to poll, those whose tools are enabled.
+	kinds []string
+
+	// snapshots holds the last poll per kind. It is reset when the client
+	// has no subscriptions or the active profile changes, so the next poll
+	// sets a new baseline rather than reporting old records.
+	snapshots map[string]snapshot
+	profile   string
+}
+
+func newPoller(profiles *profileRegistry, subs *subscriptions, notify notifyFunc, interval time.Duration, kinds []string) *poller {
+	return &poller{
+		profiles:  profiles,
+		subs:      subs,
+		notify:    notify,
+		interval:  interval,
+		kinds:     kinds,
+		snapshots: make(map[string]snapshot),
+	}
+}
+
+// Run polls every interval until ctx is cancelled.
+func (p *poller) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.poll(ctx)
+		}
+	}
+}
+
+// poll fetches the newest records of each kind and notifies about changes.
+func (p *poller) poll(ctx context.Context) {
+	if p.subs.Empty() {
+		p.snapshots = make(map[string]snapshot)
+		return
+	}
+
+	ctx = whoop.WithRequestID(ctx, newRequestID())
+	session, err := p.profiles.Get("")
+	if err != nil {
+		slog.WarnContext(ctx, "poller: resolving profile", "error", err)
+		return
+	}
+	if session.name != p.profile {
+		p.snapshots = make(map[string]snapshot)
+		p.profile = session.name
+	}
+
+	for _, kind := range p.kinds {
+		if missing := missingScopes(session.GrantedScopes(), toolScopes[kindTools[kind]]); len(missing) > 0 {
+			continue
+		}
+		next, err := fetchSnapshot(ctx, session.client, kind)
+		if err != nil {
+			slog.WarnContext(ctx, "poller: fetching latest records", "kind", kind, "error", err)
+			continue
+		}
+
+		prev, ok := p.snapshots[kind]
+		p.snapshots[kind] = next
+		if !ok {
+			continue
+		}
+		for _, change := range diffSnapshots(kind, prev, next) {
+			p.publish(change)
+		}
+	}
+}
+
+// publish notifies every subscribed resource the change affects.
+func (p *poller) publish(change recordChange) {
+	slog.Info("poller: WHOOP data changed", "uri", change.RecordURI, "change", change.Change, "status", change.Status)
+
+	for _, uri := range []string{"whoop://latest/" + change.Kind, "whoop://today", "whoop://recent", change.RecordURI} {
+		if !p.subs.Has(uri) {
+			continue
+		}
+		p.notify("notifications/resources/updated", map[string]interface{}{
+			"uri": uri,
+			"_meta": map[string]interface{}{
+				"kind":       change.Kind,
+				"record_uri": change.RecordURI,
+				"change":     change.Change,
+				"status":     change.Status,
+			},
+		})
+	}
+}
+
+func fetchSnapshot(ctx context.Context, client *whoop.Client, kind string) (snapshot, error) {
+	snap := make(snapshot)
+	switch kind {
+	case "cycle":
+		resp, err := client.GetCycles(ctx, whoop.CycleParams{Limit: pollLimit})
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range resp.Records {
+			snap[fmt.Sprintf("whoop://cycle/%d", c.ID)] = recordState{c.Status(), c.UpdatedAt}
+		}
+	case "sleep":
+		resp, err := client.GetSleeps(ctx, whoop.SleepParams{Limit: pollLimit})
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range resp.Records {
+			snap["whoop://sleep/"+s.ID] = recordState{s.Status(), s.UpdatedAt}
+		}
+	case "recovery":
+		resp, err := client.GetRecoveries(ctx, whoop.RecoveryParams{Limit: pollLimit})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range resp.Records {
+			snap[fmt.Sprintf("whoop://recovery/%d", r.CycleID)] = recordState{r.Status(), r.UpdatedAt}
+		}
+	case "workout":
+		resp, err := client.GetWorkouts(ctx, whoop.WorkoutParams{Limit: pollLimit})
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range resp.Records {
+			snap["whoop://workout/"+w.ID] = recordState{w.Status(), w.UpdatedAt}
+		}
+	default:
+		return nil, fmt.Errorf("unknown record kind %q", kind)
+	}
+	return snap, nil
+}
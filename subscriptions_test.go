@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	t0 := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Hour)
+
+	prev := snapshot{
+		"whoop://sleep/a": {whoop.StatusPendingScore, t0},
+		"whoop://sleep/b": {whoop.StatusScored, t0},
+		"whoop://sleep/c": {whoop.StatusScored, t0},
+		"whoop://sleep/d": {whoop.StatusPendingScore, t0},
+		"whoop://sleep/e": {whoop.StatusPendingScore, t0},
+		"whoop://sleep/f": {whoop.StatusScored, t0},
+	}
+	next := snapshot{
+		"whoop://sleep/a": {whoop.StatusScored, t1},
+		"whoop://sleep/b": {whoop.StatusScored, t1},
+		"whoop://sleep/c": {whoop.StatusScored, t0},
+		"whoop://sleep/d": {whoop.StatusUnscorable, t1},
+		"whoop://sleep/e": {whoop.StatusPendingScore, t1},
+		"whoop://sleep/g": {whoop.StatusPendingScore, t1},
+	}
+
+	got := diffSnapshots("sleep", prev, next)
+	want := []recordChange{
+		{Kind: "sleep", RecordURI: "whoop://sleep/a", Change: "scored", Status: whoop.StatusScored},
+		{Kind: "sleep", RecordURI: "whoop://sleep/b", Change: "updated", Status: whoop.StatusScored},
+		{Kind: "sleep", RecordURI: "whoop://sleep/d", Change: "updated", Status: whoop.StatusUnscorable},
+		{Kind: "sleep", RecordURI: "whoop://sleep/g", Change: "created", Status: whoop.StatusPendingScore},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d changes %+v, want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("change %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+type recordedNotification struct {
+	method string
+	params map[string]interface{}
+}
+
+func TestPollerNotifiesSubscribers(t *testing.T) {
+	var mu sync.Mutex
+	recovery := whoop.Recovery{CycleID: 7, ScoreState: whoop.ScoreStatePendingScore}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v2/recovery":
+			json.NewEncoder(w).Encode(whoop.RecoveryCollection{Records: []whoop.Recovery{recovery}})
+		case "/v2/cycle":
+			json.NewEncoder(w).Encode(whoop.PaginatedCycleResponse{})
+		case "/v2/activity/sleep":
+			json.NewEncoder(w).Encode(whoop.PaginatedSleepResponse{})
+		case "/v2/activity/workout":
+			json.NewEncoder(w).Encode(whoop.WorkoutCollection{})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	var notifications []recordedNotification
+	subs := newSubscriptions()
+	p := newPoller(newResourceTestRegistry(t, server), subs, func(method string, params map[string]interface{}) {
+		notifications = append(notifications, recordedNotification{method, params})
+	}, time.Minute, latestKinds)
+	ctx := context.Background()
+
+	// Without subscriptions the poller does not set a baseline
+	p.poll(ctx)
+	if len(p.snapshots) != 0 {
+		t.Fatalf("expected no snapshots without subscriptions, got %v", p.snapshots)
+	}
+
+	subs.Add("whoop://latest/recovery")
+	subs.Add("whoop://latest/sleep")
+	p.poll(ctx)
+	if len(notifications) != 0 {
+		t.Fatalf("baseline poll sent %+v", notifications)
+	}
+
+	mu.Lock()
+	recovery.ScoreState = whoop.ScoreStateScored
+	recovery.Score = &whoop.RecoveryScore{RecoveryScore: 64}
+	mu.Unlock()
+	p.poll(ctx)
+
+	if len(notifications) != 1 {
+		t.Fatalf("got %d notifications %+v, want 1", len(notifications), notifications)
+	}
+	n := notifications[0]
+	if n.method != "notifications/resources/updated" || n.params["uri"] != "whoop://latest/recovery" {
+		t.Errorf("unexpected notification %+v", n)
+	}
+	meta, _ := n.params["_meta"].(map[string]interface{})
+	if meta["record_uri"] != "whoop://recovery/7" || meta["change"] != "scored" || meta["status"] != whoop.StatusScored {
+		t.Errorf("unexpected _meta %+v", meta)
+	}
+
+	// Nothing changed since the last poll
+	p.poll(ctx)
+	if len(notifications) != 1 {
+		t.Errorf("unchanged poll sent %+v", notifications[1:])
+	}
+}
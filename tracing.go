@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// tracerName identifies the spans of MCP requests.
+const tracerName = "github.com/xokvictor/whoop-mcp"
+
+// enabled reports whether traces are exported: the endpoint is configured,
+// here or through the standard OTLP environment variables.
+func (t tracingConfig) enabled() bool {
+	return t.Endpoint != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// setupTracing installs a tracer provider exporting spans over OTLP/HTTP
+// when tracing is enabled. Otherwise the global provider stays a no-op.
+// The returned function flushes pending spans and stops the exporter.
+func setupTracing(ctx context.Context, cfg tracingConfig) (func(context.Context) error, error) {
+	if !cfg.enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var opts []otlptracehttp.Option
+	if cfg.Endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating OTLP exporter: %w", err)
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			attribute.String("service.name", serverName),
+			attribute.String("service.version", serverVersion),
+		),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("describing tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// redactedArguments returns tool arguments as JSON for a span attribute,
+// with sensitive arguments replaced and tokens and email addresses removed
+// from the rest, as in the logs.
+func redactedArguments(args map[string]interface{}) string {
+	redacted := make(map[string]interface{}, len(args))
+	for key, value := range args {
+		if sensitiveLogKeys[key] {
+			value = redactedValue
+		}
+		redacted[key] = value
+	}
+	data, err := json.Marshal(redacted)
+	if err != nil {
+		return ""
+	}
+	return redactLogString(string(data))
+}
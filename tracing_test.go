@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+// recordSpans installs a tracer provider that keeps finished spans in
+// memory for the duration of the test.
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return exporter
+}
+
+func spanAttrs(span tracetest.SpanStub) map[attribute.Key]string {
+	attrs := make(map[attribute.Key]string)
+	for _, kv := range span.Attributes {
+		attrs[kv.Key] = kv.Value.Emit()
+	}
+	return attrs
+}
+
+func TestRedactedArguments(t *testing.T) {
+	got := redactedArguments(map[string]interface{}{
+		"profile": "work",
+		"token":   "secret",
+		"note":    "from jane@example.com",
+		"limit":   10,
+	})
+	want := `{"limit":10,"note":"from [redacted]","profile":"work","token":"[redacted]"}`
+	if got != want {
+		t.Errorf("redactedArguments() = %s, want %s", got, want)
+	}
+}
+
+func TestSetupTracing(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	shutdown, err := setupTracing(context.Background(), tracingConfig{})
+	if err != nil {
+		t.Fatalf("setupTracing() error = %v", err)
+	}
+	if otel.GetTracerProvider() != previous {
+		t.Error("tracing without an endpoint should leave the no-op provider in place")
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown() error = %v", err)
+	}
+
+	var exports atomic.Int32
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/traces" {
+			exports.Add(1)
+		}
+	}))
+	defer collector.Close()
+
+	shutdown, err = setupTracing(context.Background(), tracingConfig{Endpoint: collector.URL})
+	if err != nil {
+		t.Fatalf("setupTracing() error = %v", err)
+	}
+	_, span := otel.Tracer(tracerName).Start(context.Background(), "test")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown() error = %v", err)
+	}
+	if got := exports.Load(); got != 1 {
+		t.Errorf("collector received %d exports, want 1", got)
+	}
+}
+
+func TestTransportTracing(t *testing.T) {
+	exporter := recordSpans(t)
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"user_id": 1}`))
+	}))
+	defer api.Close()
+	client := whoop.NewClientWithToken("token")
+	client.SetBaseURL(api.URL)
+
+	s := server.NewMCPServer("test", "0.0.0")
+	s.AddTool(mcp.NewTool("get_user_profile"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if _, err := client.GetUserProfile(ctx); err != nil {
+			return errorResult(err), nil
+		}
+		return mcp.NewToolResultText("ok"), nil
+	})
+	s.AddTool(mcp.NewTool("get_cycles"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return errorResult(newArgumentError("limit: must be at most 25")), nil
+	})
+	var out strings.Builder
+	transport := newStdioTransport(s, newSubscriptions(), nil, nil, &out)
+
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_user_profile","arguments":{"profile":"jane@example.com"}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"get_cycles","arguments":{"limit":50}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"ping"}`,
+	}, "\n") + "\n"
+	if err := transport.Serve(context.Background(), strings.NewReader(in)); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3: the API request and two tool calls", len(spans))
+	}
+	request, profileCall, cyclesCall := spans[0], spans[1], spans[2]
+
+	if profileCall.Name != "tools/call get_user_profile" {
+		t.Errorf("tool span = %s", profileCall.Name)
+	}
+	attrs := spanAttrs(profileCall)
+	if attrs["gen_ai.tool.name"] != "get_user_profile" || attrs["gen_ai.tool.call.arguments"] != `{"profile":"[redacted]"}` || attrs["whoop_mcp.request_id"] == "" {
+		t.Errorf("tool span attributes = %v", attrs)
+	}
+	if request.Name != "GET /v2/user/profile/basic" || request.Parent.SpanID() != profileCall.SpanContext.SpanID() {
+		t.Errorf("API span %s should be a child of the tool call span", request.Name)
+	}
+
+	if cyclesCall.Status.Code != codes.Error || spanAttrs(cyclesCall)["error.type"] != whoop.CodeValidation {
+		t.Errorf("failed tool span status = %v, attributes = %v", cyclesCall.Status, cyclesCall.Attributes)
+	}
+}
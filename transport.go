@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/xokvictor/whoop-mcp/pkg/whoop"
+)
+
+// stdioTransport serves the MCP server over stdin/stdout. It replaces
+// server.ServeStdio because mcp-go v0.10 does not route
+// resources/subscribe, resources/unsubscribe and logging/setLevel, and gives
+// the rest of the server a way to send its own notifications.
+type stdioTransport struct {
+	server *server.MCPServer
+	subs   *subscriptions
+	// logs receives logging/setLevel and sends notifications/message; nil
+	// when the client log is not offered.
+	logs *clientLog
+	// metrics records tool calls; nil when metrics are disabled.
+	metrics *metrics
+
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func newStdioTransport(s *server.MCPServer, subs *subscriptions, logs *clientLog, m *metrics, out io.Writer) *stdioTransport {
+	t := &stdioTransport{server: s, subs: subs, logs: logs, metrics: m, out: out}
+	if logs != nil {
+		logs.attach(t.notify("notifications/message"))
+	}
+	return t
+}
+
+// Serve handles messages from in, one per line, until in is closed or ctx
+// is cancelled.
+func (t *stdioTransport) Serve(ctx context.Context, in io.Reader) error {
+	ctx = t.server.WithContext(ctx, server.NotificationContext{ClientID: "stdio", SessionID: "stdio"})
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		reader := bufio.NewReader(in)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-readErr:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("reading input: %w", err)
+		case line := <-lines:
+			if err := t.handle(ctx, line); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// handle processes a single JSON-RPC message and writes any response.
+func (t *stdioTransport) handle(ctx context.Context, line []byte) error {
+	var message struct {
+		ID     interface{} `json:"id"`
+		Method string      `json:"method"`
+		Params struct {
+			URI   string `json:"uri"`
+			Level string `json:"level"`
+			Name  string `json:"name"`
+			// Arguments are those of a tools/call request
+			Arguments map[string]interface{} `json:"arguments"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(line, &message); err != nil {
+		return t.write(rpcError(nil, mcp.PARSE_ERROR, "Parse error"))
+	}
+
+	// Every request gets a correlation ID for the log records it causes
+	if message.ID != nil {
+		ctx = whoop.WithRequestID(ctx, newRequestID())
+		start := time.Now()
+		slog.DebugContext(ctx, "handling request", "method", message.Method)
+		defer func() {
+			slog.DebugContext(ctx, "request done", "method", message.Method, "duration", time.Since(start))
+		}()
+	}
+
+	switch message.Method {
+	case "logging/setLevel":
+		if t.logs == nil {
+			return t.write(rpcError(message.ID, mcp.METHOD_NOT_FOUND, "Method not found"))
+		}
+		level, ok := parseMCPLogLevel(message.Params.Level)
+		if !ok {
+			return t.write(rpcError(message.ID, mcp.INVALID_PARAMS, fmt.Sprintf("unknown logging level %q", message.Params.Level)))
+		}
+		t.logs.SetLevel(level)
+		return t.write(mcp.JSONRPCResponse{
+			JSONRPC: mcp.JSONRPC_VERSION,
+			ID:      message.ID,
+			Result:  mcp.EmptyResult{},
+		})
+	case "resources/subscribe", "resources/unsubscribe":
+		if _, _, err := parseResourceURI(message.Params.URI); err != nil {
+			return t.write(rpcError(message.ID, mcp.INVALID_PARAMS, err.Error()))
+		}
+		if message.Method == "resources/subscribe" {
+			t.subs.Add(message.Params.URI)
+		} else {
+			t.subs.Remove(message.Params.URI)
+		}
+		return t.write(mcp.JSONRPCResponse{
+			JSONRPC: mcp.JSONRPC_VERSION,
+			ID:      message.ID,
+			Result:  mcp.EmptyResult{},
+		})
+	case "tools/call":
+		return t.handleToolCall(ctx, line, message.Params.Name, message.Params.Arguments)
+	}
+
+	if response := t.server.HandleMessage(ctx, line); response != nil {
+		return t.write(decorateResponse(message.Method, response))
+	}
+	return nil
+}
+
+// handleToolCall handles a tools/call request in a span of its own, parent
+// of the WHOOP API request spans, and records it in the metrics. Calls the
+// server rejected before reaching a tool, such as for an unknown tool, are
+// not counted.
+func (t *stdioTransport) handleToolCall(ctx context.Context, line []byte, name string, args map[string]interface{}) error {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "tools/call "+name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("mcp.method.name", "tools/call"),
+			attribute.String("gen_ai.tool.name", name),
+			attribute.String("gen_ai.tool.call.arguments", redactedArguments(args)),
+			attribute.String("whoop_mcp.request_id", whoop.RequestID(ctx)),
+		),
+	)
+	defer span.End()
+
+	start := time.Now()
+	response := t.server.HandleMessage(ctx, line)
+	elapsed := time.Since(start)
+
+	switch resp := response.(type) {
+	case mcp.JSONRPCResponse:
+		if result, ok := resp.Result.(*mcp.CallToolResult); ok {
+			t.metrics.ObserveTool(name, elapsed, result)
+			if result.IsError {
+				outcome := toolOutcome(result)
+				span.SetAttributes(attribute.String("error.type", outcome))
+				span.SetStatus(codes.Error, outcome)
+			}
+		}
+	case mcp.JSONRPCError:
+		span.SetStatus(codes.Error, resp.Error.Message)
+	}
+
+	if response != nil {
+		return t.write(decorateResponse("tools/call", response))
+	}
+	return nil
+}
+
+// decorateResponse adds what mcp-go v0.10 cannot express: tool annotations
+// and output schemas in tools/list, and structuredContent in tool results.
+func decorateResponse(method string, response mcp.JSONRPCMessage) mcp.JSONRPCMessage {
+	resp, ok := response.(mcp.JSONRPCResponse)
+	if !ok {
+		return response
+	}
+
+	switch result := resp.Result.(type) {
+	case mcp.ListToolsResult:
+		if method != "tools/list" {
+			return response
+		}
+		tools := make([]interface{}, len(result.Tools))
+		for i, tool := range result.Tools {
+			tools[i] = describedTool{Tool: tool, info: toolMetadata[tool.Name]}
+		}
+		resp.Result = struct {
+			mcp.PaginatedResult
+			Tools []interface{} `json:"tools"`
+		}{result.PaginatedResult, tools}
+	case *mcp.CallToolResult:
+		structured, ok := result.Meta[structuredContentKey]
+		if !ok {
+			return response
+		}
+		copied := *result
+		copied.Meta = make(map[string]interface{}, len(result.Meta))
+		for k, v := range result.Meta {
+			if k != structuredContentKey {
+				copied.Meta[k] = v
+			}
+		}
+		if len(copied.Meta) == 0 {
+			copied.Meta = nil
+		}
+		resp.Result = struct {
+			*mcp.CallToolResult
+			StructuredContent interface{} `json:"structuredContent"`
+		}{&copied, structured}
+	}
+	return resp
+}
+
+// describedTool is a tool with its annotations and output schema.
+type describedTool struct {
+	mcp.Tool
+	info toolInfo
+}
+
+func (d describedTool) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(d.Tool)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	fields["annotations"] = d.info.annotations
+	if d.info.outputSchema != nil {
+		fields["outputSchema"] = d.info.outputSchema
+	}
+	return json.Marshal(fields)
+}
+
+// Notify sends a notification to the client.
+func (t *stdioTransport) Notify(method string, params map[string]interface{}) {
+	if err := t.notify(method)(params); err != nil {
+		slog.Error("writing notification", "method", method, "error", err)
+	}
+}
+
+// notify returns a function that sends notifications of the given method.
+func (t *stdioTransport) notify(method string) func(params map[string]interface{}) error {
+	return func(params map[string]interface{}) error {
+		return t.write(mcp.JSONRPCNotification{
+			JSONRPC: mcp.JSONRPC_VERSION,
+			Notification: mcp.Notification{
+				Method: method,
+				Params: mcp.NotificationParams{AdditionalFields: params},
+			},
+		})
+	}
+}
+
+// write marshals message as one line. Responses and notifications come
+// from different goroutines, so writes are serialized.
+func (t *stdioTransport) write(message interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("marshaling message: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, err := fmt.Fprintf(t.out, "%s\n", data); err != nil {
+		return fmt.Errorf("writing message: %w", err)
+	}
+	return nil
+}
+
+func rpcError(id interface{}, code int, message string) mcp.JSONRPCError {
+	var rpcErr mcp.JSONRPCError
+	rpcErr.JSONRPC = mcp.JSONRPC_VERSION
+	rpcErr.ID = id
+	rpcErr.Error.Code = code
+	rpcErr.Error.Message = message
+	return rpcErr
+}
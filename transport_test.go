@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestStdioTransport(t *testing.T) {
+	s := server.NewMCPServer("test", "0.0.0", server.WithResourceCapabilities(true, false))
+	subs := newSubscriptions()
+	var out bytes.Buffer
+	transport := newStdioTransport(s, subs, nil, nil, &out)
+
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"whoop://latest/sleep"}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"resources/subscribe","params":{"uri":"https://example.com"}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"ping"}`,
+		`not json`,
+		`{"jsonrpc":"2.0","id":4,"method":"resources/subscribe","params":{"uri":"whoop://today"}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"resources/unsubscribe","params":{"uri":"whoop://today"}}`,
+	}, "\n") + "\n"
+	if err := transport.Serve(context.Background(), strings.NewReader(in)); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+	transport.Notify("notifications/resources/updated", map[string]interface{}{"uri": "whoop://latest/sleep"})
+
+	var responses []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var response map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &response); err != nil {
+			t.Fatalf("invalid output line %q: %v", line, err)
+		}
+		responses = append(responses, response)
+	}
+	if len(responses) != 7 {
+		t.Fatalf("got %d messages, want 7:\n%s", len(responses), out.String())
+	}
+
+	for i, wantErr := range []bool{false, true, false, true, false, false} {
+		_, hasErr := responses[i]["error"]
+		if hasErr != wantErr {
+			t.Errorf("message %d: error = %v, want %v: %v", i, hasErr, wantErr, responses[i])
+		}
+	}
+	if responses[6]["method"] != "notifications/resources/updated" {
+		t.Errorf("expected a notification last, got %v", responses[6])
+	}
+	params, _ := responses[6]["params"].(map[string]interface{})
+	if params["uri"] != "whoop://latest/sleep" {
+		t.Errorf("notification params = %v", params)
+	}
+
+	if !subs.Has("whoop://latest/sleep") || subs.Has("whoop://today") {
+		t.Errorf("unexpected subscriptions %v", subs.uris)
+	}
+}